@@ -138,7 +138,7 @@ export async function GET(req: Request) {
 
     // Ambil soal berdasarkan mataPelajaranId ujian
     const soal = await prisma.soal.findMany({
-      where: { mataPelajaranId: ujian.mataPelajaranId },
+      where: { mataPelajaranId: ujian.mataPelajaranId, deletedAt: null },
       include: { Jawaban: true },
     });
 
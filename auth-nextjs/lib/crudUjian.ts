@@ -354,10 +354,29 @@ export const toUjian = async (prevState: unknown, formdata: FormData) => {
       };
     }
 
+    // ✅ 5. Catat mulai ujian di backend, submit dan autosave ditolak sebelum siswa memulai
+    const API_URL = process.env.NEXT_PUBLIC_API_URL_GOLANG;
+    const response = await fetch(`${API_URL}/api/ujian/${ujian.id}/start`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ token, siswaDetailId: siswaDetail.id }),
+    });
+    const mulai = await response.json();
+
+    if (!response.ok) {
+      return {
+        error: true,
+        message: mulai.message || "Gagal memulai ujian",
+        status: response.status,
+      };
+    }
+
     // Jika token valid dan semua syarat terpenuhi, kembalikan data ujian
     return {
       success: true,
       data: ujian,
+      batasWaktu: mulai.batasWaktu,
+      sisaDetik: mulai.sisaDetik,
       status: 200,
     };
   } catch (error) {
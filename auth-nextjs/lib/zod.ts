@@ -280,7 +280,8 @@ export const AddSoalSchema = z.object({
               benar: z.boolean(),
             })
           )
-          .length(5, { message: "Harus ada tepat 5 pilihan jawaban" })
+          .min(2, { message: "Minimal harus ada 2 pilihan jawaban" })
+          .max(6, { message: "Maksimal 6 pilihan jawaban" })
           .refine((pilihan) => pilihan.some((p) => p.benar), {
             message: "Jawaban benar harus di pilih",
           }),
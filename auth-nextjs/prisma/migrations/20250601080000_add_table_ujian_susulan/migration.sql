@@ -0,0 +1,14 @@
+-- CreateTable
+CREATE TABLE "ujian_susulan" (
+    "id" TEXT NOT NULL,
+    "ujianId" TEXT NOT NULL,
+    "tingkat" "Tingkat" NOT NULL,
+    "sesiId" TEXT,
+    "waktuDibuat" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "waktuBerakhir" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "ujian_susulan_pkey" PRIMARY KEY ("id")
+);
+
+-- AddForeignKey
+ALTER TABLE "ujian_susulan" ADD CONSTRAINT "ujian_susulan_ujianId_fkey" FOREIGN KEY ("ujianId") REFERENCES "ujian"("id") ON DELETE CASCADE ON UPDATE CASCADE;
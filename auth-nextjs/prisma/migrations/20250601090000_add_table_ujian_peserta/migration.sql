@@ -0,0 +1,18 @@
+-- CreateTable
+CREATE TABLE "ujian_peserta" (
+    "id" TEXT NOT NULL,
+    "ujianId" TEXT NOT NULL,
+    "siswaDetailId" TEXT NOT NULL,
+    "waktuMulai" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "ujian_peserta_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "ujian_peserta_siswaDetailId_ujianId_key" ON "ujian_peserta"("siswaDetailId", "ujianId");
+
+-- AddForeignKey
+ALTER TABLE "ujian_peserta" ADD CONSTRAINT "ujian_peserta_ujianId_fkey" FOREIGN KEY ("ujianId") REFERENCES "ujian"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "ujian_peserta" ADD CONSTRAINT "ujian_peserta_siswaDetailId_fkey" FOREIGN KEY ("siswaDetailId") REFERENCES "siswa_detail"("id") ON DELETE CASCADE ON UPDATE CASCADE;
@@ -0,0 +1,10 @@
+-- Buang jawaban ganda untuk soal yang sama, sisakan yang paling baru
+DELETE FROM "jawaban_siswa" a
+USING "jawaban_siswa" b
+WHERE a."siswaDetailId" = b."siswaDetailId"
+  AND a."ujianId" = b."ujianId"
+  AND a."soalId" = b."soalId"
+  AND (a."createdAt", a."id") < (b."createdAt", b."id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "jawaban_siswa_siswaDetailId_ujianId_soalId_key" ON "jawaban_siswa"("siswaDetailId", "ujianId", "soalId");
@@ -0,0 +1,3 @@
+-- AlterTable
+ALTER TABLE "hasil" ADD COLUMN "idempotencyKey" TEXT,
+ADD COLUMN "jawabanHash" TEXT;
@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "hasil" ADD COLUMN "kosong" TEXT NOT NULL DEFAULT '0';
@@ -0,0 +1,11 @@
+-- AlterTable
+ALTER TABLE "soal" ADD COLUMN "bobot" INTEGER NOT NULL DEFAULT 1;
+
+-- AlterTable
+ALTER TABLE "mata_pelajaran" ADD COLUMN "penaltiSalah" DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- AlterTable
+ALTER TABLE "ujian" ADD COLUMN "penaltiSalah" DOUBLE PRECISION;
+
+-- AlterTable
+ALTER TABLE "hasil" ADD COLUMN "rincianNilai" JSONB;
@@ -0,0 +1,14 @@
+-- CreateEnum
+CREATE TYPE "TipeSoal" AS ENUM ('PILIHAN_GANDA', 'PILIHAN_GANDA_KOMPLEKS');
+
+-- AlterTable
+ALTER TABLE "soal" ADD COLUMN "tipe" "TipeSoal" NOT NULL DEFAULT 'PILIHAN_GANDA';
+
+-- AlterTable
+ALTER TABLE "mata_pelajaran" ADD COLUMN "nilaiParsial" BOOLEAN NOT NULL DEFAULT false;
+
+-- AlterTable
+ALTER TABLE "ujian" ADD COLUMN "nilaiParsial" BOOLEAN;
+
+-- AlterTable
+ALTER TABLE "jawaban_siswa" ADD COLUMN "jawabanIds" TEXT[] DEFAULT ARRAY[]::TEXT[];
@@ -0,0 +1,7 @@
+-- AlterEnum
+ALTER TYPE "TipeSoal" ADD VALUE 'ISIAN_SINGKAT';
+ALTER TYPE "TipeSoal" ADD VALUE 'ESAI';
+
+-- AlterTable
+ALTER TABLE "jawaban_siswa" ADD COLUMN "jawabanTeks" TEXT,
+ADD COLUMN "skorManual" DOUBLE PRECISION;
@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "mata_pelajaran" ADD COLUMN "jumlahPilihan" INTEGER NOT NULL DEFAULT 5;
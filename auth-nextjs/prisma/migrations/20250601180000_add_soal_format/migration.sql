@@ -0,0 +1,5 @@
+-- CreateEnum
+CREATE TYPE "FormatSoal" AS ENUM ('PLAIN', 'MARKDOWN', 'MARKDOWN_LATEX');
+
+-- AlterTable
+ALTER TABLE "soal" ADD COLUMN "format" "FormatSoal" NOT NULL DEFAULT 'PLAIN';
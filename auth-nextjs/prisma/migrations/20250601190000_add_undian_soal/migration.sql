@@ -0,0 +1,14 @@
+-- CreateEnum
+CREATE TYPE "Kesulitan" AS ENUM ('MUDAH', 'SEDANG', 'SULIT');
+
+-- AlterTable
+ALTER TABLE "soal" ADD COLUMN "topik" TEXT,
+ADD COLUMN "kesulitan" "Kesulitan";
+
+-- AlterTable
+ALTER TABLE "ujian" ADD COLUMN "jumlahSoal" INTEGER,
+ADD COLUMN "kuotaSoal" JSONB;
+
+-- AlterTable
+ALTER TABLE "ujian_peserta" ADD COLUMN "seedSoal" BIGINT,
+ADD COLUMN "soalIds" TEXT[] DEFAULT ARRAY[]::TEXT[];
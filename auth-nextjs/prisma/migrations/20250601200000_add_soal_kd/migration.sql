@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "soal" ADD COLUMN "kd" TEXT;
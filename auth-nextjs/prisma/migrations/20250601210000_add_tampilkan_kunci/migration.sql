@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "ujian" ADD COLUMN "tampilkanKunci" BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,8 @@
+-- AlterTable
+ALTER TABLE "soal" ADD COLUMN "jumlahPilihan" INTEGER;
+
+-- Soal pilihan ganda yang sudah tersimpan dengan jumlah pilihan berbeda dari mata pelajarannya
+UPDATE "soal" s SET "jumlahPilihan" = j.jumlah
+FROM (SELECT "soalId", COUNT(*)::INTEGER AS jumlah FROM "jawaban" GROUP BY "soalId") j, "mata_pelajaran" mp
+WHERE j."soalId" = s.id AND mp.id = s."mataPelajaranId"
+  AND s.tipe IN ('PILIHAN_GANDA', 'PILIHAN_GANDA_KOMPLEKS') AND j.jumlah <> mp."jumlahPilihan";
@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "hasil" ADD COLUMN "ditutupOtomatis" BOOLEAN NOT NULL DEFAULT false;
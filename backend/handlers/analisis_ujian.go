@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+	"backend/utils"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAnalisisUjian analisis butir soal satu ujian dari hasil yang sudah dikumpulkan: tingkat kesukaran,
+// daya beda, sebaran pilihan jawaban, dan reliabilitas KR-20. format=json (default), xlsx, atau pdf.
+func (h *UjianHandler) GetAnalisisUjian(c *fiber.Ctx) error {
+	ujianID := c.Params("id")
+	format := strings.ToLower(c.Query("format", "json"))
+	if format != "json" && format != "xlsx" && format != "pdf" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Format harus json, xlsx, atau pdf",
+		})
+	}
+
+	ujian, err := h.Ujian.GetUjian(ujianID)
+	if err == repositories.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Ujian tidak ditemukan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error fetching ujian %s: %v", ujianID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+
+	bank, peserta, fe := h.pesertaAnalisis(ujian)
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+	analisis := services.AnalisisButir(ujian, bank, peserta)
+
+	namaFile := fmt.Sprintf("analisis-%s-%s", analisis.MataPelajaran, analisis.Tingkat)
+	var data []byte
+	switch format {
+	case "json":
+		return c.JSON(analisis)
+	case "xlsx":
+		data, err = services.TulisXLSX(services.BarisAnalisis(analisis))
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		namaFile += ".xlsx"
+	case "pdf":
+		data, err = utils.GenerateAnalisisPDF(analisis)
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		namaFile += ".pdf"
+	}
+	if err != nil {
+		log.Printf("Error exporting analisis ujian %s: %v", ujian.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal membuat file analisis",
+		})
+	}
+
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", namaFile))
+	return c.Send(data)
+}
+
+// pesertaAnalisis siswa yang sudah mengumpulkan ujian beserta soal yang tercatat untuknya dan jawabannya.
+// Bank soal yang dikembalikan ikut memuat soal tercatat yang sudah diarsipkan.
+func (h *UjianHandler) pesertaAnalisis(ujian *models.Ujian) ([]models.SoalInput, []services.PesertaAnalisis, *fiber.Error) {
+	hasilList, err := h.Hasil.ListHasilPerUjian(ujian.ID)
+	if err != nil {
+		log.Printf("Error fetching hasil ujian %s: %v", ujian.ID, err)
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	jawaban, err := h.Jawaban.GetJawabanUjian(ujian.ID)
+	if err != nil {
+		log.Printf("Error fetching jawaban ujian %s: %v", ujian.ID, err)
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	jawabanSiswa := make(map[string][]models.JawabanSiswa)
+	for _, js := range jawaban {
+		jawabanSiswa[js.SiswaDetailID] = append(jawabanSiswa[js.SiswaDetailID], js)
+	}
+
+	// Hasil lama tanpa catatan peserta dianalisis dengan semua soal ujian
+	pesertaList := make([]*models.UjianPeserta, 0, len(hasilList))
+	var tercatat []string
+	for _, hasil := range hasilList {
+		peserta, err := h.Peserta.GetPeserta(ujian.ID, hasil.SiswaDetailID)
+		if err != nil && err != repositories.ErrNotFound {
+			log.Printf("Error fetching peserta ujian: %v", err)
+			return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+		}
+		if peserta != nil {
+			tercatat = append(tercatat, peserta.SoalIDs...)
+		}
+		pesertaList = append(pesertaList, peserta)
+	}
+	bank, err := h.Soal.GetSoalUjianTercatat(ujian.ID, tercatat)
+	if err != nil {
+		log.Printf("Error fetching soal ujian %s: %v", ujian.ID, err)
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	// Peserta tanpa catatan soal tidak boleh ikut mendapat soal arsip milik peserta lain
+	aktif, err := h.Soal.GetSoalUjian(ujian.ID)
+	if err != nil {
+		log.Printf("Error fetching soal ujian %s: %v", ujian.ID, err)
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	result := make([]services.PesertaAnalisis, 0, len(hasilList))
+	for i, hasil := range hasilList {
+		peserta := pesertaList[i]
+		sumber := aktif
+		if peserta != nil && len(peserta.SoalIDs) > 0 {
+			sumber = bank
+		}
+		soalList, err := services.SoalPeserta(sumber, ujian, peserta)
+		if err != nil {
+			log.Printf("Error drawing soal ujian %s for siswa %s: %v", ujian.ID, hasil.SiswaDetailID, err)
+			return nil, nil, fiber.NewError(fiber.StatusConflict, "Bank soal tidak cukup untuk pengaturan soal ujian ini: "+err.Error())
+		}
+		result = append(result, services.PesertaAnalisis{
+			SiswaDetailID: hasil.SiswaDetailID,
+			Soal:          soalList,
+			Jawaban:       jawabanSiswa[hasil.SiswaDetailID],
+		})
+	}
+	return bank, result, nil
+}
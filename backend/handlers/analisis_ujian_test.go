@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"backend/models"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetAnalisisUjian(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+		{ID: "peserta-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2", WaktuMulai: pukul(7, 35)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	for siswa, answers := range map[string]map[string]models.JawabanPilihan{
+		"siswa-1": {"soal-1": {"s1-b"}, "soal-2": {"s2-b"}},
+		"siswa-2": {"soal-1": {"s1-a"}},
+	} {
+		request := models.SubmitUjianRequest{UjianID: "ujian-mtk", SiswaDetailID: siswa, Answers: answers}
+		if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, nil); status != http.StatusOK {
+			t.Fatalf("submit %s status = %d", siswa, status)
+		}
+	}
+
+	var analisis models.AnalisisUjian
+	if status := doJSON(t, app, http.MethodGet, "/api/ujian/ujian-mtk/analisis", nil, &analisis); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if analisis.JumlahPeserta != 2 || analisis.MataPelajaran != "MTK" || len(analisis.Soal) != 2 {
+		t.Fatalf("unexpected analisis %+v", analisis)
+	}
+	soal1, soal2 := analisis.Soal[0], analisis.Soal[1]
+	if *soal1.TingkatKesukaran != 0.5 || *soal1.DayaBeda != 1 || soal1.Pilihan[0].Dipilih != 1 || soal1.Pilihan[0].DipilihBawah != 1 {
+		t.Errorf("unexpected soal-1 analisis %+v", soal1)
+	}
+	if soal2.Kosong != 1 || *soal2.TingkatKesukaran != 0.5 {
+		t.Errorf("unexpected soal-2 analisis %+v", soal2)
+	}
+
+	for _, format := range []string{"xlsx", "pdf"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/ujian/ujian-mtk/analisis?format="+format, nil)
+		status, body := doRequest(t, app, req)
+		if status != http.StatusOK || len(body) == 0 {
+			t.Errorf("%s: status = %d, %d bytes", format, status, len(body))
+		}
+		if format == "pdf" && !bytes.HasPrefix(body, []byte("%PDF")) {
+			t.Errorf("%s: response is not a PDF", format)
+		}
+	}
+
+	if status := doJSON(t, app, http.MethodGet, "/api/ujian/tidak-ada/analisis", nil, nil); status != http.StatusNotFound {
+		t.Errorf("unknown ujian status = %d, want 404", status)
+	}
+	if status := doJSON(t, app, http.MethodGet, "/api/ujian/ujian-mtk/analisis?format=doc", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("unknown format status = %d, want 400", status)
+	}
+}
+
+func TestGetAnalisisUjianBankSoalBerubah(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35), SoalIDs: []string{"soal-1", "soal-2"}},
+		{ID: "peserta-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2", WaktuMulai: pukul(7, 35), SoalIDs: []string{"soal-1", "soal-2"}},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	for siswa, answers := range map[string]map[string]models.JawabanPilihan{
+		"siswa-1": {"soal-1": {"s1-b"}, "soal-2": {"s2-b"}},
+		"siswa-2": {"soal-1": {"s1-a"}, "soal-2": {"s2-b"}},
+	} {
+		request := models.SubmitUjianRequest{UjianID: "ujian-mtk", SiswaDetailID: siswa, Answers: answers}
+		if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, nil); status != http.StatusOK {
+			t.Fatalf("submit %s status = %d", siswa, status)
+		}
+	}
+
+	// Soal yang ditambah setelah ujian tidak ikut dianalisis, soal yang diarsipkan tetap dianalisis
+	store.Lock()
+	store.Soal = append(store.Soal, models.Soal{ID: "soal-3", Soal: "Jelaskan peluang", Tipe: models.TipeEsai, MataPelajaranID: "mp-mtk"})
+	store.Unlock()
+	if status := doJSON(t, app, http.MethodDelete, "/api/soal/soal-1", nil, nil); status != http.StatusOK {
+		t.Fatalf("HapusSoal status = %d", status)
+	}
+
+	var analisis models.AnalisisUjian
+	if status := doJSON(t, app, http.MethodGet, "/api/ujian/ujian-mtk/analisis", nil, &analisis); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(analisis.Soal) != 2 || analisis.Soal[0].SoalID != "soal-1" || analisis.Soal[1].SoalID != "soal-2" {
+		t.Fatalf("expected soal-1 and soal-2, got %+v", analisis.Soal)
+	}
+	if analisis.Soal[1].Kosong != 0 || *analisis.Soal[1].TingkatKesukaran != 1 {
+		t.Errorf("unexpected soal-2 analisis %+v", analisis.Soal[1])
+	}
+}
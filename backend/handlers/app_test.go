@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"backend/clock"
+	"backend/models"
+	"backend/repositories"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMain(m *testing.M) {
+	// Tanggal jadwal diparse sebagai tengah malam UTC, samakan zona lokal supaya hasil test tidak bergantung mesin
+	time.Local = time.UTC
+
+	// Handler menulis ke temp/ dan ../web-ulangan, jalankan di direktori sementara bertingkat
+	// supaya kedua path tersebut tetap berada di dalam direktori sementara
+	root, err := os.MkdirTemp("", "handlers-test")
+	if err != nil {
+		panic(err)
+	}
+	workDir := filepath.Join(root, "backend")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(root)
+	os.Exit(code)
+}
+
+var hariUjian = time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+func pukul(jam, menit int) time.Time {
+	return hariUjian.Add(time.Duration(jam)*time.Hour + time.Duration(menit)*time.Minute)
+}
+
+// seedStore mengisi jadwal tingkat X: sesi 1 07:30-09:30 (MTK, 2 soal), sesi 2 10:00-12:00 (BIndo),
+// dua siswa kelas X-RPL, dan satu siswa kelas XI-RPL
+func seedStore() *repositories.MemoryStore {
+	store := repositories.NewMemoryStore()
+	store.MataPelajaran = []models.MataPelajaran{
+		{ID: "mp-mtk", Tingkat: "X", Pelajaran: "MTK"},
+		{ID: "mp-bindo", Tingkat: "X", Pelajaran: "BIndo"},
+	}
+	store.Jadwal = []repositories.MemoryJadwal{{ID: "jadwal-1", Tanggal: hariUjian, Tingkat: "X"}}
+	store.Sesi = []repositories.MemorySesi{
+		{ID: "sesi-1", JadwalID: "jadwal-1", Sesi: 1, JamMulai: "07:30", JamSelesai: "09:30"},
+		{ID: "sesi-2", JadwalID: "jadwal-1", Sesi: 2, JamMulai: "10:00", JamSelesai: "12:00"},
+	}
+	store.Ujian = []repositories.MemoryUjian{
+		{ID: "ujian-mtk", MataPelajaranID: "mp-mtk", SesiID: "sesi-1", JamMulai: "07:30", JamSelesai: "09:30", Status: "pending", WaktuPengerjaan: 120},
+		{ID: "ujian-bindo", MataPelajaranID: "mp-bindo", SesiID: "sesi-2", JamMulai: "10:00", JamSelesai: "12:00", Status: "pending", WaktuPengerjaan: 120},
+	}
+	store.Soal = []models.Soal{
+		{ID: "soal-1", Soal: "1 + 1 = ?", MataPelajaranID: "mp-mtk"},
+		{ID: "soal-2", Soal: "2 x 3 = ?", MataPelajaranID: "mp-mtk"},
+	}
+	store.Jawaban = []models.Jawaban{
+		{ID: "s1-a", SoalID: "soal-1", Jawaban: "1"},
+		{ID: "s1-b", SoalID: "soal-1", Jawaban: "2", Benar: true},
+		{ID: "s1-c", SoalID: "soal-1", Jawaban: "3"},
+		{ID: "s1-d", SoalID: "soal-1", Jawaban: "4"},
+		{ID: "s1-e", SoalID: "soal-1", Jawaban: "5"},
+		{ID: "s2-a", SoalID: "soal-2", Jawaban: "5"},
+		{ID: "s2-b", SoalID: "soal-2", Jawaban: "6", Benar: true},
+		{ID: "s2-c", SoalID: "soal-2", Jawaban: "7"},
+		{ID: "s2-d", SoalID: "soal-2", Jawaban: "8"},
+		{ID: "s2-e", SoalID: "soal-2", Jawaban: "9"},
+	}
+	store.Kelas = []models.Kelas{
+		{ID: "kelas-x-rpl", Tingkat: "X", Jurusan: "RPL"},
+		{ID: "kelas-xi-rpl", Tingkat: "XI", Jurusan: "RPL"},
+	}
+	store.Siswa = []models.SiswaDetail{
+		{ID: "siswa-1", Nama: "Budi", NIS: "1001", KelasID: "kelas-x-rpl", Kelamin: "L", NomorUjian: "X-001"},
+		{ID: "siswa-2", Nama: "Sari", NIS: "1002", KelasID: "kelas-x-rpl", Kelamin: "P", NomorUjian: "X-002"},
+		{ID: "siswa-xi", Nama: "Dewi", NIS: "2001", KelasID: "kelas-xi-rpl", Kelamin: "P", NomorUjian: "XI-001"},
+	}
+	return store
+}
+
+// newTestApp menjalankan seluruh aplikasi di atas MemoryStore dengan jam palsu. Tracker ujian tidak
+// dijalankan supaya status ujian hanya berubah lewat data seed atau request di dalam test.
+func newTestApp(t *testing.T, store *repositories.MemoryStore, now time.Time) (*fiber.App, *clock.Fake) {
+	t.Helper()
+	clk := clock.NewFake(now)
+	store.Clock = clk
+	app := fiber.New()
+	tracker := SetupRoutes(app, repositories.NewMemoryRepositories(store), clk)
+	t.Cleanup(tracker.Stop)
+	return app, clk
+}
+
+func doRequest(t *testing.T, app *fiber.App, req *http.Request) (int, []byte) {
+	t.Helper()
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading response body: %v", err)
+	}
+	return resp.StatusCode, body
+}
+
+func doJSON(t *testing.T, app *fiber.App, method, path string, payload interface{}, out interface{}) int {
+	t.Helper()
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("marshal payload: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, body)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	status, respBody := doRequest(t, app, req)
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, path, respBody, err)
+		}
+	}
+	return status
+}
+
+func TestRoot(t *testing.T) {
+	app, _ := newTestApp(t, seedStore(), pukul(8, 0))
+
+	var resp map[string]string
+	if status := doJSON(t, app, http.MethodGet, "/", nil, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if resp["message"] != "API bekerja!" {
+		t.Errorf("unexpected message %q", resp["message"])
+	}
+}
+
+func TestGetSiswaDetail(t *testing.T) {
+	const id = "5f0c6d0e-7a53-4b8e-9a3c-1d2e3f405162"
+	store := seedStore()
+	store.Siswa[0].ID = id
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	var siswa models.SiswaDetail
+	if status := doJSON(t, app, http.MethodGet, "/api/siswa/"+id, nil, &siswa); status != http.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if siswa.Nama != "Budi" || siswa.Kelas.Nama != "X-RPL" || siswa.NomorUjian != "X-001" {
+		t.Errorf("unexpected siswa %+v", siswa)
+	}
+
+	if status := doJSON(t, app, http.MethodGet, "/api/siswa/8d1c2b3a-4e5f-4a6b-8c7d-9e0f1a2b3c4d", nil, nil); status != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", status)
+	}
+	if status := doJSON(t, app, http.MethodGet, "/api/siswa/bukan-uuid", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", status)
+	}
+}
+
+func TestGetUjianDetail(t *testing.T) {
+	const id = "0b9e8f7a-6c5d-4e3f-8a1b-2c3d4e5f6a7b"
+	store := seedStore()
+	store.Ujian[0].ID = id
+	store.Ujian[0].Token = "ABCDE"
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	var ujian models.Ujian
+	if status := doJSON(t, app, http.MethodGet, "/api/ujian/"+id, nil, &ujian); status != http.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if ujian.MataPelajaran.Pelajaran != "MTK" || ujian.WaktuPengerjaan != 120 || ujian.Token != "ABCDE" {
+		t.Errorf("unexpected ujian %+v", ujian)
+	}
+
+	if status := doJSON(t, app, http.MethodGet, "/api/ujian/8d1c2b3a-4e5f-4a6b-8c7d-9e0f1a2b3c4d", nil, nil); status != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", status)
+	}
+	if status := doJSON(t, app, http.MethodGet, "/api/ujian/bukan-uuid", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", status)
+	}
+}
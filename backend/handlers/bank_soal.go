@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+	validators "backend/validations"
+	"encoding/json"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	DefaultPerHalamanSoal = 20
+	MaxPerHalamanSoal     = 100
+)
+
+// ListSoal menampilkan bank soal per halaman, bisa difilter dengan tingkat, pelajaran, dan cari (teks soal)
+func (h *SoalHandler) ListSoal(c *fiber.Ctx) error {
+	filter := models.SoalFilter{
+		Tingkat:    c.Query("tingkat"),
+		Pelajaran:  c.Query("pelajaran"),
+		Cari:       c.Query("cari"),
+		Halaman:    c.QueryInt("halaman", 1),
+		PerHalaman: c.QueryInt("perHalaman", DefaultPerHalamanSoal),
+	}
+	if filter.Halaman < 1 {
+		filter.Halaman = 1
+	}
+	if filter.PerHalaman < 1 {
+		filter.PerHalaman = DefaultPerHalamanSoal
+	}
+	if filter.PerHalaman > MaxPerHalamanSoal {
+		filter.PerHalaman = MaxPerHalamanSoal
+	}
+
+	soalList, total, err := h.Soal.ListSoal(filter)
+	if err != nil {
+		log.Printf("Error listing soal: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+	return c.JSON(models.SoalListResponse{
+		Success:    true,
+		Data:       soalList,
+		Total:      total,
+		Halaman:    filter.Halaman,
+		PerHalaman: filter.PerHalaman,
+	})
+}
+
+// GetSoal menampilkan satu soal beserta pilihan dan kuncinya
+func (h *SoalHandler) GetSoal(c *fiber.Ctx) error {
+	soal, fe := h.cariSoal(c.Params("id"))
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+	return c.JSON(fiber.Map{"success": true, "data": soal})
+}
+
+// UpdateSoal mengubah soal dari form multipart: soalData berisi satu soal dengan format yang sama seperti AddSoal,
+// file gambar mengganti gambar lama, dan hapusGambar=true menghapus gambar. Tanpa keduanya gambar lama dipertahankan.
+// Pilihan dengan id lama diubah, pilihan tanpa id ditambahkan, dan pilihan yang tidak dikirim dihapus.
+// Soal yang sudah dijawab siswa hanya boleh diubah teksnya, menghapus pilihan atau mengubah kunci mendapat 409.
+func (h *SoalHandler) UpdateSoal(c *fiber.Ctx) error {
+	lama, fe := h.cariSoal(c.Params("id"))
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+
+	var soal models.SoalInput
+	if err := json.Unmarshal([]byte(c.FormValue("soalData")), &soal); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Format data soal tidak valid: " + err.Error(),
+		})
+	}
+	soal.ID = lama.ID
+	if soal.JumlahPilihan == 0 {
+		// Client yang tidak mengirim jumlahPilihan tetap memakai jumlah pilihan soal yang tersimpan
+		soal.JumlahPilihan = lama.JumlahPilihan
+	}
+	validators.SanitizeSoal(&soal)
+
+	jumlahPilihan := 0
+	if mataPelajaran, err := h.Soal.GetMataPelajaran(lama.Tingkat, lama.Pelajaran); err == nil {
+		jumlahPilihan = mataPelajaran.JumlahPilihan
+	} else if err != repositories.ErrNotFound {
+		log.Printf("Error fetching mata pelajaran: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+	if err := validators.ValidateSoal(soal, 0, jumlahPilihan); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	// Path gambar hanya berasal dari file yang diunggah, bukan dari soalData
+	soal.Gambar = lama.Gambar
+	var gambarBaru string
+	if form, err := c.MultipartForm(); err == nil && len(form.File["gambar"]) > 0 {
+		file := form.File["gambar"][0]
+		if err := validators.ValidateGambar(file.Filename, file.Size, 0); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+			})
+		}
+		if gambarBaru, err = utils.SaveImage(file); err != nil {
+			log.Printf("Error saving image: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"message": "Gagal menyimpan gambar: " + err.Error(),
+			})
+		}
+		soal.Gambar = &gambarBaru
+	} else if c.FormValue("hapusGambar") == "true" {
+		soal.Gambar = nil
+	}
+
+	if err := h.Soal.UpdateSoal(soal); err != nil {
+		if gambarBaru != "" {
+			utils.DeleteImage(gambarBaru)
+		}
+		if err == repositories.ErrNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"message": "Soal tidak ditemukan",
+			})
+		}
+		if err == repositories.ErrKunciSoalTerkunci {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"message": "Soal sudah dijawab siswa, pilihan tidak dapat dihapus dan kunci jawaban tidak dapat diubah. Hapus soal lalu buat soal baru bila kunci perlu diganti.",
+			})
+		}
+		log.Printf("Error updating soal %s: %v", soal.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal mengubah soal",
+		})
+	}
+
+	if lama.Gambar != nil && (soal.Gambar == nil || *soal.Gambar != *lama.Gambar) {
+		if err := utils.DeleteImage(*lama.Gambar); err != nil {
+			log.Printf("Error deleting old image %s: %v", *lama.Gambar, err)
+		}
+	}
+
+	baru, fe := h.cariSoal(soal.ID)
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Soal berhasil diubah", "data": baru})
+}
+
+// HapusSoal menghapus soal. Soal yang sudah dijawab siswa diarsipkan saja supaya hasil ujian lama tetap bisa
+// dibaca, soal arsip tidak muncul lagi di bank soal maupun ujian berikutnya.
+func (h *SoalHandler) HapusSoal(c *fiber.Ctx) error {
+	soal, fe := h.cariSoal(c.Params("id"))
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+
+	diarsipkan, err := h.Soal.HapusSoal(soal.ID)
+	if err == repositories.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Soal tidak ditemukan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error deleting soal %s: %v", soal.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal menghapus soal",
+		})
+	}
+
+	message := "Soal berhasil dihapus"
+	if diarsipkan {
+		message = "Soal sudah dijawab siswa sehingga diarsipkan, hasil ujian lama tidak berubah"
+	} else if soal.Gambar != nil {
+		if err := utils.DeleteImage(*soal.Gambar); err != nil {
+			log.Printf("Error deleting image %s: %v", *soal.Gambar, err)
+		}
+	}
+	return c.JSON(fiber.Map{"success": true, "message": message, "diarsipkan": diarsipkan})
+}
+
+func (h *SoalHandler) cariSoal(id string) (*models.SoalDetail, *fiber.Error) {
+	soal, err := h.Soal.GetSoal(id)
+	if err == repositories.ErrNotFound {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Soal tidak ditemukan")
+	}
+	if err != nil {
+		log.Printf("Error fetching soal %s: %v", id, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	return soal, nil
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"backend/models"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// updateSoalRequest menyusun form PUT /api/soal/:id, gambar nil berarti tidak mengunggah gambar
+func updateSoalRequest(t *testing.T, id string, soal models.SoalInput, gambar []byte, hapusGambar bool) *http.Request {
+	t.Helper()
+	data, err := json.Marshal(soal)
+	if err != nil {
+		t.Fatalf("marshal soal: %v", err)
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("soalData", string(data))
+	if hapusGambar {
+		w.WriteField("hapusGambar", "true")
+	}
+	if gambar != nil {
+		part, _ := w.CreateFormFile("gambar", "baru.png")
+		part.Write(gambar)
+	}
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/soal/"+id, &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestListSoal(t *testing.T) {
+	store := seedStore()
+	store.Soal = append(store.Soal, models.Soal{ID: "soal-b1", Soal: "Sinonim kata cepat?", MataPelajaranID: "mp-bindo"})
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	var semua models.SoalListResponse
+	doJSON(t, app, http.MethodGet, "/api/soal", nil, &semua)
+	if semua.Total != 3 || len(semua.Data) != 3 || semua.Halaman != 1 || semua.PerHalaman != DefaultPerHalamanSoal {
+		t.Fatalf("unexpected list %+v", semua)
+	}
+
+	var halaman models.SoalListResponse
+	doJSON(t, app, http.MethodGet, "/api/soal?tingkat=X&pelajaran=MTK&perHalaman=1&halaman=2", nil, &halaman)
+	if halaman.Total != 2 || len(halaman.Data) != 1 || halaman.Data[0].ID != "soal-2" || halaman.Data[0].Pelajaran != "MTK" ||
+		len(halaman.Data[0].Pilihan) != 5 || !halaman.Data[0].Pilihan[1].Benar {
+		t.Errorf("unexpected second page %+v", halaman)
+	}
+
+	var cari models.SoalListResponse
+	doJSON(t, app, http.MethodGet, "/api/soal?cari=SINONIM", nil, &cari)
+	if cari.Total != 1 || cari.Data[0].ID != "soal-b1" {
+		t.Errorf("search must be case-insensitive, got %+v", cari)
+	}
+
+	var kosong models.SoalListResponse
+	doJSON(t, app, http.MethodGet, "/api/soal?tingkat=X&halaman=9", nil, &kosong)
+	if kosong.Total != 3 || kosong.Data == nil || len(kosong.Data) != 0 {
+		t.Errorf("page past the end must be an empty list, got %+v", kosong)
+	}
+}
+
+func TestUpdateSoal(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	var detail struct {
+		Data models.SoalDetail `json:"data"`
+	}
+	if status := doJSON(t, app, http.MethodGet, "/api/soal/soal-1", nil, &detail); status != http.StatusOK {
+		t.Fatalf("GetSoal status = %d", status)
+	}
+
+	// Perbaiki kunci (B -> C), ganti pilihan E dengan pilihan baru, dan pasang gambar
+	soal := detail.Data.SoalInput
+	soal.Soal = "1 + 2 = ?"
+	soal.Pilihan[1].Benar, soal.Pilihan[2].Benar = false, true
+	soal.Pilihan[4] = models.Pilihan{Text: "6"}
+	status, body := doRequest(t, app, updateSoalRequest(t, "soal-1", soal, []byte("\x89PNG"), false))
+	if status != http.StatusOK {
+		t.Fatalf("UpdateSoal status = %d, body %s", status, body)
+	}
+	doJSON(t, app, http.MethodGet, "/api/soal/soal-1", nil, &detail)
+	diubah := detail.Data
+	if diubah.Soal != "1 + 2 = ?" || diubah.Gambar == nil || len(diubah.Pilihan) != 5 {
+		t.Fatalf("unexpected updated soal %+v", diubah)
+	}
+	gambarLama := filepath.Join("../web-ulangan/public", *diubah.Gambar)
+	if _, err := os.Stat(gambarLama); err != nil {
+		t.Fatalf("image not saved: %v", err)
+	}
+	var kunci []string
+	for _, p := range diubah.Pilihan {
+		if p.Benar {
+			kunci = append(kunci, p.ID)
+		}
+		if p.ID == "s1-e" {
+			t.Error("removed pilihan s1-e must be deleted")
+		}
+	}
+	if len(kunci) != 1 || kunci[0] != "s1-c" {
+		t.Errorf("correct answer must move to s1-c, got %v", kunci)
+	}
+
+	// Tanpa file dan tanpa hapusGambar gambar tetap, hapusGambar menghapus file lama
+	if status, _ := doRequest(t, app, updateSoalRequest(t, "soal-1", diubah.SoalInput, nil, false)); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	doJSON(t, app, http.MethodGet, "/api/soal/soal-1", nil, &detail)
+	if detail.Data.Gambar == nil {
+		t.Fatal("image must be kept when not replaced")
+	}
+	if status, _ := doRequest(t, app, updateSoalRequest(t, "soal-1", diubah.SoalInput, nil, true)); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	doJSON(t, app, http.MethodGet, "/api/soal/soal-1", nil, &detail)
+	if detail.Data.Gambar != nil {
+		t.Error("hapusGambar must remove the image")
+	}
+	if _, err := os.Stat(gambarLama); !os.IsNotExist(err) {
+		t.Errorf("old image file must be deleted, stat err %v", err)
+	}
+
+	// Validasi sama dengan AddSoal: dua jawaban benar pada pilihan ganda ditolak
+	salah := diubah.SoalInput
+	salah.Pilihan[0].Benar = true
+	if status, _ := doRequest(t, app, updateSoalRequest(t, "soal-1", salah, nil, false)); status != http.StatusBadRequest {
+		t.Errorf("invalid soal status = %d, want 400", status)
+	}
+	if status, _ := doRequest(t, app, updateSoalRequest(t, "tidak-ada", soal, nil, false)); status != http.StatusNotFound {
+		t.Errorf("unknown soal status = %d, want 404", status)
+	}
+}
+
+func TestUpdateSoalSudahDijawab(t *testing.T) {
+	store := seedStore()
+	store.JawabanSiswa = []models.JawabanSiswa{
+		{ID: "js-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", SoalID: "soal-1", JawabanID: "s1-e"},
+	}
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	var detail struct {
+		Data models.SoalDetail `json:"data"`
+	}
+	doJSON(t, app, http.MethodGet, "/api/soal/soal-1", nil, &detail)
+	asli := detail.Data.SoalInput
+
+	// Perbaikan teks tetap boleh, hasil lama tidak berubah
+	teks := asli
+	teks.Soal = "1 + 1 = ... ?"
+	teks.Pilihan = append([]models.Pilihan(nil), asli.Pilihan...)
+	teks.Pilihan[0].Text = "satu"
+	if status, body := doRequest(t, app, updateSoalRequest(t, "soal-1", teks, nil, false)); status != http.StatusOK {
+		t.Fatalf("text fix: status = %d, body %s", status, body)
+	}
+
+	tests := []struct {
+		name string
+		ubah func(soal *models.SoalInput)
+	}{
+		{"ganti pilihan yang dijawab", func(soal *models.SoalInput) { soal.Pilihan[4] = models.Pilihan{Text: "6"} }},
+		{"pindah kunci", func(soal *models.SoalInput) { soal.Pilihan[1].Benar, soal.Pilihan[2].Benar = false, true }},
+		{"ganti tipe", func(soal *models.SoalInput) { soal.Tipe = models.TipePilihanGandaKompleks }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			soal := teks
+			soal.Pilihan = append([]models.Pilihan(nil), teks.Pilihan...)
+			tt.ubah(&soal)
+			if status, body := doRequest(t, app, updateSoalRequest(t, "soal-1", soal, nil, false)); status != http.StatusConflict {
+				t.Errorf("status = %d, body %s, want 409", status, body)
+			}
+		})
+	}
+
+	doJSON(t, app, http.MethodGet, "/api/soal/soal-1", nil, &detail)
+	var ids, kunci []string
+	for _, p := range detail.Data.Pilihan {
+		ids = append(ids, p.ID)
+		if p.Benar {
+			kunci = append(kunci, p.ID)
+		}
+	}
+	if len(ids) != 5 || ids[4] != "s1-e" || len(kunci) != 1 || kunci[0] != "s1-b" || detail.Data.Tipe != models.TipePilihanGanda {
+		t.Errorf("rejected updates must leave pilihan and kunci untouched, got %+v", detail.Data)
+	}
+}
+
+func TestHapusSoal(t *testing.T) {
+	store := seedStore()
+	store.JawabanSiswa = []models.JawabanSiswa{
+		{ID: "js-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", SoalID: "soal-1", JawabanID: "s1-b"},
+	}
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	var resp struct {
+		Diarsipkan bool `json:"diarsipkan"`
+	}
+	if status := doJSON(t, app, http.MethodDelete, "/api/soal/soal-2", nil, &resp); status != http.StatusOK || resp.Diarsipkan {
+		t.Fatalf("delete unanswered soal: status = %d, diarsipkan = %v", status, resp.Diarsipkan)
+	}
+	if status := doJSON(t, app, http.MethodDelete, "/api/soal/soal-1", nil, &resp); status != http.StatusOK || !resp.Diarsipkan {
+		t.Fatalf("delete answered soal: status = %d, diarsipkan = %v", status, resp.Diarsipkan)
+	}
+
+	store.Lock()
+	if len(store.Soal) != 1 || store.Soal[0].ID != "soal-1" || store.Soal[0].DeletedAt == nil {
+		t.Errorf("soal-2 must be removed and soal-1 archived, got %+v", store.Soal)
+	} else if !store.Soal[0].DeletedAt.Equal(pukul(6, 0)) {
+		t.Errorf("DeletedAt = %v, want the fake clock %v", store.Soal[0].DeletedAt, pukul(6, 0))
+	}
+	for _, j := range store.Jawaban {
+		if j.SoalID == "soal-2" {
+			t.Errorf("pilihan of deleted soal must be removed, found %+v", j)
+		}
+	}
+	store.Unlock()
+
+	if status := doJSON(t, app, http.MethodGet, "/api/soal/soal-1", nil, nil); status != http.StatusNotFound {
+		t.Errorf("archived soal status = %d, want 404", status)
+	}
+	if status := doJSON(t, app, http.MethodDelete, "/api/soal/soal-1", nil, nil); status != http.StatusNotFound {
+		t.Errorf("deleting archived soal again status = %d, want 404", status)
+	}
+	var list models.SoalListResponse
+	doJSON(t, app, http.MethodGet, "/api/soal?tingkat=X&pelajaran=MTK", nil, &list)
+	if list.Total != 0 {
+		t.Errorf("archived soal must not be listed, got %+v", list)
+	}
+}
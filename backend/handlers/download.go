@@ -1,81 +1,201 @@
 package handlers
 
 import (
+	"backend/models"
 	"backend/repositories"
+	"backend/services"
 	"backend/utils"
-	"database/sql"
+	"bytes"
+	"encoding/csv"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func DownloadHasilUjian(c *fiber.Ctx, db *sql.DB) error {
-    hasil, err := repositories.GetHasilUjian(db)
-    if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal mengambil data hasil ujian"})
-    }
-
-    // Buat direktori temporary dengan timestamp untuk menghindari konflik
-    timestamp := time.Now().Unix()
-    tempDir := fmt.Sprintf("temp/hasil_ujian_%d", timestamp)
-    os.MkdirAll(tempDir, os.ModePerm)
-    
-    // Map untuk menyimpan tingkat, mata pelajaran, dan kelas yang unik
-    uniqueData := make(map[string]map[string]map[string]bool)
-    
-    // Identifikasi semua kombinasi tingkat/matapelajaran/kelas yang ada
-    for _, h := range hasil {
-        if _, ok := uniqueData[h.Tingkat]; !ok {
-            uniqueData[h.Tingkat] = make(map[string]map[string]bool)
-        }
-        
-        if _, ok := uniqueData[h.Tingkat][h.MataPelajaran]; !ok {
-            uniqueData[h.Tingkat][h.MataPelajaran] = make(map[string]bool)
-        }
-        
-        uniqueData[h.Tingkat][h.MataPelajaran][h.Kelas] = true
-    }
-    
-    // Map untuk menyimpan path file dalam ZIP dan path file PDF
-    files := make(map[string]string)
-    
-    // Buat PDF untuk setiap kelas
-    for tingkat, mataPelajaranMap := range uniqueData {
-        for mataPelajaran, kelasMap := range mataPelajaranMap {
-            for kelas := range kelasMap {
-                pdfPath, err := utils.GeneratePDF(tingkat, mataPelajaran, kelas, hasil)
-                if err != nil {
-                    continue
-                }
-                
-                // Format jalur file di dalam ZIP: Ujian Tingkat X/X-BIndo/X-RPL.pdf
-               zipPath := fmt.Sprintf("Hasil Ujian/Ujian Tingkat %s/%s/%s.pdf", tingkat, mataPelajaran, kelas)
-
-                
-                files[zipPath] = pdfPath
-            }
-        }
-    }
-    
-    // Buat ZIP
-    zipPath := filepath.Join(tempDir, "hasil_ujian.zip")
-    err = utils.CreateZIP(zipPath, files)
-    if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal membuat ZIP"})
-    }
-    
-    // Kirim file ZIP ke user
-    c.Set("Content-Disposition", "attachment; filename=hasil_ujian.zip")
-    err = c.SendFile(zipPath)
-    
-    // Bersihkan file temporary setelah selesai (bisa dijalankan sebagai goroutine)
-    go func() {
-        time.Sleep(5 * time.Minute) // Beri waktu untuk download selesai
-        os.RemoveAll(tempDir)
-    }()
-    
-    return err
-}
\ No newline at end of file
+// DownloadHasilUjian mengunduh hasil ujian yang cocok dengan filter tingkat, pelajaran, kelas, ujianId dan
+// rentang tanggal dari/sampai (YYYY-MM-DD, keduanya inklusif). format=pdf (default) menghasilkan ZIP berisi
+// PDF per kelas, format=xlsx dan csv satu baris per siswa untuk diisikan ke e-Rapor, dan format=json datanya.
+func DownloadHasilUjian(c *fiber.Ctx, hasilRepo repositories.HasilRepository) error {
+	filter := models.HasilFilter{
+		Tingkat:   c.Query("tingkat"),
+		Pelajaran: c.Query("pelajaran"),
+		Kelas:     c.Query("kelas"),
+		UjianID:   c.Query("ujianId"),
+	}
+	var err error
+	if filter.Dari, err = parseTanggalFilter(c.Query("dari")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Format tanggal dari harus YYYY-MM-DD",
+		})
+	}
+	if filter.Sampai, err = parseTanggalFilter(c.Query("sampai")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Format tanggal sampai harus YYYY-MM-DD",
+		})
+	}
+	if !filter.Sampai.IsZero() {
+		// Sampai pada filter eksklusif, jadi hasil sepanjang hari terakhir tetap ikut
+		filter.Sampai = filter.Sampai.AddDate(0, 0, 1)
+	}
+	if !filter.Dari.IsZero() && !filter.Sampai.IsZero() && !filter.Dari.Before(filter.Sampai) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Tanggal dari tidak boleh setelah tanggal sampai",
+		})
+	}
+
+	format := strings.ToLower(c.Query("format", "pdf"))
+	switch format {
+	case "pdf", "xlsx", "csv", "json":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Format harus pdf, xlsx, csv, atau json",
+		})
+	}
+
+	hasil, err := hasilRepo.ListHasilUjian(filter)
+	if err != nil {
+		log.Printf("Error fetching hasil ujian %+v: %v", filter, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal mengambil data hasil ujian",
+		})
+	}
+	if len(hasil) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Tidak ada hasil ujian yang cocok dengan filter",
+		})
+	}
+
+	namaFile := namaFileHasil(filter, c.Query("dari"), c.Query("sampai"))
+	var data []byte
+	switch format {
+	case "pdf":
+		return kirimZIPHasil(c, hasil, namaFile+".zip")
+	case "json":
+		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", namaFile+".json"))
+		return c.JSON(hasil)
+	case "xlsx":
+		data, err = services.TulisXLSX(services.BarisHasil(hasil), services.KolomAngkaHasil...)
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		namaFile += ".xlsx"
+	case "csv":
+		data, err = tulisCSV(services.BarisHasil(hasil))
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		namaFile += ".csv"
+	}
+	if err != nil {
+		log.Printf("Error exporting hasil ujian %+v: %v", filter, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal membuat file ekspor",
+		})
+	}
+
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", namaFile))
+	return c.Send(data)
+}
+
+// parseTanggalFilter membaca tanggal YYYY-MM-DD pada zona waktu server, string kosong berarti tanpa batas
+func parseTanggalFilter(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+// namaFileHasil nama file unduhan tanpa ekstensi, misalnya hasil_ujian_X_MTK_X-RPL_2025-06-01_2025-06-30
+func namaFileHasil(filter models.HasilFilter, dari, sampai string) string {
+	bagian := []string{"hasil_ujian"}
+	for _, v := range []string{filter.Tingkat, filter.Pelajaran, filter.Kelas, filter.UjianID, dari, sampai} {
+		if v != "" {
+			bagian = append(bagian, strings.ReplaceAll(v, " ", "-"))
+		}
+	}
+	return strings.Join(bagian, "_")
+}
+
+// tulisCSV menulis baris sebagai CSV dengan BOM UTF-8 supaya Excel membaca nama siswa dengan benar
+func tulisCSV(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// kirimZIPHasil mengirim ZIP berisi satu PDF per tingkat, mata pelajaran dan kelas
+func kirimZIPHasil(c *fiber.Ctx, hasil []models.HasilUjianDetail, namaFile string) error {
+	// Buat direktori temporary dengan timestamp untuk menghindari konflik
+	timestamp := time.Now().UnixNano()
+	tempDir := fmt.Sprintf("temp/hasil_ujian_%d", timestamp)
+	os.MkdirAll(tempDir, os.ModePerm)
+
+	// Map untuk menyimpan tingkat, mata pelajaran, dan kelas yang unik
+	uniqueData := make(map[string]map[string]map[string]bool)
+
+	// Identifikasi semua kombinasi tingkat/matapelajaran/kelas yang ada
+	for _, h := range hasil {
+		if _, ok := uniqueData[h.Tingkat]; !ok {
+			uniqueData[h.Tingkat] = make(map[string]map[string]bool)
+		}
+
+		if _, ok := uniqueData[h.Tingkat][h.MataPelajaran]; !ok {
+			uniqueData[h.Tingkat][h.MataPelajaran] = make(map[string]bool)
+		}
+
+		uniqueData[h.Tingkat][h.MataPelajaran][h.Kelas] = true
+	}
+
+	// Map untuk menyimpan path file dalam ZIP dan path file PDF
+	files := make(map[string]string)
+
+	// Buat PDF untuk setiap kelas
+	for tingkat, mataPelajaranMap := range uniqueData {
+		for mataPelajaran, kelasMap := range mataPelajaranMap {
+			for kelas := range kelasMap {
+				pdfPath, err := utils.GeneratePDF(tingkat, mataPelajaran, kelas, hasil)
+				if err != nil {
+					continue
+				}
+
+				// Format jalur file di dalam ZIP: Ujian Tingkat X/X-BIndo/X-RPL.pdf
+				zipPath := fmt.Sprintf("Hasil Ujian/Ujian Tingkat %s/%s/%s.pdf", tingkat, mataPelajaran, kelas)
+
+				files[zipPath] = pdfPath
+			}
+		}
+	}
+
+	// Buat ZIP
+	zipPath := filepath.Join(tempDir, "hasil_ujian.zip")
+	if err := utils.CreateZIP(zipPath, files); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal membuat ZIP",
+		})
+	}
+
+	// Kirim file ZIP ke user
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", namaFile))
+	err := c.SendFile(zipPath)
+
+	// Bersihkan file temporary setelah selesai (bisa dijalankan sebagai goroutine)
+	go func() {
+		time.Sleep(5 * time.Minute) // Beri waktu untuk download selesai
+		os.RemoveAll(tempDir)
+	}()
+
+	return err
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+	"backend/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// rencanaDuplikat aksi yang dipilih pengunggah untuk setiap soal yang terdeteksi duplikat
+type rencanaDuplikat struct {
+	duplikat map[int]models.DuplikatSoal
+	aksi     map[int]string
+	bank     map[string]models.SoalInput // soal bank yang mungkin ditimpa
+}
+
+// lewati true bila soal ke-i tidak perlu disimpan, gambarnya pun tidak perlu ditulis
+func (r rencanaDuplikat) lewati(i int) bool {
+	return r.aksi[i] == services.AksiLewati
+}
+
+// ringkasanSimpan jumlah soal per hasil penyimpanan untuk respons unggahan
+type ringkasanSimpan struct {
+	Disimpan int `json:"disimpan"`
+	Ditimpa  int `json:"ditimpa"`
+	Dilewati int `json:"dilewati"`
+}
+
+// rencanakanDuplikat membandingkan soal yang diunggah dengan bank soal mata pelajaran. Field aksiDuplikat berisi
+// JSON indeks soal -> lewati/timpa/simpan. Bila ada duplikat yang belum diberi aksi, daftar duplikat tersebut
+// dikembalikan supaya handler menolak unggahan dengan 409 dan pengunggah bisa memilih lalu mengirim ulang.
+func (h *SoalHandler) rencanakanDuplikat(c *fiber.Ctx, tingkat, pelajaran string, soalList []models.SoalInput) (rencanaDuplikat, []models.DuplikatSoal, *fiber.Error) {
+	rencana := rencanaDuplikat{
+		duplikat: make(map[int]models.DuplikatSoal),
+		aksi:     make(map[int]string),
+		bank:     make(map[string]models.SoalInput),
+	}
+
+	if raw := c.FormValue("aksiDuplikat"); raw != "" {
+		var aksi map[string]string
+		if err := json.Unmarshal([]byte(raw), &aksi); err != nil {
+			return rencana, nil, fiber.NewError(fiber.StatusBadRequest, "Format aksiDuplikat tidak valid: "+err.Error())
+		}
+		for key, a := range aksi {
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(soalList) {
+				return rencana, nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Indeks aksiDuplikat %q tidak valid", key))
+			}
+			if a != services.AksiLewati && a != services.AksiTimpa && a != services.AksiSimpan {
+				return rencana, nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Aksi duplikat soal %d harus lewati, timpa, atau simpan", i+1))
+			}
+			rencana.aksi[i] = a
+		}
+	}
+
+	bank, err := h.Soal.GetSoalMataPelajaran(tingkat, pelajaran)
+	if err == repositories.ErrNotFound {
+		rencana.aksi = map[int]string{}
+		return rencana, nil, nil
+	}
+	if err != nil {
+		log.Printf("Error fetching soal %s tingkat %s: %v", pelajaran, tingkat, err)
+		return rencana, nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	for _, soal := range bank {
+		rencana.bank[soal.ID] = soal
+	}
+
+	var belumDipilih []models.DuplikatSoal
+	for _, d := range services.CariDuplikat(soalList, bank) {
+		rencana.duplikat[d.Indeks] = d
+		if _, ok := rencana.aksi[d.Indeks]; !ok {
+			belumDipilih = append(belumDipilih, d)
+		}
+	}
+	// Aksi hanya berlaku untuk soal yang memang duplikat
+	for i := range rencana.aksi {
+		if _, ok := rencana.duplikat[i]; !ok {
+			delete(rencana.aksi, i)
+		}
+	}
+	return rencana, belumDipilih, nil
+}
+
+// tolakDuplikat respons 409 berisi duplikat yang belum diberi aksi, tidak ada soal yang disimpan
+func tolakDuplikat(c *fiber.Ctx, duplikat []models.DuplikatSoal) error {
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"success":  false,
+		"message":  fmt.Sprintf("Ditemukan %d soal yang sama atau mirip dengan bank soal, pilih aksi lewati, timpa, atau simpan di aksiDuplikat lalu kirim ulang", len(duplikat)),
+		"duplikat": duplikat,
+	})
+}
+
+// simpanSesuaiRencana menyimpan soal baru dan mengganti soal bank yang dipilih untuk ditimpa dalam satu transaksi.
+// Soal yang menimpa tanpa gambar baru tetap memakai gambar soal lama, dan pilihan yang teksnya sama dengan
+// pilihan lama memakai ID lama supaya jawaban siswa yang sudah ada tetap menunjuk pilihan yang benar.
+func (h *SoalHandler) simpanSesuaiRencana(tingkat, pelajaran string, soalList []models.SoalInput, rencana rencanaDuplikat) (ringkasanSimpan, *fiber.Error) {
+	var ringkasan ringkasanSimpan
+	var baru, timpa []models.SoalInput
+	var gambarDiganti []string
+	for i, soal := range soalList {
+		switch rencana.aksi[i] {
+		case services.AksiLewati:
+			ringkasan.Dilewati++
+		case services.AksiTimpa:
+			lama := rencana.bank[rencana.duplikat[i].SoalID]
+			soal.ID = lama.ID
+			soal.Pilihan = pakaiIDPilihanLama(soal.Pilihan, lama.Pilihan)
+			if soal.Gambar == nil {
+				soal.Gambar = lama.Gambar
+			}
+			if lama.Gambar != nil && *lama.Gambar != *soal.Gambar {
+				gambarDiganti = append(gambarDiganti, *lama.Gambar)
+			}
+			timpa = append(timpa, soal)
+		default:
+			baru = append(baru, soal)
+		}
+	}
+
+	if len(baru) > 0 || len(timpa) > 0 {
+		err := h.Soal.SimpanSoalDanTimpa(tingkat, pelajaran, baru, timpa)
+		if errors.Is(err, repositories.ErrKunciSoalTerkunci) {
+			return ringkasan, fiber.NewError(fiber.StatusConflict, "Soal bank yang akan ditimpa sudah dijawab siswa dan kuncinya berbeda, pilih simpan atau lewati untuk soal tersebut")
+		}
+		if err != nil {
+			log.Printf("Error saving soal %s tingkat %s: %v", pelajaran, tingkat, err)
+			return ringkasan, fiber.NewError(fiber.StatusInternalServerError, "Gagal menyimpan soal")
+		}
+	}
+	ringkasan.Disimpan, ringkasan.Ditimpa = len(baru), len(timpa)
+
+	for _, gambar := range gambarDiganti {
+		if err := utils.DeleteImage(gambar); err != nil {
+			log.Printf("Error deleting replaced image %s: %v", gambar, err)
+		}
+	}
+	return ringkasan, nil
+}
+
+func pakaiIDPilihanLama(pilihan, lama []models.Pilihan) []models.Pilihan {
+	tersedia := make(map[string][]string)
+	for _, p := range lama {
+		teks := services.NormalisasiSoal(p.Text)
+		tersedia[teks] = append(tersedia[teks], p.ID)
+	}
+	result := make([]models.Pilihan, len(pilihan))
+	for i, p := range pilihan {
+		teks := services.NormalisasiSoal(p.Text)
+		if ids := tersedia[teks]; len(ids) > 0 {
+			p.ID, tersedia[teks] = ids[0], ids[1:]
+		} else {
+			p.ID = ""
+		}
+		result[i] = p
+	}
+	return result
+}
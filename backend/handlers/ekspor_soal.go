@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"backend/repositories"
+	"backend/services"
+	"backend/utils"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportSoal mengunduh bank soal satu mata pelajaran. format=json (default) menghasilkan array soalData
+// seperti yang diterima AddSoal, format=xlsx memakai kolom yang sama dengan ImportSoal, format=pdf
+// naskah soal siap cetak, dan format=kunci lembar kunci jawabannya.
+func (h *SoalHandler) ExportSoal(c *fiber.Ctx) error {
+	tingkat := c.Query("tingkat")
+	pelajaran := c.Query("pelajaran")
+	if tingkat == "" || pelajaran == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Parameter tingkat dan pelajaran wajib diisi",
+		})
+	}
+
+	soalList, err := h.Soal.GetSoalMataPelajaran(tingkat, pelajaran)
+	if err == repositories.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Mata pelajaran tidak ditemukan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error fetching soal %s tingkat %s: %v", pelajaran, tingkat, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+
+	namaFile := fmt.Sprintf("soal-%s-%s", pelajaran, tingkat)
+	var data []byte
+	switch strings.ToLower(c.Query("format", "json")) {
+	case "json":
+		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", namaFile+".json"))
+		return c.JSON(soalList)
+	case "xlsx":
+		data, err = services.TulisXLSX(services.BarisSpreadsheet(soalList))
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		namaFile += ".xlsx"
+	case "pdf":
+		data, err = utils.GenerateSoalPDF(tingkat, pelajaran, soalList)
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		namaFile += ".pdf"
+	case "kunci":
+		data, err = utils.GenerateKunciJawabanPDF(tingkat, pelajaran, soalList)
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		namaFile = fmt.Sprintf("kunci-%s-%s.pdf", pelajaran, tingkat)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Format harus json, xlsx, pdf, atau kunci",
+		})
+	}
+	if err != nil {
+		log.Printf("Error exporting soal %s tingkat %s: %v", pelajaran, tingkat, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal membuat file ekspor",
+		})
+	}
+
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", namaFile))
+	return c.Send(data)
+}
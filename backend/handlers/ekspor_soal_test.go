@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"backend/models"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExportSoal(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	var ekspor []models.SoalInput
+	if status := doJSON(t, app, http.MethodGet, "/api/soal/export?tingkat=X&pelajaran=MTK", nil, &ekspor); status != http.StatusOK {
+		t.Fatalf("json status = %d", status)
+	}
+	if len(ekspor) != 2 || len(ekspor[0].Pilihan) != 5 || !ekspor[0].Pilihan[1].Benar {
+		t.Fatalf("unexpected export %+v", ekspor)
+	}
+
+	// JSON ekspor diterima AddSoal apa adanya
+	if status, body := doRequest(t, app, soalRequest(t, "XI", "MTK", ekspor, nil)); status != http.StatusOK {
+		t.Fatalf("re-import via AddSoal status = %d, body %s", status, body)
+	}
+	var salinan []models.SoalInput
+	doJSON(t, app, http.MethodGet, "/api/soal/export?tingkat=XI&pelajaran=MTK", nil, &salinan)
+	if len(salinan) != 2 {
+		t.Errorf("expected 2 copied soal, got %+v", salinan)
+	}
+
+	for _, format := range []string{"xlsx", "pdf", "kunci"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/soal/export?tingkat=X&pelajaran=MTK&format="+format, nil)
+		status, body := doRequest(t, app, req)
+		if status != http.StatusOK || len(body) == 0 {
+			t.Errorf("%s: status = %d, %d bytes", format, status, len(body))
+		}
+		if format != "xlsx" && !bytes.HasPrefix(body, []byte("%PDF")) {
+			t.Errorf("%s: response is not a PDF", format)
+		}
+	}
+
+	if status := doJSON(t, app, http.MethodGet, "/api/soal/export?tingkat=XII&pelajaran=MTK", nil, nil); status != http.StatusNotFound {
+		t.Errorf("unknown mata pelajaran status = %d, want 404", status)
+	}
+	if status := doJSON(t, app, http.MethodGet, "/api/soal/export?tingkat=X&pelajaran=MTK&format=doc", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("unknown format status = %d, want 400", status)
+	}
+}
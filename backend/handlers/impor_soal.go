@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"archive/zip"
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+	"backend/utils"
+	validators "backend/validations"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	MaxUkuranFileImpor = 10 * 1024 * 1024 // 10MB untuk XLSX/CSV
+	MaxUkuranZipGambar = 50 * 1024 * 1024 // 50MB untuk ZIP gambar
+)
+
+// ImportSoal mengimpor soal dari file XLSX/CSV (satu baris per soal) atau bank soal Moodle (GIFT/Aiken),
+// dengan ZIP gambar opsional untuk spreadsheet.
+// Semua baris divalidasi lebih dulu, bila ada satu saja yang salah tidak ada soal yang disimpan
+// dan semua kesalahan dikembalikan per baris.
+func (h *SoalHandler) ImportSoal(c *fiber.Ctx) error {
+	tingkat := c.FormValue("tingkat")
+	pelajaran := c.FormValue("pelajaran")
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "File soal (.xlsx, .csv, .gift, atau .txt) wajib dikirim di field file",
+		})
+	}
+	if file.Size > MaxUkuranFileImpor {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Ukuran file soal maksimal 10MB",
+		})
+	}
+	barisSoal, kesalahan, err := bacaFileSoal(file, c.FormValue("format"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	var gambarZip map[string]*zip.File
+	if form, err := c.MultipartForm(); err == nil && len(form.File["gambar"]) > 0 {
+		if gambarZip, err = bacaZipGambar(form.File["gambar"][0]); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+			})
+		}
+	}
+
+	jumlahPilihan := 0
+	mataPelajaran, err := h.Soal.GetMataPelajaran(tingkat, pelajaran)
+	if err == nil {
+		jumlahPilihan = mataPelajaran.JumlahPilihan
+	} else if err != repositories.ErrNotFound {
+		log.Printf("Error fetching mata pelajaran: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+
+	for i, baris := range barisSoal {
+		validators.SanitizeSoal(&barisSoal[i].Soal)
+		if err := validators.ValidateSoal(barisSoal[i].Soal, i, jumlahPilihan); err != nil {
+			kesalahan = append(kesalahan, models.KesalahanImpor{Baris: baris.Baris, Pesan: err.Error()})
+		}
+		if baris.Gambar == "" {
+			continue
+		}
+		if gambarZip == nil {
+			kesalahan = append(kesalahan, models.KesalahanImpor{Baris: baris.Baris, Pesan: fmt.Sprintf("gambar %s disebut tetapi ZIP gambar tidak dikirim", baris.Gambar)})
+		} else if zf, ok := gambarZip[strings.ToLower(baris.Gambar)]; !ok {
+			kesalahan = append(kesalahan, models.KesalahanImpor{Baris: baris.Baris, Pesan: fmt.Sprintf("gambar %s tidak ditemukan di ZIP", baris.Gambar)})
+		} else if err := validators.ValidateGambar(zf.Name, int64(zf.UncompressedSize64), i); err != nil {
+			kesalahan = append(kesalahan, models.KesalahanImpor{Baris: baris.Baris, Pesan: err.Error()})
+		}
+	}
+	if len(kesalahan) > 0 {
+		sort.SliceStable(kesalahan, func(i, j int) bool { return kesalahan[i].Baris < kesalahan[j].Baris })
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success":   false,
+			"message":   fmt.Sprintf("Impor dibatalkan, %d kesalahan ditemukan", len(kesalahan)),
+			"kesalahan": kesalahan,
+		})
+	}
+	if len(barisSoal) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "File tidak berisi soal",
+		})
+	}
+
+	soalList := make([]models.SoalInput, len(barisSoal))
+	for i, baris := range barisSoal {
+		soalList[i] = baris.Soal
+	}
+	if err := validators.ValidateSoalInput(tingkat, pelajaran, jumlahPilihan, soalList, nil); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	rencana, belumDipilih, fe := h.rencanakanDuplikat(c, tingkat, pelajaran, soalList)
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+	if len(belumDipilih) > 0 {
+		return tolakDuplikat(c, belumDipilih)
+	}
+
+	// Gambar baru ditulis setelah semua baris valid, dan dihapus lagi bila penyimpanan soal gagal
+	var tersimpan []string
+	hapusGambar := func() {
+		for _, path := range tersimpan {
+			if err := utils.DeleteImage(path); err != nil {
+				log.Printf("Error deleting imported image %s: %v", path, err)
+			}
+		}
+	}
+	for i, baris := range barisSoal {
+		if baris.Gambar == "" || rencana.lewati(i) {
+			continue
+		}
+		path, err := simpanGambarZip(gambarZip[strings.ToLower(baris.Gambar)])
+		if err != nil {
+			hapusGambar()
+			log.Printf("Error saving imported image %s: %v", baris.Gambar, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"message": "Gagal menyimpan gambar: " + err.Error(),
+			})
+		}
+		tersimpan = append(tersimpan, path)
+		soalList[i].Gambar = &path
+	}
+
+	ringkasan, fe := h.simpanSesuaiRencana(tingkat, pelajaran, soalList, rencana)
+	if fe != nil {
+		hapusGambar()
+		return kirimFiberError(c, fe)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"message":  fmt.Sprintf("Berhasil mengimpor %d soal ke %s tingkat %s", ringkasan.Disimpan, pelajaran, tingkat),
+		"jumlah":   ringkasan.Disimpan,
+		"ditimpa":  ringkasan.Ditimpa,
+		"dilewati": ringkasan.Dilewati,
+	})
+}
+
+func bacaFileSoal(file *multipart.FileHeader, format string) ([]services.BarisSoal, []models.KesalahanImpor, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("gagal membuka file soal: %v", err)
+	}
+	defer f.Close()
+	return services.BacaFileSoal(file.Filename, format, f)
+}
+
+func bacaZipGambar(file *multipart.FileHeader) (map[string]*zip.File, error) {
+	if file.Size > MaxUkuranZipGambar {
+		return nil, fmt.Errorf("ukuran ZIP gambar maksimal 50MB")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka ZIP gambar: %v", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca ZIP gambar: %v", err)
+	}
+	return services.GambarZip(data)
+}
+
+// simpanGambarZip menulis satu gambar dari ZIP, ukurannya dibatasi lagi karena header ZIP bisa dipalsukan
+func simpanGambarZip(zf *zip.File) (string, error) {
+	r, err := zf.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, validators.MaxFileSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > validators.MaxFileSize {
+		return "", fmt.Errorf("gambar %s lebih dari 5MB", zf.Name)
+	}
+	return utils.SaveImageBytes(zf.Name, data)
+}
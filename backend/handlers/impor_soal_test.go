@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"archive/zip"
+	"backend/models"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// imporRequest menyusun form impor soal, files berisi nama field -> nama file dan isinya
+func imporRequest(t *testing.T, tingkat, pelajaran string, files map[string]map[string][]byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("tingkat", tingkat)
+	w.WriteField("pelajaran", pelajaran)
+	for field, file := range files {
+		for nama, isi := range file {
+			part, err := w.CreateFormFile(field, nama)
+			if err != nil {
+				t.Fatalf("create form file: %v", err)
+			}
+			part.Write(isi)
+		}
+	}
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/soal/import", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func xlsxSoal(t *testing.T, rows [][]interface{}) []byte {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatalf("SetSheetRow: %v", err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zipGambar(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for nama, isi := range files {
+		f, err := w.Create(nama)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		f.Write(isi)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestImportSoalXLSX(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	xlsx := xlsxSoal(t, [][]interface{}{
+		{"Soal", "A", "B", "C", "D", "E", "Kunci", "Gambar"},
+		{"Satuan gaya?", "Newton", "Joule", "Watt", "Pascal", "Volt", "A", "gaya.PNG"},
+		{"Satuan energi?", "Newton", "Joule", "Watt", "Pascal", "Volt", "B"},
+	})
+	req := imporRequest(t, "XI", "Fisika", map[string]map[string][]byte{
+		"file":   {"soal.xlsx": xlsx},
+		"gambar": {"gambar.zip": zipGambar(t, map[string][]byte{"folder/gaya.png": []byte("\x89PNG")})},
+	})
+	status, body := doRequest(t, app, req)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	var impor []models.Soal
+	for _, soal := range store.Soal {
+		if soal.MataPelajaranID != "mp-mtk" && soal.MataPelajaranID != "mp-bindo" {
+			impor = append(impor, soal)
+		}
+	}
+	if len(impor) != 2 || impor[0].Gambar == nil || impor[1].Gambar != nil {
+		t.Fatalf("unexpected imported soal %+v", impor)
+	}
+	if _, err := os.Stat(filepath.Join("../web-ulangan/public", *impor[0].Gambar)); err != nil {
+		t.Errorf("image not written: %v", err)
+	}
+	benar := 0
+	for _, j := range store.Jawaban {
+		if (j.SoalID == impor[0].ID && j.Jawaban == "Newton") || (j.SoalID == impor[1].ID && j.Jawaban == "Joule") {
+			if j.Benar {
+				benar++
+			}
+		}
+	}
+	if benar != 2 {
+		t.Errorf("expected correct keys Newton and Joule, got %d marked", benar)
+	}
+}
+
+func TestImportSoalKesalahanPerBaris(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	csv := "Soal,A,B,C,D,E,Kunci,Gambar\n" +
+		"Soal benar,1,2,3,4,5,A,\n" +
+		",1,2,3,4,5,A,\n" +
+		"Empat pilihan,1,2,3,4,,B,\n" +
+		"Gambar hilang,1,2,3,4,5,C,tidak-ada.png\n"
+	req := imporRequest(t, "X", "MTK", map[string]map[string][]byte{"file": {"soal.csv": []byte(csv)}})
+	status, body := doRequest(t, app, req)
+	if status != http.StatusBadRequest {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+
+	var resp struct {
+		Kesalahan []models.KesalahanImpor `json:"kesalahan"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var baris []int
+	for _, k := range resp.Kesalahan {
+		baris = append(baris, k.Baris)
+	}
+	if len(baris) != 3 || baris[0] != 3 || baris[1] != 4 || baris[2] != 5 {
+		t.Errorf("expected errors on rows 3, 4 and 5, got %+v", resp.Kesalahan)
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	if len(store.Soal) != 2 {
+		t.Errorf("no soal may be saved when any row is invalid, store has %d", len(store.Soal))
+	}
+}
+
+func TestImportSoalMoodle(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	gift := "::T1:: Satuan gaya? {=Newton ~Joule ~Watt ~Pascal ~Volt}\n\nBumi itu bulat. {T}\n\nJelaskan hukum Newton I. {}\n"
+	req := imporRequest(t, "XI", "Fisika", map[string]map[string][]byte{"file": {"fisika.gift": []byte(gift)}})
+	if status, body := doRequest(t, app, req); status != http.StatusOK {
+		t.Fatalf("GIFT status = %d, body %s", status, body)
+	}
+
+	// Export Aiken dari Moodle berekstensi .txt, formatnya ditebak dari baris ANSWER:
+	aiken := "Satuan energi?\nA. Newton\nB. Joule\nANSWER: C\n"
+	req = imporRequest(t, "XI", "Fisika", map[string]map[string][]byte{"file": {"fisika.txt": []byte(aiken)}})
+	status, body := doRequest(t, app, req)
+	if status != http.StatusBadRequest || !bytes.Contains(body, []byte(`"baris":4`)) {
+		t.Fatalf("Aiken status = %d, body %s", status, body)
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	tipe := map[string]int{} // soal seed tidak mengisi tipe
+	for _, soal := range store.Soal {
+		tipe[soal.Tipe]++
+	}
+	// 2 soal seed ditambah pilihan ganda, benar/salah, dan esai dari GIFT; soal Aiken tidak tersimpan
+	if len(store.Soal) != 5 || tipe[models.TipePilihanGanda] != 2 || tipe[models.TipeEsai] != 1 {
+		t.Errorf("unexpected stored soal types %v", tipe)
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SimpanJawaban menyimpan satu jawaban begitu dipilih siswa, sehingga jawaban tidak hilang
+// ketika browser tertutup sebelum ujian dikumpulkan
+func (h *UjianHandler) SimpanJawaban(c *fiber.Ctx) error {
+	var request models.SimpanJawabanRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request format",
+		})
+	}
+
+	jawaban, fe := h.simpanJawaban(c.Params("id"), request)
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+	return c.JSON(jawabanTersimpan(jawaban))
+}
+
+// simpanJawaban memvalidasi lalu menyimpan satu jawaban, dipakai oleh endpoint HTTP dan websocket siswa
+func (h *UjianHandler) simpanJawaban(ujianID string, request models.SimpanJawabanRequest) (*models.JawabanSiswa, *fiber.Error) {
+	dipilih := request.JawabanIDs
+	if len(dipilih) == 0 && request.JawabanID != "" {
+		dipilih = []string{request.JawabanID}
+	}
+	if request.SiswaDetailID == "" || request.SoalID == "" || (len(dipilih) == 0 && request.JawabanTeks == "") {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "siswaDetailId, soalId dan jawabanId atau jawabanTeks wajib diisi")
+	}
+
+	ujian, err := h.Ujian.GetUjian(ujianID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Ujian tidak ditemukan")
+		}
+		log.Printf("Error fetching ujian %s: %v", ujianID, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	peserta, fe := h.cekPesertaUjian(ujianID, request.SiswaDetailID)
+	if fe != nil {
+		return nil, fe
+	}
+	if fe := h.cekBelumMengumpulkan(ujianID, request.SiswaDetailID); fe != nil {
+		return nil, fe
+	}
+	now := h.Clock.Now()
+	if _, terlambat := services.DurasiPengerjaan(peserta.WaktuMulai, now, ujian.WaktuPengerjaan); terlambat {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Waktu pengerjaan ujian sudah habis")
+	}
+
+	soalList, fe := h.soalPeserta(ujian, peserta)
+	if fe != nil {
+		return nil, fe
+	}
+	kunci := services.NewKunciJawaban(soalList, ujian)
+	if fe := cekJawaban(kunci, request.SoalID, dipilih, request.JawabanTeks); fe != nil {
+		return nil, fe
+	}
+
+	// ujian.ID dipakai, bukan ujianID, karena nilai c.Params hanya berlaku selama request
+	jawaban := jawabanSiswaBaru(kunci, ujian.ID, request.SiswaDetailID, request.SoalID, dipilih, request.JawabanTeks, now)
+	if err := h.Jawaban.SimpanJawaban(jawaban); err != nil {
+		log.Printf("Error saving jawaban: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Gagal menyimpan jawaban")
+	}
+	return &jawaban, nil
+}
+
+// cekJawaban memastikan soal isian singkat dan esai dijawab dengan teks, soal lain dengan pilihan yang sah
+func cekJawaban(kunci services.KunciJawaban, soalID string, dipilih []string, teks string) *fiber.Error {
+	if kunci.Teks(soalID) {
+		if !kunci.ValidTeks(soalID, teks) {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Jawaban teks untuk soal %s kosong atau lebih dari %d karakter", soalID, services.MaxPanjangJawabanTeks))
+		}
+		return nil
+	}
+	if !kunci.Valid(soalID, dipilih) {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Jawaban tidak valid untuk soal %s", soalID))
+	}
+	return nil
+}
+
+// jawabanSiswaBaru menyusun baris jawaban_siswa. Soal pilihan ganda kompleks menyimpan semua pilihan
+// (terurut) di JawabanIDs, soal isian singkat dan esai menyimpan teks di JawabanTeks,
+// soal lain menyimpan satu pilihan di JawabanID.
+func jawabanSiswaBaru(kunci services.KunciJawaban, ujianID, siswaDetailID, soalID string, dipilih []string, teks string, now time.Time) models.JawabanSiswa {
+	jawaban := models.JawabanSiswa{
+		ID:            services.IDBaru(),
+		SiswaDetailID: siswaDetailID,
+		UjianID:       ujianID,
+		SoalID:        soalID,
+		CreatedAt:     now.UTC().UnixMilli(),
+	}
+	switch {
+	case kunci.Teks(soalID):
+		jawaban.JawabanTeks = strings.TrimSpace(teks)
+	case kunci.Kompleks(soalID):
+		jawaban.JawabanIDs = append([]string(nil), dipilih...)
+		sort.Strings(jawaban.JawabanIDs)
+	default:
+		jawaban.JawabanID = dipilih[0]
+	}
+	return jawaban
+}
+
+func jawabanTersimpan(jawaban *models.JawabanSiswa) models.SimpanJawabanResponse {
+	return models.SimpanJawabanResponse{
+		Success:      true,
+		Message:      "Jawaban tersimpan",
+		SoalID:       jawaban.SoalID,
+		JawabanID:    jawaban.JawabanID,
+		JawabanIDs:   jawaban.JawabanIDs,
+		JawabanTeks:  jawaban.JawabanTeks,
+		DisimpanPada: time.UnixMilli(jawaban.CreatedAt),
+	}
+}
+
+// gabungJawaban menggabungkan jawaban tersimpan dengan jawaban baru, satu jawaban per soal
+// dengan jawaban baru menimpa yang lama
+func gabungJawaban(tersimpan, baru []models.JawabanSiswa) []models.JawabanSiswa {
+	indeks := make(map[string]int, len(tersimpan)+len(baru))
+	var result []models.JawabanSiswa
+	for _, js := range append(append([]models.JawabanSiswa{}, tersimpan...), baru...) {
+		if i, ok := indeks[js.SoalID]; ok {
+			result[i] = js
+			continue
+		}
+		indeks[js.SoalID] = len(result)
+		result = append(result, js)
+	}
+	return result
+}
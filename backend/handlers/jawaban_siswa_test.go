@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"backend/clock"
+	"backend/models"
+	"backend/repositories"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSimpanJawaban(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, clk := newTestApp(t, store, pukul(7, 40))
+
+	simpan := func(soalID, jawabanID string) {
+		t.Helper()
+		request := models.SimpanJawabanRequest{SiswaDetailID: "siswa-1", SoalID: soalID, JawabanID: jawabanID}
+		var resp models.SimpanJawabanResponse
+		if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/jawaban", request, &resp); status != http.StatusOK {
+			t.Fatalf("status = %d, resp %+v", status, resp)
+		}
+		if !resp.Success || resp.SoalID != soalID || !resp.DisimpanPada.Equal(clk.Now()) {
+			t.Errorf("unexpected response %+v", resp)
+		}
+	}
+
+	simpan("soal-1", "s1-a")
+	clk.Advance(time.Minute)
+	// Siswa mengganti jawaban, baris lama ditimpa
+	simpan("soal-1", "s1-b")
+	simpan("soal-2", "s2-b")
+
+	jawaban, _ := store.GetJawabanSiswa("ujian-mtk", "siswa-1")
+	if len(jawaban) != 2 || jawaban[0].JawabanID != "s1-b" {
+		t.Fatalf("expected one row per soal with the latest answer, got %+v", jawaban)
+	}
+
+	// Submit tanpa jawaban menilai jawaban yang sudah tersimpan
+	clk.Set(pukul(8, 0))
+	request := models.SubmitUjianRequest{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1"}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("submit status = %d, resp %+v", status, resp)
+	}
+	if resp.Nilai != 100 || resp.Benar != 2 {
+		t.Errorf("unexpected submit response %+v", resp)
+	}
+
+	// Setelah dikumpulkan jawaban tidak bisa diubah lagi
+	body := models.SimpanJawabanRequest{SiswaDetailID: "siswa-1", SoalID: "soal-1", JawabanID: "s1-a"}
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/jawaban", body, nil); status != http.StatusConflict {
+		t.Errorf("save after submit: status = %d, want 409", status)
+	}
+}
+
+func TestSubmitUjianMenimpaJawabanTersimpan(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	store.JawabanSiswa = []models.JawabanSiswa{
+		{ID: "js-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", SoalID: "soal-1", JawabanID: "s1-a"},
+		{ID: "js-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", SoalID: "soal-2", JawabanID: "s2-b"},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	if resp.Nilai != 100 || resp.Benar != 2 || resp.Salah != 0 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	jawaban, _ := store.GetJawabanSiswa("ujian-mtk", "siswa-1")
+	if len(jawaban) != 2 {
+		t.Errorf("expected 2 jawaban_siswa rows, got %+v", jawaban)
+	}
+}
+
+func TestSimpanJawabanDitolak(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+		{ID: "peserta-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2", WaktuMulai: pukul(5, 0)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	tests := []struct {
+		name    string
+		ujianID string
+		request models.SimpanJawabanRequest
+		status  int
+	}{
+		{"tanpa soal", "ujian-mtk", models.SimpanJawabanRequest{SiswaDetailID: "siswa-1", JawabanID: "s1-b"}, http.StatusBadRequest},
+		{"ujian tidak ada", "tidak-ada", models.SimpanJawabanRequest{SiswaDetailID: "siswa-1", SoalID: "soal-1", JawabanID: "s1-b"}, http.StatusNotFound},
+		{"belum memulai", "ujian-mtk", models.SimpanJawabanRequest{SiswaDetailID: "siswa-xi", SoalID: "soal-1", JawabanID: "s1-b"}, http.StatusForbidden},
+		{"waktu habis", "ujian-mtk", models.SimpanJawabanRequest{SiswaDetailID: "siswa-2", SoalID: "soal-1", JawabanID: "s1-b"}, http.StatusForbidden},
+		{"jawaban dari soal lain", "ujian-mtk", models.SimpanJawabanRequest{SiswaDetailID: "siswa-1", SoalID: "soal-1", JawabanID: "s2-b"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := doJSON(t, app, http.MethodPut, "/api/ujian/"+tt.ujianID+"/jawaban", tt.request, nil); status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	if len(store.JawabanSiswa) != 0 {
+		t.Errorf("rejected answers must not be stored, got %+v", store.JawabanSiswa)
+	}
+}
+
+func TestSimpanJawabanWebSocket(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	h := NewUjianHandler(repositories.NewMemoryRepositories(store), clock.NewFake(pukul(7, 40)))
+
+	pesan := models.SimpanJawabanRequest{Type: models.PesanSimpanJawaban, SoalID: "soal-1", JawabanID: "s1-b"}
+	if resp := simpanJawabanWS(h, "ujian-mtk", "siswa-1", pesan); resp.Type != models.PesanJawabanTersimpan || !resp.Success {
+		t.Errorf("unexpected reply %+v", resp)
+	}
+
+	// Siswa lain tidak bisa menulis jawaban lewat koneksi milik siswa-1
+	pesan.SiswaDetailID = "siswa-2"
+	if resp := simpanJawabanWS(h, "ujian-mtk", "siswa-1", pesan); resp.Type != models.PesanJawabanGagal {
+		t.Errorf("expected %s, got %+v", models.PesanJawabanGagal, resp)
+	}
+
+	jawaban, _ := store.GetJawabanSiswa("ujian-mtk", "siswa-1")
+	if len(jawaban) != 1 || jawaban[0].JawabanID != "s1-b" {
+		t.Errorf("unexpected stored answers %+v", jawaban)
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MulaiUjian memverifikasi token ujian yang dimasukkan siswa dan mencatat waktu mulainya.
+// Memasukkan token lagi (misalnya setelah memuat ulang halaman) tidak menggeser waktu mulai.
+func (h *UjianHandler) MulaiUjian(c *fiber.Ctx) error {
+	ujianID := c.Params("id")
+
+	var request models.MulaiUjianRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request format",
+		})
+	}
+
+	token := strings.ToUpper(strings.TrimSpace(request.Token))
+	if token == "" || request.SiswaDetailID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Token dan siswaDetailId wajib diisi",
+		})
+	}
+
+	ujian, fe := h.cekTokenUjian(ujianID, token)
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+	if _, fe := h.cekSiswaUjian(ujian, request.SiswaDetailID); fe != nil {
+		return kirimFiberError(c, fe)
+	}
+	if fe := h.cekBelumMengumpulkan(ujianID, request.SiswaDetailID); fe != nil {
+		return kirimFiberError(c, fe)
+	}
+
+	// Siswa yang masuk lagi dengan soal yang sudah tercatat tidak diundi ulang, supaya perubahan bank soal
+	// setelahnya tidak mengunci siswa di luar ujian
+	lama, err := h.Peserta.GetPeserta(ujian.ID, request.SiswaDetailID)
+	if err != nil && err != repositories.ErrNotFound {
+		log.Printf("Error fetching peserta ujian: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+
+	// Soal siswa (hasil undian atau semua soal mata pelajaran) disusun sebelum siswa dicatat supaya bank soal
+	// yang kurang tidak meninggalkan peserta tanpa soal. Soal ini dicatat sehingga penilaian ulang, lembar
+	// jawaban, dan analisis tetap memakai soal yang dikerjakan siswa walaupun bank soal berubah.
+	var soalIDs []string
+	if lama == nil || len(lama.SoalIDs) == 0 {
+		soalList, fe := h.soalPeserta(ujian, &models.UjianPeserta{UjianID: ujian.ID, SiswaDetailID: request.SiswaDetailID})
+		if fe != nil {
+			return kirimFiberError(c, fe)
+		}
+		for _, soal := range soalList {
+			soalIDs = append(soalIDs, soal.ID)
+		}
+	}
+
+	// ujian.ID dipakai, bukan ujianID, karena nilai c.Params hanya berlaku selama request
+	peserta, err := h.Peserta.MulaiUjian(ujian.ID, request.SiswaDetailID, h.Clock.Now())
+	if err != nil {
+		log.Printf("Error saving peserta ujian: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+	if len(soalIDs) > 0 && len(peserta.SoalIDs) == 0 {
+		var seed int64
+		if services.MemakaiUndian(ujian) {
+			seed = services.SeedSoal(ujian.ID, request.SiswaDetailID)
+		}
+		if err := h.Peserta.SimpanSoalPeserta(peserta.ID, seed, soalIDs); err != nil {
+			log.Printf("Error saving soal peserta ujian: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"message": "Database error",
+			})
+		}
+	}
+
+	batasWaktu := services.BatasWaktu(peserta.WaktuMulai, ujian.WaktuPengerjaan)
+	sisaDetik := int(batasWaktu.Sub(h.Clock.Now()) / time.Second)
+	if sisaDetik < 0 {
+		sisaDetik = 0
+	}
+
+	return c.JSON(models.MulaiUjianResponse{
+		Success:       true,
+		Message:       "Token diterima, ujian dimulai",
+		UjianID:       ujian.ID,
+		MataPelajaran: ujian.MataPelajaran.Pelajaran,
+		WaktuMulai:    peserta.WaktuMulai,
+		BatasWaktu:    batasWaktu,
+		SisaDetik:     sisaDetik,
+	})
+}
+
+// cekTokenUjian memastikan ujian ada, sedang aktif, dan token cocok dengan token dari tracker
+func (h *UjianHandler) cekTokenUjian(ujianID, token string) (*models.Ujian, *fiber.Error) {
+	ujian, err := h.Ujian.GetUjian(ujianID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Ujian tidak ditemukan")
+		}
+		log.Printf("Error fetching ujian %s: %v", ujianID, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	if ujian.Status != "active" {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Ujian belum dimulai atau sudah selesai")
+	}
+	if ujian.Token == "" || token != ujian.Token {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Token ujian tidak valid")
+	}
+	return ujian, nil
+}
+
+// cekSiswaUjian memastikan siswa ada dan kelasnya setingkat dengan mata pelajaran ujian
+func (h *UjianHandler) cekSiswaUjian(ujian *models.Ujian, siswaDetailID string) (*models.SiswaDetail, *fiber.Error) {
+	siswa, err := h.Siswa.GetSiswaDetail(siswaDetailID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Siswa tidak ditemukan")
+		}
+		log.Printf("Error fetching siswa %s: %v", siswaDetailID, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	if siswa.Kelas.Tingkat != ujian.MataPelajaran.Tingkat {
+		return nil, fiber.NewError(fiber.StatusForbidden,
+			fmt.Sprintf("Ujian ini untuk tingkat %s, bukan tingkat %s", ujian.MataPelajaran.Tingkat, siswa.Kelas.Tingkat))
+	}
+	return siswa, nil
+}
+
+// cekPesertaUjian mengambil catatan mulai siswa, ditolak bila siswa belum memasukkan token
+func (h *UjianHandler) cekPesertaUjian(ujianID, siswaDetailID string) (*models.UjianPeserta, *fiber.Error) {
+	peserta, err := h.Peserta.GetPeserta(ujianID, siswaDetailID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusForbidden, "Mulai ujian dengan token terlebih dahulu")
+		}
+		log.Printf("Error fetching peserta ujian: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	return peserta, nil
+}
+
+// cekBelumMengumpulkan menolak siswa yang sudah memiliki hasil untuk ujian ini
+func (h *UjianHandler) cekBelumMengumpulkan(ujianID, siswaDetailID string) *fiber.Error {
+	_, err := h.Hasil.GetHasilSiswa(ujianID, siswaDetailID)
+	if err == nil {
+		return fiber.NewError(fiber.StatusConflict, "Ujian ini sudah dikumpulkan")
+	}
+	if err != repositories.ErrNotFound {
+		log.Printf("Error fetching hasil siswa: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	return nil
+}
+
+// soalPeserta soal yang dikerjakan siswa beserta kuncinya. Soal yang tercatat saat siswa mulai selalu dipakai,
+// termasuk yang sudah diarsipkan. Tanpa catatan: soal undian siswa bila ujian memakai jumlah soal atau kuota,
+// selain itu semua soal mata pelajaran ujian.
+func (h *UjianHandler) soalPeserta(ujian *models.Ujian, peserta *models.UjianPeserta) ([]models.SoalInput, *fiber.Error) {
+	var tercatat []string
+	if peserta != nil {
+		tercatat = peserta.SoalIDs
+	}
+	bank, err := h.Soal.GetSoalUjianTercatat(ujian.ID, tercatat)
+	if err != nil {
+		log.Printf("Error fetching soal ujian %s: %v", ujian.ID, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	soalList, err := services.SoalPeserta(bank, ujian, peserta)
+	if err != nil {
+		log.Printf("Error drawing soal ujian %s for siswa %s: %v", ujian.ID, peserta.SiswaDetailID, err)
+		return nil, fiber.NewError(fiber.StatusConflict, "Bank soal tidak cukup untuk pengaturan soal ujian ini: "+err.Error())
+	}
+	return soalList, nil
+}
+
+func kirimFiberError(c *fiber.Ctx, fe *fiber.Error) error {
+	return c.Status(fe.Code).JSON(fiber.Map{
+		"success": false,
+		"message": fe.Message,
+	})
+}
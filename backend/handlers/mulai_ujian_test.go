@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"backend/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMulaiUjian(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "active"
+	store.Ujian[0].Token = "ABCDE"
+	app, clk := newTestApp(t, store, pukul(7, 40))
+
+	request := models.MulaiUjianRequest{Token: "abcde", SiswaDetailID: "siswa-1"}
+	var resp models.MulaiUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/ujian-mtk/start", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	if !resp.WaktuMulai.Equal(pukul(7, 40)) || resp.MataPelajaran != "MTK" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if !resp.BatasWaktu.Equal(pukul(9, 40)) || resp.SisaDetik != 7200 {
+		t.Errorf("batasWaktu = %s, sisaDetik = %d", resp.BatasWaktu, resp.SisaDetik)
+	}
+
+	// Memasukkan token lagi setelah memuat ulang halaman tidak menggeser waktu mulai
+	clk.Advance(10 * time.Minute)
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/ujian-mtk/start", request, &resp); status != http.StatusOK {
+		t.Fatalf("second start: status = %d", status)
+	}
+	if !resp.WaktuMulai.Equal(pukul(7, 40)) || resp.SisaDetik != 6600 {
+		t.Errorf("waktuMulai moved to %s, sisaDetik = %d", resp.WaktuMulai, resp.SisaDetik)
+	}
+
+	peserta, err := store.GetPeserta("ujian-mtk", "siswa-1")
+	if err != nil || !peserta.WaktuMulai.Equal(pukul(7, 40)) {
+		t.Errorf("expected peserta recorded at 07:40, got %+v (%v)", peserta, err)
+	}
+}
+
+func TestMulaiUjianDitolak(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "active"
+	store.Ujian[0].Token = "ABCDE"
+	store.Ujian[1].Token = "FGHIJ"
+	store.Hasil = []models.HasilDetail{{ID: "hasil-2", SiswaDetailID: "siswa-2", UjianID: "ujian-mtk"}}
+	app, _ := newTestApp(t, store, pukul(7, 40))
+
+	tests := []struct {
+		name    string
+		ujianID string
+		request models.MulaiUjianRequest
+		status  int
+	}{
+		{"tanpa token", "ujian-mtk", models.MulaiUjianRequest{SiswaDetailID: "siswa-1"}, http.StatusBadRequest},
+		{"token salah", "ujian-mtk", models.MulaiUjianRequest{Token: "FGHIJ", SiswaDetailID: "siswa-1"}, http.StatusForbidden},
+		{"ujian belum aktif", "ujian-bindo", models.MulaiUjianRequest{Token: "FGHIJ", SiswaDetailID: "siswa-1"}, http.StatusForbidden},
+		{"tingkat berbeda", "ujian-mtk", models.MulaiUjianRequest{Token: "ABCDE", SiswaDetailID: "siswa-xi"}, http.StatusForbidden},
+		{"sudah mengumpulkan", "ujian-mtk", models.MulaiUjianRequest{Token: "ABCDE", SiswaDetailID: "siswa-2"}, http.StatusConflict},
+		{"ujian tidak ada", "tidak-ada", models.MulaiUjianRequest{Token: "ABCDE", SiswaDetailID: "siswa-1"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp map[string]interface{}
+			if status := doJSON(t, app, http.MethodPost, "/api/ujian/"+tt.ujianID+"/start", tt.request, &resp); status != tt.status {
+				t.Errorf("status = %d, want %d (%v)", status, tt.status, resp)
+			}
+		})
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	if len(store.Peserta) != 0 {
+		t.Errorf("rejected start must not be recorded, got %+v", store.Peserta)
+	}
+}
+
+func TestMulaiUjianLagiSetelahBankSoalBerkurang(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "active"
+	store.Ujian[0].Token = "ABCDE"
+	store.Ujian[0].JumlahSoal = 2
+	store.Soal = append(store.Soal, models.Soal{ID: "soal-3", Soal: "Jelaskan bilangan prima", Tipe: models.TipeEsai, MataPelajaranID: "mp-mtk"})
+	app, _ := newTestApp(t, store, pukul(7, 40))
+
+	request := models.MulaiUjianRequest{Token: "ABCDE", SiswaDetailID: "siswa-1"}
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/ujian-mtk/start", request, nil); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	// Bank soal tinggal satu soal aktif, kurang dari jumlah soal ujian
+	for _, id := range []string{"soal-1", "soal-2"} {
+		if status := doJSON(t, app, http.MethodDelete, "/api/soal/"+id, nil, nil); status != http.StatusOK {
+			t.Fatalf("HapusSoal %s status = %d", id, status)
+		}
+	}
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/ujian-mtk/start", request, nil); status != http.StatusOK {
+		t.Errorf("re-entering with recorded soal: status = %d, want 200", status)
+	}
+	var soal models.SoalUjianResponse
+	doJSON(t, app, http.MethodGet, "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=siswa-1", nil, &soal)
+	if len(soal.Soal) != 2 {
+		t.Errorf("expected the 2 recorded soal, got %v", urutanSoal(soal))
+	}
+
+	// Siswa lain yang baru mulai tetap ditolak karena bank soal tidak cukup
+	request.SiswaDetailID = "siswa-2"
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/ujian-mtk/start", request, nil); status != http.StatusConflict {
+		t.Errorf("new student with a short bank: status = %d, want 409", status)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	validators "backend/validations"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SimpanPengaturanPenilaian mengatur penalti jawaban salah dan nilai parsial pilihan ganda kompleks khusus
+// satu ujian. Keduanya selalu dikirim bersama, nilai null mengembalikan ujian ke pengaturan mata pelajarannya.
+// Seperti pengaturan soal, penilaian hanya bisa diubah selama ujian masih pending supaya siswa dalam satu
+// ujian dinilai dengan aturan yang sama.
+func (h *UjianHandler) SimpanPengaturanPenilaian(c *fiber.Ctx) error {
+	ujianID := c.Params("id")
+
+	var request models.PengaturanPenilaianRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request format",
+		})
+	}
+	if err := validators.ValidatePengaturanPenilaian(request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	ujian, err := h.Ujian.GetUjian(ujianID)
+	if err == repositories.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Ujian tidak ditemukan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error fetching ujian %s: %v", ujianID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+	if ujian.Status != "pending" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": "Pengaturan penilaian hanya bisa diubah sebelum ujian dimulai",
+		})
+	}
+
+	if err := h.Ujian.SimpanPengaturanPenilaian(ujian.ID, request); err != nil {
+		log.Printf("Error saving pengaturan penilaian ujian %s: %v", ujian.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+
+	penaltiSalah := ujian.MataPelajaran.PenaltiSalah
+	if request.PenaltiSalah != nil {
+		penaltiSalah = *request.PenaltiSalah
+	}
+	nilaiParsial := ujian.MataPelajaran.NilaiParsial
+	if request.NilaiParsial != nil {
+		nilaiParsial = *request.NilaiParsial
+	}
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"penaltiSalah": penaltiSalah,
+		"nilaiParsial": nilaiParsial,
+	})
+}
+
+// SimpanPengaturanMataPelajaran mengubah pengaturan bawaan satu mata pelajaran yang dipakai ujian tanpa
+// pengaturan sendiri. Ujian yang sudah dimulai tetap memakai nilai lama, perubahan berlaku untuk ujian berikutnya.
+// Jumlah pilihan baru hanya berlaku untuk soal baru, soal yang sudah ada tetap dengan jumlah pilihannya.
+func (h *SoalHandler) SimpanPengaturanMataPelajaran(c *fiber.Ctx) error {
+	var request models.PengaturanMataPelajaranRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request format",
+		})
+	}
+	if err := validators.ValidatePengaturanMataPelajaran(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	mp, err := h.Soal.GetMataPelajaran(request.Tingkat, request.Pelajaran)
+	if err == repositories.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Mata pelajaran tidak ditemukan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error fetching mata pelajaran %s %s: %v", request.Tingkat, request.Pelajaran, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+
+	if request.PenaltiSalah != nil {
+		mp.PenaltiSalah = *request.PenaltiSalah
+	}
+	if request.NilaiParsial != nil {
+		mp.NilaiParsial = *request.NilaiParsial
+	}
+	if request.JumlahPilihan != nil {
+		mp.JumlahPilihan = *request.JumlahPilihan
+	}
+	if err := h.Soal.SimpanPengaturanMataPelajaran(*mp); err != nil {
+		log.Printf("Error saving pengaturan mata pelajaran %s: %v", mp.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"mataPelajaran": mp,
+	})
+}
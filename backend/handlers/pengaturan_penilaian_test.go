@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	"net/http"
+	"testing"
+)
+
+func penalti(p float64) *float64 {
+	return &p
+}
+
+func parsial(b bool) *bool {
+	return &b
+}
+
+func TestSimpanPengaturanPenilaian(t *testing.T) {
+	store := seedStore()
+	store.MataPelajaran[0].PenaltiSalah = 0.5
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, clk := newTestApp(t, store, pukul(6, 0))
+
+	for _, tt := range []struct {
+		name       string
+		pengaturan models.PengaturanPenilaianRequest
+	}{
+		{"penalti negatif", models.PengaturanPenilaianRequest{PenaltiSalah: penalti(-0.25)}},
+		{"penalti melebihi bobot soal", models.PengaturanPenilaianRequest{PenaltiSalah: penalti(1.5)}},
+	} {
+		if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/penilaian", tt.pengaturan, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tt.name, status)
+		}
+	}
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/tidak-ada/penilaian", models.PengaturanPenilaianRequest{}, nil); status != http.StatusNotFound {
+		t.Errorf("unknown ujian: status = %d, want 404", status)
+	}
+
+	pengaturan := models.PengaturanPenilaianRequest{PenaltiSalah: penalti(0.25), NilaiParsial: parsial(true)}
+	var resp map[string]interface{}
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/penilaian", pengaturan, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %v", status, resp)
+	}
+	if resp["penaltiSalah"] != 0.25 || resp["nilaiParsial"] != true {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	// null mengembalikan ujian ke pengaturan mata pelajaran
+	resp = nil
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/penilaian", map[string]interface{}{"penaltiSalah": nil, "nilaiParsial": nil}, &resp); status != http.StatusOK {
+		t.Fatalf("reset status = %d, resp %v", status, resp)
+	}
+	if ujian, _ := store.GetUjian("ujian-mtk"); resp["penaltiSalah"] != 0.5 || resp["nilaiParsial"] != false || ujian.PenaltiSalah != 0.5 || ujian.NilaiParsial {
+		t.Errorf("null must follow mata pelajaran, got %v", resp)
+	}
+
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/penilaian", pengaturan, nil); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	clk.Set(pukul(7, 40))
+	store.Lock()
+	store.Ujian[0].Status = "active"
+	store.Unlock()
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/penilaian", models.PengaturanPenilaianRequest{}, nil); status != http.StatusConflict {
+		t.Errorf("changing an active ujian: status = %d, want 409", status)
+	}
+
+	clk.Set(pukul(8, 0))
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-a"}},
+	}
+	var hasil models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &hasil); status != http.StatusOK {
+		t.Fatalf("submit status = %d, resp %+v", status, hasil)
+	}
+	if hasil.Rincian == nil || hasil.Rincian.PenaltiSalah != 0.25 || hasil.Rincian.Pengurangan != 0.25 || !hasil.Rincian.NilaiParsial {
+		t.Errorf("submit must use the saved penalti, got %+v", hasil.Rincian)
+	}
+}
+
+func TestSimpanPengaturanMataPelajaran(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "active"
+	store.Ujian = append(store.Ujian, repositories.MemoryUjian{
+		ID: "ujian-mtk-2", MataPelajaranID: "mp-mtk", SesiID: "sesi-2", JamMulai: "10:00", JamSelesai: "12:00", Status: "pending", WaktuPengerjaan: 120,
+	})
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	for _, tt := range []struct {
+		name       string
+		pengaturan models.PengaturanMataPelajaranRequest
+	}{
+		{"tingkat tidak dikenal", models.PengaturanMataPelajaranRequest{Tingkat: "XIII", Pelajaran: "MTK", PenaltiSalah: penalti(0.5)}},
+		{"pelajaran kosong", models.PengaturanMataPelajaranRequest{Tingkat: "X", Pelajaran: " ", PenaltiSalah: penalti(0.5)}},
+		{"tanpa pengaturan", models.PengaturanMataPelajaranRequest{Tingkat: "X", Pelajaran: "MTK"}},
+		{"penalti salah di samping nilai parsial", models.PengaturanMataPelajaranRequest{Tingkat: "X", Pelajaran: "MTK", PenaltiSalah: penalti(-1), NilaiParsial: parsial(true)}},
+		{"penalti melebihi bobot soal", models.PengaturanMataPelajaranRequest{Tingkat: "X", Pelajaran: "MTK", PenaltiSalah: penalti(2)}},
+	} {
+		if status := doJSON(t, app, http.MethodPut, "/api/mata-pelajaran/pengaturan", tt.pengaturan, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tt.name, status)
+		}
+	}
+	fisika := models.PengaturanMataPelajaranRequest{Tingkat: "X", Pelajaran: "Fisika", PenaltiSalah: penalti(0.5)}
+	if status := doJSON(t, app, http.MethodPut, "/api/mata-pelajaran/pengaturan", fisika, nil); status != http.StatusNotFound {
+		t.Errorf("unknown mata pelajaran: status = %d, want 404", status)
+	}
+
+	var resp struct {
+		Success       bool                 `json:"success"`
+		MataPelajaran models.MataPelajaran `json:"mataPelajaran"`
+	}
+	request := models.PengaturanMataPelajaranRequest{Tingkat: "X", Pelajaran: " MTK ", PenaltiSalah: penalti(0.5)}
+	if status := doJSON(t, app, http.MethodPut, "/api/mata-pelajaran/pengaturan", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	if resp.MataPelajaran.ID != "mp-mtk" || resp.MataPelajaran.PenaltiSalah != 0.5 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	// Field yang tidak dikirim tidak berubah
+	request = models.PengaturanMataPelajaranRequest{Tingkat: "X", Pelajaran: "MTK", NilaiParsial: parsial(true)}
+	if status := doJSON(t, app, http.MethodPut, "/api/mata-pelajaran/pengaturan", request, &resp); status != http.StatusOK {
+		t.Fatalf("nilaiParsial status = %d, resp %+v", status, resp)
+	}
+	if resp.MataPelajaran.PenaltiSalah != 0.5 || !resp.MataPelajaran.NilaiParsial {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	// Ujian yang sedang berjalan tetap dinilai dengan aturan lama, ujian berikutnya memakai aturan baru
+	if ujian, _ := store.GetUjian("ujian-mtk"); ujian.PenaltiSalah != 0 || ujian.NilaiParsial {
+		t.Errorf("active ujian = penalti %v, parsial %v, want the old 0 and false", ujian.PenaltiSalah, ujian.NilaiParsial)
+	}
+	if ujian, _ := store.GetUjian("ujian-mtk-2"); ujian.PenaltiSalah != 0.5 || !ujian.NilaiParsial {
+		t.Errorf("pending ujian = penalti %v, parsial %v, want 0.5 and true", ujian.PenaltiSalah, ujian.NilaiParsial)
+	}
+}
+
+func TestSimpanJumlahPilihanMataPelajaran(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	for _, jumlah := range []int{1, 7} {
+		request := models.PengaturanMataPelajaranRequest{Tingkat: "X", Pelajaran: "MTK", JumlahPilihan: &jumlah}
+		if status := doJSON(t, app, http.MethodPut, "/api/mata-pelajaran/pengaturan", request, nil); status != http.StatusBadRequest {
+			t.Errorf("jumlahPilihan %d: status = %d, want 400", jumlah, status)
+		}
+	}
+
+	empat := 4
+	request := models.PengaturanMataPelajaranRequest{Tingkat: "X", Pelajaran: "MTK", JumlahPilihan: &empat}
+	if status := doJSON(t, app, http.MethodPut, "/api/mata-pelajaran/pengaturan", request, nil); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if mp, _ := store.GetMataPelajaran("X", "MTK"); mp.JumlahPilihan != 4 {
+		t.Errorf("mata pelajaran jumlahPilihan = %d, want 4", mp.JumlahPilihan)
+	}
+
+	// Soal lima pilihan yang sudah ada tetap bisa diedit tanpa menyebut jumlah pilihannya
+	var detail struct {
+		Data models.SoalDetail `json:"data"`
+	}
+	if status := doJSON(t, app, http.MethodGet, "/api/soal/soal-1", nil, &detail); status != http.StatusOK || detail.Data.JumlahPilihan != 5 {
+		t.Fatalf("GetSoal status = %d, jumlahPilihan = %d, want 5", status, detail.Data.JumlahPilihan)
+	}
+	diubah := detail.Data.SoalInput
+	diubah.Soal = "1 + 1 sama dengan?"
+	diubah.JumlahPilihan = 0
+	if status, body := doRequest(t, app, updateSoalRequest(t, "soal-1", diubah, nil, false)); status != http.StatusOK {
+		t.Fatalf("UpdateSoal status = %d, body %s", status, body)
+	}
+
+	// Soal baru mengikuti jumlah pilihan yang baru
+	lima := soalInput("3 + 4 = ?")
+	if status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{lima}, nil)); status != http.StatusBadRequest {
+		t.Errorf("5 pilihan: status = %d, body %s, want 400", status, body)
+	}
+	baru := soalInput("3 + 4 = ?")
+	baru.Pilihan = baru.Pilihan[:4]
+	if status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{baru}, nil)); status != http.StatusOK {
+		t.Errorf("4 pilihan: status = %d, body %s", status, body)
+	}
+}
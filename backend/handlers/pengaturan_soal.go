@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+	validators "backend/validations"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SimpanPengaturanSoal mengatur jumlah soal dan kuota topik/kesulitan yang diundi untuk setiap siswa.
+// Pengaturan hanya bisa diubah selama ujian masih pending dan harus bisa dipenuhi bank soal saat ini.
+// jumlahSoal 0 tanpa kuota mengembalikan ujian ke semua soal mata pelajaran.
+func (h *UjianHandler) SimpanPengaturanSoal(c *fiber.Ctx) error {
+	ujianID := c.Params("id")
+
+	var request models.PengaturanSoalRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request format",
+		})
+	}
+	if err := validators.ValidatePengaturanSoal(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	ujian, err := h.Ujian.GetUjian(ujianID)
+	if err == repositories.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Ujian tidak ditemukan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error fetching ujian %s: %v", ujianID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+	if ujian.Status != "pending" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": "Pengaturan soal hanya bisa diubah sebelum ujian dimulai",
+		})
+	}
+
+	bank, err := h.Soal.GetSoalUjian(ujian.ID)
+	if err != nil {
+		log.Printf("Error fetching soal ujian %s: %v", ujian.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+	if len(request.KuotaSoal) > 0 || request.JumlahSoal > 0 {
+		if _, err := services.UndiSoal(bank, request.JumlahSoal, request.KuotaSoal, 0); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+			})
+		}
+	}
+
+	if err := h.Ujian.SimpanPengaturanSoal(ujian.ID, request); err != nil {
+		log.Printf("Error saving pengaturan soal ujian %s: %v", ujian.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+
+	message := fmt.Sprintf("Setiap siswa mengerjakan semua %d soal %s", len(bank), ujian.MataPelajaran.Pelajaran)
+	if request.JumlahSoal > 0 || len(request.KuotaSoal) > 0 {
+		jumlah := request.JumlahSoal
+		if jumlah == 0 {
+			for _, k := range request.KuotaSoal {
+				jumlah += k.Jumlah
+			}
+		}
+		message = fmt.Sprintf("Setiap siswa mengerjakan %d dari %d soal %s yang diundi", jumlah, len(bank), ujian.MataPelajaran.Pelajaran)
+	}
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"message":    message,
+		"jumlahSoal": request.JumlahSoal,
+		"kuotaSoal":  request.KuotaSoal,
+		"jumlahBank": len(bank),
+	})
+}
+
+// SimpanTampilkanKunci mengatur apakah siswa boleh melihat jawaban yang benar di lembar jawaban hasilnya
+// (GET /api/hasil/:id?detail=true). Bisa diubah kapan saja, misalnya setelah semua sesi ujian selesai.
+func (h *UjianHandler) SimpanTampilkanKunci(c *fiber.Ctx) error {
+	ujianID := c.Params("id")
+
+	var request models.TampilkanKunciRequest
+	if err := c.BodyParser(&request); err != nil || request.TampilkanKunci == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "tampilkanKunci wajib diisi true atau false",
+		})
+	}
+
+	err := h.Ujian.SimpanTampilkanKunci(ujianID, *request.TampilkanKunci)
+	if err == repositories.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Ujian tidak ditemukan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error saving tampilkan kunci ujian %s: %v", ujianID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Database error",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"tampilkanKunci": *request.TampilkanKunci,
+	})
+}
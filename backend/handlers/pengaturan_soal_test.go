@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/services"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestUndianSoalUjian(t *testing.T) {
+	store := seedStore()
+	store.Soal = append(store.Soal, models.Soal{ID: "soal-3", Soal: "x + 2 = 5, x = ?", MataPelajaranID: "mp-mtk", Topik: "Aljabar", Kesulitan: models.KesulitanSulit})
+	store.Jawaban = append(store.Jawaban,
+		models.Jawaban{ID: "s3-a", SoalID: "soal-3", Jawaban: "3", Benar: true},
+		models.Jawaban{ID: "s3-b", SoalID: "soal-3", Jawaban: "7"},
+	)
+	// Pengaturan diubah sebelum sesi 1 dimulai, status ujian diaktifkan sendiri di bawah
+	app, clk := newTestApp(t, store, pukul(6, 0))
+
+	for _, tt := range []struct {
+		name       string
+		pengaturan models.PengaturanSoalRequest
+	}{
+		{"jumlah melebihi bank soal", models.PengaturanSoalRequest{JumlahSoal: 4}},
+		{"kuota tidak cukup", models.PengaturanSoalRequest{JumlahSoal: 2, KuotaSoal: []models.KuotaSoal{{Kesulitan: "SULIT", Jumlah: 2}}}},
+		{"kuota melebihi jumlah", models.PengaturanSoalRequest{JumlahSoal: 1, KuotaSoal: []models.KuotaSoal{{Topik: "Aljabar", Jumlah: 2}}}},
+		{"kesulitan tidak dikenal", models.PengaturanSoalRequest{KuotaSoal: []models.KuotaSoal{{Kesulitan: "SEDIKIT", Jumlah: 1}}}},
+	} {
+		if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/pengaturan-soal", tt.pengaturan, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tt.name, status)
+		}
+	}
+
+	pengaturan := models.PengaturanSoalRequest{JumlahSoal: 2, KuotaSoal: []models.KuotaSoal{{Kesulitan: "sulit", Jumlah: 1}}}
+	var resp map[string]interface{}
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/pengaturan-soal", pengaturan, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %v", status, resp)
+	}
+
+	clk.Set(pukul(7, 40))
+	store.Lock()
+	store.Ujian[0].Status = "active"
+	store.Ujian[0].Token = "ABCDE"
+	store.Unlock()
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/pengaturan-soal", models.PengaturanSoalRequest{}, nil); status != http.StatusConflict {
+		t.Errorf("changing an active ujian: status = %d, want 409", status)
+	}
+
+	start := models.MulaiUjianRequest{Token: "ABCDE", SiswaDetailID: "siswa-1"}
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/ujian-mtk/start", start, nil); status != http.StatusOK {
+		t.Fatalf("start status = %d", status)
+	}
+	peserta, err := store.GetPeserta("ujian-mtk", "siswa-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peserta.SoalIDs) != 2 || peserta.SeedSoal != services.SeedSoal("ujian-mtk", "siswa-1") {
+		t.Fatalf("expected 2 drawn soal with the recorded seed, got %+v", peserta)
+	}
+	if peserta.SoalIDs[0] != "soal-3" && peserta.SoalIDs[1] != "soal-3" {
+		t.Errorf("kuota SULIT not honoured, drawn %v", peserta.SoalIDs)
+	}
+	// Undian bisa diulang dari seed yang tercatat
+	bank, _ := store.GetSoalUjian("ujian-mtk")
+	ulang, err := services.UndiSoal(bank, 2, []models.KuotaSoal{{Kesulitan: models.KesulitanSulit, Jumlah: 1}}, peserta.SeedSoal)
+	if err != nil || !reflect.DeepEqual(ulang, peserta.SoalIDs) {
+		t.Errorf("redraw with recorded seed = %v (%v), want %v", ulang, err, peserta.SoalIDs)
+	}
+
+	var soal models.SoalUjianResponse
+	doJSON(t, app, http.MethodGet, "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=siswa-1", nil, &soal)
+	if len(soal.Soal) != 2 {
+		t.Fatalf("expected the 2 drawn soal, got %v", urutanSoal(soal))
+	}
+
+	kunci := map[string]string{"soal-1": "s1-b", "soal-2": "s2-b", "soal-3": "s3-a"}
+	answers := map[string]models.JawabanPilihan{}
+	for _, id := range peserta.SoalIDs {
+		answers[id] = models.JawabanPilihan{kunci[id]}
+	}
+	for id, pilihan := range kunci {
+		if _, ok := answers[id]; !ok {
+			tidakDiundi := map[string]models.JawabanPilihan{id: {pilihan}}
+			submit := models.SubmitUjianRequest{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", Answers: tidakDiundi}
+			if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", submit, nil); status != http.StatusBadRequest {
+				t.Errorf("answer for soal %s outside the draw: status = %d, want 400", id, status)
+			}
+		}
+	}
+
+	var hasil models.SubmitUjianResponse
+	submit := models.SubmitUjianRequest{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", Answers: answers}
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", submit, &hasil); status != http.StatusOK {
+		t.Fatalf("submit status = %d", status)
+	}
+	if hasil.Nilai != 100 || hasil.Benar != 2 || hasil.Kosong != 0 {
+		t.Errorf("expected scoring against the 2 drawn soal only, got %+v", hasil)
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/services"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// responsHasil menyusun SubmitUjianResponse dari hasil yang sudah dinilai
+func (h *UjianHandler) responsHasil(hasil *models.HasilDetail, message string) models.SubmitUjianResponse {
+	totalCheating, err := h.Kecurangan.CountKecurangan(hasil.UjianID, hasil.SiswaDetailID)
+	if err != nil {
+		log.Printf("Error counting cheating incidents: %v", err)
+		// Non-fatal error, continue with 0 cheating count
+		totalCheating = 0
+	}
+
+	return models.SubmitUjianResponse{
+		Success:         true,
+		Message:         message,
+		HasilID:         hasil.ID,
+		Nilai:           hasil.Nilai,
+		Benar:           hasil.Benar,
+		Salah:           hasil.Salah,
+		Kosong:          hasil.Kosong,
+		Rincian:         hasil.Rincian,
+		TotalKecurangan: totalCheating,
+		WaktuPengerjaan: hasil.WaktuPengerjaan,
+	}
+}
+
+// kirimPengumpulanUlang menjawab submit untuk ujian yang sudah dinilai. Himpunan jawaban yang sama
+// dianggap pengiriman ulang dan mendapat respons sebelumnya, jawaban berbeda ditolak dengan 409.
+// Hasil yang ditutup otomatis selalu dikirim ulang: submit yang datang setelahnya pasti terlambat,
+// jadi jawaban yang dikirim tidak akan dinilai juga.
+func (h *UjianHandler) kirimPengumpulanUlang(c *fiber.Ctx, sudahAda *models.HasilDetail, jawaban []models.JawabanSiswa) error {
+	if sudahAda.DitutupOtomatis {
+		return c.Status(fiber.StatusOK).JSON(h.responsHasil(sudahAda, "Waktu habis, ujian sudah ditutup otomatis"))
+	}
+	if sudahAda.JawabanHash != "" && sudahAda.JawabanHash == services.HashJawaban(jawaban) {
+		return c.Status(fiber.StatusOK).JSON(h.responsHasil(sudahAda, "Exam already submitted"))
+	}
+
+	log.Printf("Rejected resubmission with different answers: ujian %s siswa %s", sudahAda.UjianID, sudahAda.SiswaDetailID)
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"success": false,
+		"message": "Ujian ini sudah dikumpulkan dan dinilai, jawaban tidak dapat diubah",
+		"hasilId": sudahAda.ID,
+	})
+}
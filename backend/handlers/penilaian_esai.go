@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// dataPenilaianEsai hasil ujian beserta soal, kunci, dan jawaban siswa yang dibutuhkan untuk menilai esai
+type dataPenilaianEsai struct {
+	hasil   *models.HasilDetail
+	ujian   *models.Ujian
+	soal    []models.SoalInput
+	kunci   services.KunciJawaban
+	jawaban []models.JawabanSiswa
+}
+
+func (h *UjianHandler) muatPenilaianEsai(hasilID string) (*dataPenilaianEsai, *fiber.Error) {
+	hasil, err := h.Hasil.GetHasilDetail(hasilID)
+	if err == repositories.ErrNotFound {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Hasil not found")
+	}
+	if err != nil {
+		log.Printf("Error fetching hasil %s: %v", hasilID, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	return h.muatJawabanHasil(hasil)
+}
+
+// muatJawabanHasil memuat soal yang dikerjakan siswa pada hasil ujian beserta kunci dan jawabannya
+func (h *UjianHandler) muatJawabanHasil(hasil *models.HasilDetail) (*dataPenilaianEsai, *fiber.Error) {
+	ujian, err := h.Ujian.GetUjian(hasil.UjianID)
+	if err != nil {
+		log.Printf("Error fetching ujian %s: %v", hasil.UjianID, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	// Hasil lama tanpa catatan peserta dinilai dari semua soal ujian
+	peserta, err := h.Peserta.GetPeserta(hasil.UjianID, hasil.SiswaDetailID)
+	if err != nil && err != repositories.ErrNotFound {
+		log.Printf("Error fetching peserta ujian: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	soalList, fe := h.soalPeserta(ujian, peserta)
+	if fe != nil {
+		return nil, fe
+	}
+	jawaban, err := h.Jawaban.GetJawabanSiswa(hasil.UjianID, hasil.SiswaDetailID)
+	if err != nil {
+		log.Printf("Error fetching jawaban siswa: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	return &dataPenilaianEsai{
+		hasil:   hasil,
+		ujian:   ujian,
+		soal:    soalList,
+		kunci:   services.NewKunciJawaban(soalList, ujian),
+		jawaban: jawaban,
+	}, nil
+}
+
+// GetJawabanEsai menampilkan jawaban esai siswa pada satu hasil ujian beserta skor yang sudah diberikan
+func (h *UjianHandler) GetJawabanEsai(c *fiber.Ctx) error {
+	data, fe := h.muatPenilaianEsai(c.Params("id"))
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+
+	jawabanSoal := make(map[string]models.JawabanSiswa, len(data.jawaban))
+	for _, js := range data.jawaban {
+		jawabanSoal[js.SoalID] = js
+	}
+	esai := []models.JawabanEsai{}
+	for _, soal := range data.soal {
+		js, ok := jawabanSoal[soal.ID]
+		if !data.kunci.Esai(soal.ID) || !ok || js.JawabanTeks == "" {
+			continue
+		}
+		esai = append(esai, models.JawabanEsai{
+			SoalID:      soal.ID,
+			Soal:        soal.Soal,
+			Bobot:       data.kunci.Bobot(soal.ID),
+			JawabanTeks: js.JawabanTeks,
+			SkorManual:  js.SkorManual,
+		})
+	}
+
+	return c.JSON(models.JawabanEsaiResponse{Success: true, HasilID: data.hasil.ID, Esai: esai})
+}
+
+// NilaiEsai menyimpan skor esai dari guru lalu menghitung ulang nilai hasil ujian.
+// Skor yang sudah ada boleh diubah, esai yang tidak dikirim tetap memakai skor sebelumnya.
+func (h *UjianHandler) NilaiEsai(c *fiber.Ctx) error {
+	var request models.NilaiEsaiRequest
+	if err := c.BodyParser(&request); err != nil || len(request.Nilai) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Nilai esai wajib diisi",
+		})
+	}
+
+	data, fe := h.muatPenilaianEsai(c.Params("id"))
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+
+	indeks := make(map[string]int, len(data.jawaban))
+	for i, js := range data.jawaban {
+		indeks[js.SoalID] = i
+	}
+	skor := make(map[string]float64, len(request.Nilai))
+	for _, n := range request.Nilai {
+		if !data.kunci.Esai(n.SoalID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": fmt.Sprintf("Soal %s bukan soal esai pada ujian ini", n.SoalID),
+			})
+		}
+		i, ok := indeks[n.SoalID]
+		if !ok || data.jawaban[i].JawabanTeks == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": fmt.Sprintf("Siswa tidak menjawab soal %s", n.SoalID),
+			})
+		}
+		if bobot := data.kunci.Bobot(n.SoalID); n.Skor < 0 || n.Skor > float64(bobot) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": fmt.Sprintf("Skor soal %s harus antara 0 dan %d", n.SoalID, bobot),
+			})
+		}
+		s := n.Skor
+		data.jawaban[i].SkorManual = &s
+		skor[n.SoalID] = s
+	}
+
+	// Jawaban dan waktu pengerjaan tidak berubah, hanya nilai yang dihitung ulang
+	hasil := data.kunci.NilaiHasil(data.hasil.UjianID, data.hasil.SiswaDetailID, data.jawaban, data.hasil.WaktuPengerjaan)
+	hasil.ID = data.hasil.ID
+	if err := h.Hasil.SimpanPenilaianManual(hasil, skor); err != nil {
+		log.Printf("Error saving essay scores for hasil %s: %v", hasil.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal menyimpan nilai esai",
+		})
+	}
+
+	return c.JSON(h.responsHasil(&hasil, "Nilai esai tersimpan"))
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestPenilaianEsai(t *testing.T) {
+	store := seedStore()
+	// soal-3 isian singkat, soal-4 esai berbobot 2
+	store.Soal = append(store.Soal,
+		models.Soal{ID: "soal-3", Soal: "Ibu kota Indonesia?", Tipe: models.TipeIsianSingkat, MataPelajaranID: "mp-mtk"},
+		models.Soal{ID: "soal-4", Soal: "Jelaskan teorema Pythagoras", Tipe: models.TipeEsai, Bobot: 2, MataPelajaranID: "mp-mtk"},
+	)
+	store.Jawaban = append(store.Jawaban, models.Jawaban{ID: "s3-a", SoalID: "soal-3", Jawaban: "Jakarta", Benar: true})
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}},
+		JawabanTeks:   map[string]string{"soal-3": "  JAKARTA ", "soal-4": "a² + b² = c²"},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	// soal-1 dan soal-3 benar, soal-2 kosong, esai belum dinilai: 2 / 5 * 100 = 40
+	if resp.Nilai != 40 || resp.Benar != 2 || resp.Kosong != 1 || resp.Rincian.EsaiBelumDinilai != 1 {
+		t.Fatalf("unexpected response %+v (rincian %+v)", resp, resp.Rincian)
+	}
+
+	var esai models.JawabanEsaiResponse
+	if status := doJSON(t, app, http.MethodGet, "/api/hasil/"+resp.HasilID+"/esai", nil, &esai); status != http.StatusOK {
+		t.Fatalf("GetJawabanEsai status = %d", status)
+	}
+	if len(esai.Esai) != 1 || esai.Esai[0].SoalID != "soal-4" || esai.Esai[0].JawabanTeks != "a² + b² = c²" || esai.Esai[0].SkorManual != nil {
+		t.Fatalf("unexpected essays %+v", esai.Esai)
+	}
+
+	ditolak := []struct {
+		name  string
+		nilai models.NilaiEsai
+	}{
+		{"skor melebihi bobot", models.NilaiEsai{SoalID: "soal-4", Skor: 3}},
+		{"skor negatif", models.NilaiEsai{SoalID: "soal-4", Skor: -1}},
+		{"bukan soal esai", models.NilaiEsai{SoalID: "soal-3", Skor: 1}},
+	}
+	for _, tt := range ditolak {
+		body := models.NilaiEsaiRequest{Nilai: []models.NilaiEsai{tt.nilai}}
+		if status := doJSON(t, app, http.MethodPut, "/api/hasil/"+resp.HasilID+"/esai", body, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tt.name, status)
+		}
+	}
+
+	// Skor 1 dari bobot 2: (2 + 1) / 5 * 100 = 60
+	body := models.NilaiEsaiRequest{Nilai: []models.NilaiEsai{{SoalID: "soal-4", Skor: 1}}}
+	var dinilai models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPut, "/api/hasil/"+resp.HasilID+"/esai", body, &dinilai); status != http.StatusOK {
+		t.Fatalf("NilaiEsai status = %d, resp %+v", status, dinilai)
+	}
+	if dinilai.HasilID != resp.HasilID || dinilai.Nilai != 60 || dinilai.Salah != 1 || dinilai.Rincian.EsaiBelumDinilai != 0 {
+		t.Fatalf("unexpected graded response %+v (rincian %+v)", dinilai, dinilai.Rincian)
+	}
+
+	var hasil models.HasilDetail
+	if status := doJSON(t, app, http.MethodGet, "/api/hasil/"+resp.HasilID, nil, &hasil); status != http.StatusOK {
+		t.Fatalf("GetHasilDetail status = %d", status)
+	}
+	if hasil.Nilai != 60 || hasil.Rincian == nil || hasil.Rincian.SkorEsai != 1 {
+		t.Errorf("hasil.nilai must be recomputed, got %+v (rincian %+v)", hasil, hasil.Rincian)
+	}
+}
+
+func TestPenilaianEsaiBankSoalBerubah(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "active"
+	store.Ujian[0].Token = "ABCDE"
+	store.Soal = append(store.Soal, models.Soal{ID: "soal-3", Soal: "Jelaskan teorema Pythagoras", Tipe: models.TipeEsai, MataPelajaranID: "mp-mtk"})
+	app, clk := newTestApp(t, store, pukul(7, 35))
+
+	start := models.MulaiUjianRequest{Token: "ABCDE", SiswaDetailID: "siswa-1"}
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/ujian-mtk/start", start, nil); status != http.StatusOK {
+		t.Fatalf("start status = %d", status)
+	}
+	clk.Set(pukul(8, 0))
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-b"}},
+		JawabanTeks:   map[string]string{"soal-3": "a² + b² = c²"},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	if resp.Nilai != 66 || resp.Benar != 2 || resp.Kosong != 0 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	// Guru menambah soal baru dan mengarsipkan soal-1 sebelum menilai esai
+	store.Lock()
+	for _, id := range []string{"soal-4", "soal-5", "soal-6"} {
+		store.Soal = append(store.Soal, models.Soal{ID: id, Soal: "Soal baru " + id, Tipe: models.TipeEsai, MataPelajaranID: "mp-mtk"})
+	}
+	store.Unlock()
+	if status := doJSON(t, app, http.MethodDelete, "/api/soal/soal-1", nil, nil); status != http.StatusOK {
+		t.Fatalf("HapusSoal status = %d", status)
+	}
+
+	body := models.NilaiEsaiRequest{Nilai: []models.NilaiEsai{{SoalID: "soal-3", Skor: 1}}}
+	var dinilai models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPut, "/api/hasil/"+resp.HasilID+"/esai", body, &dinilai); status != http.StatusOK {
+		t.Fatalf("NilaiEsai status = %d, resp %+v", status, dinilai)
+	}
+	if dinilai.Nilai != 100 || dinilai.Benar != 3 || dinilai.Kosong != 0 || dinilai.Rincian.TotalBobot != 3 {
+		t.Errorf("regrade must use the soal the student worked on, got %+v (rincian %+v)", dinilai, dinilai.Rincian)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"backend/clock"
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupRoutes mendaftarkan semua route HTTP dan websocket di atas repository yang diberikan,
+// lalu mengembalikan tracker ujian. Tracker belum berjalan, pemanggil yang menjalankan StartTracking
+// supaya test bisa memakai jam palsu tanpa goroutine yang mengubah status ujian di belakang layar.
+func SetupRoutes(app *fiber.App, repos repositories.Repositories, clk clock.Clock) *services.UjianTracker {
+	soalHandler := NewSoalHandler(repos.Soal)
+	cheatingHandler := NewCheatingHandler(repos.Kecurangan)
+	ujianHandler := NewUjianHandler(repos, clk)
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"message": "API bekerja!"})
+	})
+	app.Post("/api/soal", soalHandler.AddSoal)
+	app.Post("/api/soal/import", soalHandler.ImportSoal)
+	app.Get("/api/soal/export", soalHandler.ExportSoal)
+	app.Get("/api/soal", soalHandler.ListSoal)
+	app.Get("/api/soal/:id", soalHandler.GetSoal)
+	app.Put("/api/soal/:id", soalHandler.UpdateSoal)
+	app.Delete("/api/soal/:id", soalHandler.HapusSoal)
+	app.Put("/api/mata-pelajaran/pengaturan", soalHandler.SimpanPengaturanMataPelajaran)
+	app.Post("/api/kecurangan", cheatingHandler.ReportCheating)
+
+	app.Post("/api/ujian/submit", ujianHandler.SubmitUjian)
+	app.Post("/api/ujian/:id/start", ujianHandler.MulaiUjian)
+	app.Get("/api/ujian/:id/soal", ujianHandler.GetSoalUjian)
+	app.Put("/api/ujian/:id/jawaban", ujianHandler.SimpanJawaban)
+	app.Put("/api/ujian/:id/pengaturan-soal", ujianHandler.SimpanPengaturanSoal)
+	app.Put("/api/ujian/:id/tampilkan-kunci", ujianHandler.SimpanTampilkanKunci)
+	app.Put("/api/ujian/:id/penilaian", ujianHandler.SimpanPengaturanPenilaian)
+	app.Get("/api/ujian/:id/analisis", ujianHandler.GetAnalisisUjian)
+	app.Get("/api/data-ujian-terlewat", GetUjianTerlewat(repos.Jadwal, clk))
+
+	app.Get("/api/hasil/:id", ujianHandler.GetHasilDetail)
+	app.Get("/api/hasil/:id/esai", ujianHandler.GetJawabanEsai)
+	app.Put("/api/hasil/:id/esai", ujianHandler.NilaiEsai)
+	app.Get("/api/ujian/download", func(c *fiber.Ctx) error {
+		return DownloadHasilUjian(c, repos.Hasil)
+	})
+	SetupAPIRoutes(app, repos)
+	SetupWebSocket(app, repos.Kecurangan, ujianHandler)
+
+	// Setup websocket dan tracker PERTAMA
+	ujianBroadcast := make(chan models.ResponseDataUjian, 10)
+	ujianTracker := SetupWebSocketUjian(app, repos, clk, ujianBroadcast)
+
+	// Setup routes dengan tracker yang SAMA
+	app.Get("/api/data-ujian", GetUjianTrackingData(repos.Jadwal, ujianTracker.Clock))
+	app.Post("/api/data-ujian-terlewat", AddUjianSusulan(repos.Ujian, ujianTracker))
+	app.Post("/api/data-ujian/refresh", RefreshJadwalUjian(ujianTracker))
+
+	return ujianTracker
+}
@@ -2,23 +2,22 @@ package handlers
 
 import (
 	"backend/models"
+	"backend/repositories"
 	"backend/utils"
 	validators "backend/validations"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type SoalHandler struct {
-    DB *sql.DB
+    Soal repositories.SoalRepository
 }
 
-func NewSoalHandler(db *sql.DB) *SoalHandler {
-    return &SoalHandler{DB: db}
+func NewSoalHandler(soalRepo repositories.SoalRepository) *SoalHandler {
+    return &SoalHandler{Soal: soalRepo}
 }
 
 func (h *SoalHandler) AddSoal(c *fiber.Ctx) error {
@@ -61,52 +60,56 @@ func (h *SoalHandler) AddSoal(c *fiber.Ctx) error {
 
     fmt.Printf("Successfully parsed %d soal items\n", len(soalDataArr))
 
-     if err := validators.ValidateSoalInput(tingkat, pelajaran, soalDataArr, form.File); err != nil {
-        fmt.Printf("Validation error: %v\n", err)
-        return c.Status(400).JSON(fiber.Map{
+    // Jumlah pilihan mengikuti pengaturan mata pelajaran, mata pelajaran baru memakai default
+    jumlahPilihan := 0
+    mataPelajaran, err := h.Soal.GetMataPelajaran(tingkat, pelajaran)
+    if err == nil {
+        jumlahPilihan = mataPelajaran.JumlahPilihan
+    } else if err != repositories.ErrNotFound {
+        fmt.Printf("Error fetching mata pelajaran: %v\n", err)
+        return c.Status(500).JSON(fiber.Map{
             "success": false,
-            "message": err.Error(),
+            "message": "Database error",
         })
     }
 
-    tx, err := h.DB.Begin()
-    if err != nil {
-        return c.Status(500).JSON(fiber.Map{
+    for i := range soalDataArr {
+        validators.SanitizeSoal(&soalDataArr[i])
+    }
+
+     if err := validators.ValidateSoalInput(tingkat, pelajaran, jumlahPilihan, soalDataArr, form.File); err != nil {
+        fmt.Printf("Validation error: %v\n", err)
+        return c.Status(400).JSON(fiber.Map{
             "success": false,
-            "message": "Gagal memulai transaksi: " + err.Error(),
+            "message": err.Error(),
         })
     }
-    defer tx.Rollback()
-
-    // Cek mata pelajaran
-    var mataPelajaranID string
-    err = tx.QueryRow(`
-        SELECT id FROM mata_pelajaran WHERE tingkat = $1 AND pelajaran = $2
-    `, tingkat, pelajaran).Scan(&mataPelajaranID)
 
-    if err == sql.ErrNoRows {
-        mataPelajaranID = uuid.New().String()
-        _, err = tx.Exec(`
-            INSERT INTO mata_pelajaran (id, tingkat, pelajaran)
-            VALUES ($1, $2, $3)
-        `, mataPelajaranID, tingkat, pelajaran)
+    // Soal yang sama atau mirip dengan bank soal harus diberi aksi lebih dulu lewat aksiDuplikat
+    rencana, belumDipilih, fe := h.rencanakanDuplikat(c, tingkat, pelajaran, soalDataArr)
+    if fe != nil {
+        return kirimFiberError(c, fe)
+    }
+    if len(belumDipilih) > 0 {
+        return tolakDuplikat(c, belumDipilih)
+    }
 
-        if err != nil {
-            fmt.Printf("Error inserting mata pelajaran: %v\n", err)
-            return c.Status(500).JSON(fiber.Map{
-                "success": false,
-                "message": "Gagal menyimpan mata pelajaran: " + err.Error(),
-            })
+    // Simpan gambar setiap soal setelah rencana duplikat pasti, path-nya ikut disimpan bersama soal.
+    // Gambar yang sudah ditulis dihapus lagi bila penyimpanan gagal
+    var tersimpan []string
+    hapusGambar := func() {
+        for _, path := range tersimpan {
+            if err := utils.DeleteImage(path); err != nil {
+                fmt.Printf("Error deleting image %s: %v\n", path, err)
+            }
         }
     }
-
-    // Proses setiap soal
-    for i, soalInput := range soalDataArr {
-        soalID := uuid.New().String()
-        
-        // Handle gambar jika ada
-        var imagePath *string
+    for i := range soalDataArr {
         fileKey := fmt.Sprintf("gambar_%d", i)
+        if rencana.lewati(i) {
+            soalDataArr[i].Gambar = nil
+            continue
+        }
         
         // Log attempt to get file
         fmt.Printf("Attempting to get file with key: %s\n", fileKey)
@@ -118,63 +121,38 @@ func (h *SoalHandler) AddSoal(c *fiber.Ctx) error {
             } else {
                 fmt.Printf("No file found for key: %s\n", fileKey)
             }
-        } else {
-            fmt.Printf("Found file: %s for key: %s\n", file.Filename, fileKey)
-            savedPath, err := utils.SaveImage(file)
-            if err != nil {
-                fmt.Printf("Error saving image: %v\n", err)
-                return c.Status(500).JSON(fiber.Map{
-                    "success": false,
-                    "message": "Gagal menyimpan gambar: " + err.Error(),
-                })
-            }
-            imagePath = &savedPath
-            fmt.Printf("Successfully saved image to: %s\n", savedPath)
+            soalDataArr[i].Gambar = nil
+            continue
         }
 
-        // Insert soal dengan path gambar
-        _, err = tx.Exec(`
-            INSERT INTO soal (id, gambar, soal, "mataPelajaranId")
-            VALUES ($1, $2, $3, $4)
-        `, soalID, imagePath, soalInput.Soal, mataPelajaranID)
-
+        fmt.Printf("Found file: %s for key: %s\n", file.Filename, fileKey)
+        savedPath, err := utils.SaveImage(file)
         if err != nil {
-            fmt.Printf("Error inserting soal: %v\n", err)
+            hapusGambar()
+            fmt.Printf("Error saving image: %v\n", err)
             return c.Status(500).JSON(fiber.Map{
                 "success": false,
-                "message": "Gagal menyimpan soal: " + err.Error(),
+                "message": "Gagal menyimpan gambar: " + err.Error(),
             })
         }
-
-        // Insert jawaban
-        for j, pilihan := range soalInput.Pilihan {
-            _, err = tx.Exec(`
-                INSERT INTO jawaban (id, "soalId", jawaban, benar)
-                VALUES ($1, $2, $3, $4)
-            `, uuid.New().String(), soalID, pilihan.Text, pilihan.Benar)
-
-            if err != nil {
-                fmt.Printf("Error inserting jawaban %d for soal %d: %v\n", j, i, err)
-                return c.Status(500).JSON(fiber.Map{
-                    "success": false,
-                    "message": "Gagal menyimpan jawaban: " + err.Error(),
-                })
-            }
-        }
+        tersimpan = append(tersimpan, savedPath)
+        soalDataArr[i].Gambar = &savedPath
+        fmt.Printf("Successfully saved image to: %s\n", savedPath)
     }
 
-    if err := tx.Commit(); err != nil {
-        fmt.Printf("Error committing transaction: %v\n", err)
-        return c.Status(500).JSON(fiber.Map{
-            "success": false,
-            "message": "Gagal menyimpan data: " + err.Error(),
-        })
+    ringkasan, fe := h.simpanSesuaiRencana(tingkat, pelajaran, soalDataArr, rencana)
+    if fe != nil {
+        hapusGambar()
+        return kirimFiberError(c, fe)
     }
 
     fmt.Println("Successfully completed AddSoal handler")
     return c.JSON(fiber.Map{
         "success": true,
         "message": fmt.Sprintf("Berhasil menambahkan %d soal ke %s tingkat %s", 
-            len(soalDataArr), pelajaran, tingkat),
+            ringkasan.Disimpan, pelajaran, tingkat),
+        "disimpan": ringkasan.Disimpan,
+        "ditimpa":  ringkasan.Ditimpa,
+        "dilewati": ringkasan.Dilewati,
     })
 }
\ No newline at end of file
@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"backend/models"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func soalInput(teks string) models.SoalInput {
+	return models.SoalInput{
+		Soal: teks,
+		Pilihan: []models.Pilihan{
+			{Text: "A", Benar: true}, {Text: "B"}, {Text: "C"}, {Text: "D"}, {Text: "E"},
+		},
+	}
+}
+
+func soalRequest(t *testing.T, tingkat, pelajaran string, soal []models.SoalInput, gambar map[string][]byte) *http.Request {
+	t.Helper()
+	data, err := json.Marshal(soal)
+	if err != nil {
+		t.Fatalf("marshal soal: %v", err)
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("tingkat", tingkat)
+	w.WriteField("pelajaran", pelajaran)
+	w.WriteField("soalData", string(data))
+	for key, isi := range gambar {
+		part, err := w.CreateFormFile(key, key+".png")
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		part.Write(isi)
+	}
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/soal", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestAddSoal(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	berbobot := soalInput("Satuan energi?")
+	berbobot.Bobot = 3
+	berbobot.Tipe = models.TipePilihanGandaKompleks
+	berbobot.Pilihan[1].Benar = true
+	req := soalRequest(t, "XI", "Fisika", []models.SoalInput{soalInput("Satuan gaya?"), berbobot},
+		map[string][]byte{"gambar_1": []byte("\x89PNG")})
+	status, body := doRequest(t, app, req)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+	if !strings.Contains(string(body), "Berhasil menambahkan 2 soal ke Fisika tingkat XI") {
+		t.Errorf("unexpected body %s", body)
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	var mataPelajaranID string
+	for _, mp := range store.MataPelajaran {
+		if mp.Tingkat == "XI" && mp.Pelajaran == "Fisika" {
+			mataPelajaranID = mp.ID
+		}
+	}
+	if mataPelajaranID == "" {
+		t.Fatalf("mata pelajaran Fisika XI was not created")
+	}
+
+	var tersimpan []models.Soal
+	for _, soal := range store.Soal {
+		if soal.MataPelajaranID == mataPelajaranID {
+			tersimpan = append(tersimpan, soal)
+		}
+	}
+	if len(tersimpan) != 2 {
+		t.Fatalf("expected 2 soal saved, got %d", len(tersimpan))
+	}
+	if tersimpan[0].Gambar != nil || tersimpan[1].Gambar == nil {
+		t.Fatalf("expected only the second soal to have an image, got %v / %v", tersimpan[0].Gambar, tersimpan[1].Gambar)
+	}
+	if tersimpan[0].Bobot != 1 || tersimpan[1].Bobot != 3 {
+		t.Errorf("expected bobot 1 (default) and 3, got %d and %d", tersimpan[0].Bobot, tersimpan[1].Bobot)
+	}
+	if tersimpan[0].Tipe != models.TipePilihanGanda || tersimpan[1].Tipe != models.TipePilihanGandaKompleks {
+		t.Errorf("expected tipe %s (default) and %s, got %s and %s", models.TipePilihanGanda, models.TipePilihanGandaKompleks, tersimpan[0].Tipe, tersimpan[1].Tipe)
+	}
+	gambarPath := filepath.Join("../web-ulangan/public", *tersimpan[1].Gambar)
+	if _, err := os.Stat(gambarPath); err != nil {
+		t.Errorf("image not written: %v", err)
+	}
+}
+
+func TestAddSoalValidasi(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	soal := soalInput("Tanpa jawaban benar")
+	soal.Pilihan[0].Benar = false
+	status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{soal}, nil))
+	if status != http.StatusBadRequest {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+
+	status, _ = doRequest(t, app, soalRequest(t, "XIII", "MTK", []models.SoalInput{soalInput("Soal")}, nil))
+	if status != http.StatusBadRequest {
+		t.Errorf("invalid tingkat: status = %d, want 400", status)
+	}
+
+	soal = soalInput("Bobot terlalu besar")
+	soal.Bobot = 1000
+	status, _ = doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{soal}, nil))
+	if status != http.StatusBadRequest {
+		t.Errorf("invalid bobot: status = %d, want 400", status)
+	}
+
+	soal = soalInput("Dua kunci tanpa tipe kompleks")
+	soal.Pilihan[1].Benar = true
+	status, _ = doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{soal}, nil))
+	if status != http.StatusBadRequest {
+		t.Errorf("multiple correct answers on %s: status = %d, want 400", models.TipePilihanGanda, status)
+	}
+
+	soal = soalInput("Esai dengan pilihan")
+	soal.Tipe = models.TipeEsai
+	status, _ = doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{soal}, nil))
+	if status != http.StatusBadRequest {
+		t.Errorf("%s with pilihan: status = %d, want 400", models.TipeEsai, status)
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	if len(store.Soal) != 2 {
+		t.Errorf("rejected soal must not be saved, store has %d soal", len(store.Soal))
+	}
+}
+
+func TestAddSoalFormat(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	rumus := soalInput(`Hasil dari $\frac{1}{2} + \frac{1}{4}$ adalah <script>alert(1)</script>**...**`)
+	rumus.Format = "latex"
+	rumus.Pilihan[0].Text = `$\frac{3}{4}$`
+	markdown := soalInput("Lihat [sumber](javascript:alert(1)) lalu pilih *satu*")
+	markdown.Format = "markdown"
+	status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{rumus, markdown}, nil))
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+
+	store.Lock()
+	tersimpan := store.Soal[len(store.Soal)-2:]
+	store.Unlock()
+	if tersimpan[0].Format != models.FormatMarkdownLatex || tersimpan[1].Format != models.FormatMarkdown {
+		t.Errorf("expected formats %s and %s, got %s and %s", models.FormatMarkdownLatex, models.FormatMarkdown, tersimpan[0].Format, tersimpan[1].Format)
+	}
+	if want := `Hasil dari $\frac{1}{2} + \frac{1}{4}$ adalah alert(1)**...**`; tersimpan[0].Soal != want {
+		t.Errorf("soal = %q, want %q", tersimpan[0].Soal, want)
+	}
+	if strings.Contains(tersimpan[1].Soal, "javascript:") {
+		t.Errorf("javascript: link was not removed: %q", tersimpan[1].Soal)
+	}
+
+	// Bentuk HTML dan tautan yang lolos dari pembuangan tag biasa
+	for _, tc := range []struct {
+		name, format, soal string
+	}{
+		{"html in math", models.FormatMarkdownLatex, "Hitung $<img src=x onerror=alert(1)>$"},
+		{"unterminated tag", models.FormatMarkdown, "Hitung <img src=x onerror=alert(1)"},
+		{"rebuilt tag", models.FormatMarkdown, "Hitung <<b>img src=x onerror=alert(1)>"},
+		{"autolink", models.FormatMarkdown, "Buka <javascript:alert(1)>"},
+		{"reference link", models.FormatMarkdown, "Buka [sumber][1]\n\n[1]: javascript:alert(1)"},
+		{"encoded link", models.FormatMarkdown, "Buka [sumber](javascript&#58;alert(1))"},
+	} {
+		soal := soalInput(tc.soal)
+		soal.Format = tc.format
+		if status, body := kirimSoalDenganAksi(t, app, []models.SoalInput{soal}, `{"0":"simpan"}`); status != http.StatusOK {
+			t.Fatalf("%s: status = %d, body %v", tc.name, status, body)
+		}
+		store.Lock()
+		tersimpan := store.Soal[len(store.Soal)-1].Soal
+		store.Unlock()
+		if strings.Contains(tersimpan, "<") || strings.Contains(tersimpan, "](javascript") || strings.Contains(tersimpan, "]: javascript") {
+			t.Errorf("%s: unsafe content kept: %q", tc.name, tersimpan)
+		}
+	}
+	aman := soalInput("Lihat <https://contoh.id> atau [sumber](https://contoh.id)")
+	aman.Format = models.FormatMarkdown
+	if status, body := kirimSoalDenganAksi(t, app, []models.SoalInput{aman}, `{"0":"simpan"}`); status != http.StatusOK {
+		t.Fatalf("safe links: status = %d, body %v", status, body)
+	}
+	store.Lock()
+	if got := store.Soal[len(store.Soal)-1].Soal; got != aman.Soal {
+		t.Errorf("safe links changed: %q, want %q", got, aman.Soal)
+	}
+	store.Unlock()
+
+	for _, tc := range []struct {
+		name, format, soal string
+	}{
+		{"unknown format", "html", "Soal"},
+		{"unclosed math", models.FormatMarkdownLatex, "Nilai $x + 1 adalah"},
+		{"forbidden command", models.FormatMarkdownLatex, `Buka $\href{http://contoh.id}{x}$`},
+		{"unbalanced braces", models.FormatMarkdownLatex, `Nilai $\frac{1}{2$`},
+	} {
+		soal := soalInput(tc.soal)
+		soal.Format = tc.format
+		if status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{soal}, nil)); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, body %s", tc.name, status, body)
+		}
+	}
+
+	// Rumus dicetak sebagai teks di naskah PDF
+	req := httptest.NewRequest(http.MethodGet, "/api/soal/export?tingkat=X&pelajaran=MTK&format=pdf", nil)
+	if status, body := doRequest(t, app, req); status != http.StatusOK || !strings.HasPrefix(string(body), "%PDF") {
+		t.Errorf("pdf export: status = %d", status)
+	}
+}
+
+func TestAddSoalTopikKD(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	soal := soalInput("Nilai x dari 2x = 6 adalah")
+	soal.Topik, soal.KD, soal.Kesulitan = " Persamaan Linear ", " 3.2 ", "mudah"
+	if status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{soal}, nil)); status != http.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+	store.Lock()
+	tersimpan := store.Soal[len(store.Soal)-1]
+	store.Unlock()
+	if tersimpan.Topik != "Persamaan Linear" || tersimpan.KD != "3.2" || tersimpan.Kesulitan != models.KesulitanMudah {
+		t.Errorf("unexpected tags topik %q, kd %q, kesulitan %q", tersimpan.Topik, tersimpan.KD, tersimpan.Kesulitan)
+	}
+
+	for _, tc := range []struct {
+		name, topik, kd, kesulitan string
+	}{
+		{"kd bukan kode", "", "Bab 3", ""},
+		{"kd tanpa subbagian", "", "3", ""},
+		{"kesulitan tidak dikenal", "", "", "GAMPANG"},
+		{"topik terlalu panjang", strings.Repeat("a", 101), "", ""},
+	} {
+		soal := soalInput("Soal")
+		soal.Topik, soal.KD, soal.Kesulitan = tc.topik, tc.kd, tc.kesulitan
+		if status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{soal}, nil)); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, body %s", tc.name, status, body)
+		}
+	}
+}
+
+func TestAddSoalJumlahPilihan(t *testing.T) {
+	store := seedStore()
+	store.MataPelajaran[0].JumlahPilihan = 4 // MTK kelas X memakai pilihan A-D
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	empatPilihan := soalInput("Empat pilihan")
+	empatPilihan.Pilihan = empatPilihan.Pilihan[:4]
+	benarSalah := soalInput("Benar atau salah?")
+	benarSalah.JumlahPilihan = 2
+	benarSalah.Pilihan = []models.Pilihan{{Text: "Benar", Benar: true}, {Text: "Salah"}}
+	status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{empatPilihan, benarSalah}, nil))
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+
+	tests := []struct {
+		name    string
+		soal    models.SoalInput
+		message string
+	}{
+		{"lima pilihan pada mata pelajaran empat pilihan", soalInput("Lima pilihan"), "soal 1 harus memiliki tepat 4 pilihan jawaban, ditemukan 5"},
+		{"jumlah pilihan di luar batas", func() models.SoalInput {
+			soal := soalInput("Tujuh pilihan")
+			soal.JumlahPilihan = 7
+			return soal
+		}(), "jumlah pilihan soal 1 harus antara 2 dan 6, bukan 7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{tt.soal}, nil))
+			if status != http.StatusBadRequest || !strings.Contains(string(body), tt.message) {
+				t.Errorf("status = %d, body %s, want 400 with %q", status, body, tt.message)
+			}
+		})
+	}
+
+	// Mata pelajaran baru memakai default 5 pilihan
+	if status, body := doRequest(t, app, soalRequest(t, "X", "Fisika", []models.SoalInput{soalInput("Satuan gaya?")}, nil)); status != http.StatusOK {
+		t.Errorf("default option count: status = %d, body %s", status, body)
+	}
+}
+
+func TestSoalJumlahPilihanTersimpan(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	// Soal benar/salah di mata pelajaran lima pilihan tetap bisa diubah, diekspor, dan diimpor lagi
+	benarSalah := soalInput("Bumi itu bulat?")
+	benarSalah.JumlahPilihan = 2
+	benarSalah.Pilihan = []models.Pilihan{{Text: "Benar", Benar: true}, {Text: "Salah"}}
+	if status, body := doRequest(t, app, soalRequest(t, "X", "MTK", []models.SoalInput{benarSalah}, nil)); status != http.StatusOK {
+		t.Fatalf("AddSoal status = %d, body %s", status, body)
+	}
+	store.Lock()
+	id := store.Soal[len(store.Soal)-1].ID
+	store.Unlock()
+
+	var detail struct {
+		Data models.SoalDetail `json:"data"`
+	}
+	if status := doJSON(t, app, http.MethodGet, "/api/soal/"+id, nil, &detail); status != http.StatusOK || detail.Data.JumlahPilihan != 2 {
+		t.Fatalf("GetSoal status = %d, jumlahPilihan = %d, want 2", status, detail.Data.JumlahPilihan)
+	}
+	diubah := detail.Data.SoalInput
+	diubah.Soal = "Apakah bumi bulat?"
+	diubah.JumlahPilihan = 0 // client lama yang tidak mengirim jumlahPilihan
+	if status, body := doRequest(t, app, updateSoalRequest(t, id, diubah, nil, false)); status != http.StatusOK {
+		t.Fatalf("UpdateSoal status = %d, body %s", status, body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/soal/export?tingkat=X&pelajaran=MTK&format=xlsx", nil)
+	status, xlsx := doRequest(t, app, req)
+	if status != http.StatusOK {
+		t.Fatalf("export status = %d", status)
+	}
+	if status, body := doRequest(t, app, imporRequest(t, "XI", "MTK", map[string]map[string][]byte{"file": {"soal.xlsx": xlsx}})); status != http.StatusOK {
+		t.Fatalf("re-import status = %d, body %s", status, body)
+	}
+	var salinan []models.SoalInput
+	doJSON(t, app, http.MethodGet, "/api/soal/export?tingkat=XI&pelajaran=MTK", nil, &salinan)
+	jumlah := map[string]int{}
+	for _, soal := range salinan {
+		jumlah[soal.Soal] = soal.JumlahPilihan
+	}
+	if len(salinan) != 3 || jumlah["Apakah bumi bulat?"] != 2 || jumlah["1 + 1 = ?"] != 0 {
+		t.Errorf("unexpected re-imported jumlahPilihan %v", jumlah)
+	}
+}
+
+// kirimSoalDenganAksi mengirim soal ke X/MTK beserta aksiDuplikat dan mengembalikan respons JSON-nya
+func kirimSoalDenganAksi(t *testing.T, app *fiber.App, soalList []models.SoalInput, aksi string) (int, map[string]interface{}) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	w.WriteField("tingkat", "X")
+	w.WriteField("pelajaran", "MTK")
+	data, _ := json.Marshal(soalList)
+	w.WriteField("soalData", string(data))
+	w.WriteField("aksiDuplikat", aksi)
+	w.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/soal", &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	status, body := doRequest(t, app, req)
+	var resp map[string]interface{}
+	json.Unmarshal(body, &resp)
+	return status, resp
+}
+
+func TestAddSoalDuplikat(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	// Sama dengan soal-1 di bank soal (kunci diperbaiki), satu lagi soal baru
+	duplikat := models.SoalInput{Soal: "1+1 = ?", Pilihan: []models.Pilihan{
+		{Text: "1"}, {Text: "2"}, {Text: "3", Benar: true}, {Text: "4"}, {Text: "5"},
+	}}
+	soalList := []models.SoalInput{duplikat, soalInput("Akar dari 81?")}
+
+	var konflik struct {
+		Duplikat []models.DuplikatSoal `json:"duplikat"`
+	}
+	status, body := doRequest(t, app, soalRequest(t, "X", "MTK", soalList, nil))
+	json.Unmarshal(body, &konflik)
+	if status != http.StatusConflict || len(konflik.Duplikat) != 1 || konflik.Duplikat[0].Indeks != 0 ||
+		konflik.Duplikat[0].SoalID != "soal-1" || !konflik.Duplikat[0].Persis {
+		t.Fatalf("expected 409 flagging soal 0 as copy of soal-1, got %d %s", status, body)
+	}
+	store.Lock()
+	if len(store.Soal) != 2 {
+		t.Errorf("nothing may be saved until duplicates are resolved, got %d soal", len(store.Soal))
+	}
+	store.Unlock()
+
+	kirim := func(aksi string) (int, map[string]interface{}) {
+		return kirimSoalDenganAksi(t, app, soalList, aksi)
+	}
+
+	if status, _ := kirim(`{"0":"hapus"}`); status != http.StatusBadRequest {
+		t.Errorf("unknown action status = %d, want 400", status)
+	}
+
+	// timpa: soal-1 diperbarui di tempat, hanya soal kedua yang ditambahkan
+	status, resp := kirim(`{"0":"timpa"}`)
+	if status != http.StatusOK || resp["disimpan"] != float64(1) || resp["ditimpa"] != float64(1) {
+		t.Fatalf("timpa: status %d, resp %v", status, resp)
+	}
+	var detail struct {
+		Data models.SoalDetail `json:"data"`
+	}
+	doJSON(t, app, http.MethodGet, "/api/soal/soal-1", nil, &detail)
+	// Pilihan dengan teks yang sama tetap memakai ID lama
+	if p := detail.Data.Pilihan; detail.Data.Soal != "1+1 = ?" || len(p) != 5 || p[2].ID != "s1-c" || !p[2].Benar || p[1].Benar {
+		t.Errorf("soal-1 must be overwritten, got %+v", detail.Data)
+	}
+
+	// Unggah ulang yang sama: kedua soal sekarang duplikat, lewati satu dan simpan keduanya untuk yang lain
+	status, resp = kirim(`{"0":"lewati","1":"simpan"}`)
+	if status != http.StatusOK || resp["disimpan"] != float64(1) || resp["dilewati"] != float64(1) {
+		t.Fatalf("lewati/simpan: status %d, resp %v", status, resp)
+	}
+	store.Lock()
+	defer store.Unlock()
+	if len(store.Soal) != 4 {
+		t.Errorf("expected seed 2 + 1 new + 1 kept copy, got %d soal", len(store.Soal))
+	}
+}
+
+func TestAddSoalDuplikatTimpaGagal(t *testing.T) {
+	store := seedStore()
+	store.JawabanSiswa = []models.JawabanSiswa{
+		{ID: "js-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", SoalID: "soal-1", JawabanID: "s1-b"},
+	}
+	app, _ := newTestApp(t, store, pukul(6, 0))
+
+	// soal-1 sudah dijawab, menimpanya dengan kunci lain ditolak dan soal baru di unggahan yang sama ikut batal
+	duplikat := models.SoalInput{Soal: "1+1 = ?", Pilihan: []models.Pilihan{
+		{Text: "1"}, {Text: "2"}, {Text: "3", Benar: true}, {Text: "4"}, {Text: "5"},
+	}}
+	soalList := []models.SoalInput{duplikat, soalInput("Akar dari 81?")}
+	if status, resp := kirimSoalDenganAksi(t, app, soalList, `{"0":"timpa"}`); status != http.StatusConflict {
+		t.Fatalf("timpa answered soal: status %d, resp %v", status, resp)
+	}
+	store.Lock()
+	if len(store.Soal) != 2 || store.Soal[0].Soal != "1 + 1 = ?" {
+		t.Errorf("failed overwrite must not keep the new soal or change soal-1, got %+v", store.Soal)
+	}
+	store.Unlock()
+
+	// Kirim ulang dengan aksi lain tidak melaporkan soal baru dari percobaan pertama sebagai duplikat
+	if status, resp := kirimSoalDenganAksi(t, app, soalList, `{"0":"simpan"}`); status != http.StatusOK || resp["disimpan"] != float64(2) {
+		t.Fatalf("retry: status %d, resp %v", status, resp)
+	}
+}
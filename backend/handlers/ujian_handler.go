@@ -1,28 +1,44 @@
 package handlers
 
 import (
+	"backend/clock"
 	"backend/models"
 	"backend/repositories"
-	"database/sql"
+	"backend/services"
 	"fmt"
 	"log"
-	"time"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
-func NewUjianHandler(db *sql.DB) *UjianHandler {
-	return &UjianHandler{DB: db}
+func NewUjianHandler(repos repositories.Repositories, clk clock.Clock) *UjianHandler {
+	return &UjianHandler{
+		Clock:      clk,
+		Ujian:      repos.Ujian,
+		Soal:       repos.Soal,
+		Hasil:      repos.Hasil,
+		Kecurangan: repos.Kecurangan,
+		Siswa:      repos.Siswa,
+		Peserta:    repos.Peserta,
+		Jawaban:    repos.Jawaban,
+	}
 }
 
 type UjianHandler struct {
-	DB *sql.DB
+	Clock      clock.Clock
+	Ujian      repositories.UjianRepository
+	Soal       repositories.SoalRepository
+	Hasil      repositories.HasilRepository
+	Kecurangan repositories.KecuranganRepository
+	Siswa      repositories.SiswaRepository
+	Peserta    repositories.PesertaRepository
+	Jawaban    repositories.JawabanRepository
 }
 
-func GetUjianTrackingData(db *sql.DB) fiber.Handler {
+func GetUjianTrackingData(jadwalRepo repositories.JadwalRepository, clk clock.Clock) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		jadwalData, err := repositories.GetJadwalUjian(db)
+		jadwalData, err := jadwalRepo.GetJadwalUjian(clk.Now())
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{
 				"message": "Error fetching data",
@@ -53,6 +69,64 @@ func GetUjianTrackingData(db *sql.DB) fiber.Handler {
 }
 
 
+// RefreshJadwalUjian memberi sinyal ke tracker bahwa jadwal/sesi/ujian diubah dari panel admin,
+// sehingga cache jadwal dimuat ulang tanpa menunggu batas transisi berikutnya
+func RefreshJadwalUjian(ujianTracker *services.UjianTracker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ujianTracker.NotifyJadwalChanged()
+		return c.JSON(fiber.Map{
+			"success": true,
+			"message": "Jadwal ujian akan dimuat ulang",
+		})
+	}
+}
+
+// GetSoalUjian mengirim soal ujian ke siswa yang sudah memulai ujian dengan token yang sah.
+// Kunci jawaban dibuang dan urutan soal/pilihan diacak per siswa.
+func (h *UjianHandler) GetSoalUjian(c *fiber.Ctx) error {
+	ujianID := c.Params("id")
+	token := strings.ToUpper(strings.TrimSpace(c.Query("token")))
+	siswaDetailID := c.Query("siswaDetailId")
+
+	if token == "" || siswaDetailID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Token dan siswaDetailId wajib diisi",
+		})
+	}
+
+	ujian, fe := h.cekTokenUjian(ujianID, token)
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+	if _, fe := h.cekSiswaUjian(ujian, siswaDetailID); fe != nil {
+		return kirimFiberError(c, fe)
+	}
+
+	// Soal hanya dikirim setelah siswa memulai ujian lewat /start dan sebelum mengumpulkan
+	peserta, fe := h.cekPesertaUjian(ujianID, siswaDetailID)
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+	if fe := h.cekBelumMengumpulkan(ujianID, siswaDetailID); fe != nil {
+		return kirimFiberError(c, fe)
+	}
+
+	soalList, fe := h.soalPeserta(ujian, peserta)
+	if fe != nil {
+		return kirimFiberError(c, fe)
+	}
+
+	return c.JSON(models.SoalUjianResponse{
+		Success:         true,
+		UjianID:         ujian.ID,
+		MataPelajaran:   ujian.MataPelajaran.Pelajaran,
+		WaktuPengerjaan: ujian.WaktuPengerjaan,
+		BatasWaktu:      services.BatasWaktu(peserta.WaktuMulai, ujian.WaktuPengerjaan),
+		Soal:            services.AcakSoal(soalList, ujian.ID, siswaDetailID),
+	})
+}
+
 // SubmitUjian handles the submission of student exam answers
 func (h *UjianHandler) SubmitUjian(c *fiber.Ctx) error {
     // Log the start of request handling
@@ -68,134 +142,129 @@ func (h *UjianHandler) SubmitUjian(c *fiber.Ctx) error {
         })
     }
 
+    if request.IdempotencyKey == "" {
+        // Nilai header hanya berlaku selama request, salin sebelum disimpan
+        request.IdempotencyKey = strings.Clone(c.Get("Idempotency-Key"))
+    }
+
     log.Printf("Received submission data: %+v", request)
     log.Printf("Answers received: %+v", request.Answers)
 
     // Validate request data
-    if request.UjianID == "" || request.SiswaDetailID == "" || len(request.Answers) == 0 {
+    if request.UjianID == "" || request.SiswaDetailID == "" {
         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
             "success": false,
             "message": "Missing required fields",
         })
     }
 
-    // Start a transaction
-    tx, err := h.DB.Begin()
+    ujian, err := h.Ujian.GetUjian(request.UjianID)
+    if err == repositories.ErrNotFound {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "success": false,
+            "message": "Ujian not found",
+        })
+    }
     if err != nil {
-        log.Printf("Error starting transaction: %v", err)
+        log.Printf("Error fetching ujian: %v", err)
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "success": false,
             "message": "Database error",
         })
     }
-    defer tx.Rollback() // Will be ignored if transaction is committed
-
-    // 1. Save all student answers first
-   // In your SubmitUjian handler function, modify the insert code:
-for soalID, jawabanID := range request.Answers {
-    // Generate a CUID-compatible ID for the answer
-    answerID := fmt.Sprintf("cm%s", uuid.New().String()[:20])
-    
-    // Get current time for createdAt
-    now := time.Now().UTC()
-    
-    // Debug the values being inserted
-    log.Printf("Inserting answer: ID=%s, siswaDetailId=%s, ujianId=%s, soalId=%s, jawabanId=%s, createdAt=%v",
-        answerID, request.SiswaDetailID, request.UjianID, soalID, jawabanID, now)
-    
-    // Insert answer into jawaban_siswa table
-   result, err := tx.Exec(
-    `INSERT INTO jawaban_siswa 
-    ("id", "siswaDetailId", "ujianId", "soalId", "jawabanId", "createdAt") 
-    VALUES ($1, $2, $3, $4, $5, $6)`,
-    answerID, request.SiswaDetailID, request.UjianID, soalID, jawabanID, now,
-)
 
-    
+    // Durasi dihitung dari waktu mulai yang dicatat server, bukan waktuPengerjaan dari client
+    peserta, fe := h.cekPesertaUjian(request.UjianID, request.SiswaDetailID)
+    if fe != nil {
+        return kirimFiberError(c, fe)
+    }
+
+    // Klik ganda atau retry jaringan dengan idempotency key yang sama langsung mendapat respons sebelumnya
+    sudahAda, err := h.Hasil.GetHasilSiswa(request.UjianID, request.SiswaDetailID)
+    if err != nil && err != repositories.ErrNotFound {
+        log.Printf("Error fetching hasil siswa: %v", err)
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": "Database error",
+        })
+    }
+    if sudahAda != nil && request.IdempotencyKey != "" && request.IdempotencyKey == sudahAda.IdempotencyKey {
+        return c.Status(fiber.StatusOK).JSON(h.responsHasil(sudahAda, "Exam already submitted"))
+    }
+    waktuPengerjaan, terlambat := services.DurasiPengerjaan(peserta.WaktuMulai, h.Clock.Now(), ujian.WaktuPengerjaan)
+
+    // 1. Ambil kunci jawaban dari soal yang dikerjakan siswa (hasil undian bila ujian memakai undian)
+    // dan jawaban yang sudah tersimpan lewat autosave
+    soalList, fe := h.soalPeserta(ujian, peserta)
+    if fe != nil {
+        return kirimFiberError(c, fe)
+    }
+    kunci := services.NewKunciJawaban(soalList, ujian)
+
+    tersimpan, err := h.Jawaban.GetJawabanSiswa(request.UjianID, request.SiswaDetailID)
     if err != nil {
-        // Get exact MySQL error details
-        log.Printf("Full error details for saving answer: %v", err)
-        
-        // Try to get a more specific error type if using MySQL
-        
-        
+        log.Printf("Error fetching jawaban siswa: %v", err)
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "success": false,
-            "message": fmt.Sprintf("Error saving answers: %v", err),
+            "message": "Database error",
         })
     }
-    
-    // Log the number of rows affected
-    rowsAffected, _ := result.RowsAffected()
-    log.Printf("Rows affected by insert: %d", rowsAffected)
-}
 
-    // 2. Calculate the results (correct/incorrect answers)
-    var totalCorrect int
-    var totalAnswers int = len(request.Answers)
-
-    for soalID, jawabanID := range request.Answers {
-        var isCorrect bool
-		err := tx.QueryRow(
-			`SELECT benar FROM jawaban WHERE "id" = $1 AND "soalId" = $2`, 
-			jawabanID, soalID,
-		).Scan(&isCorrect)
-
-
-        
-        if err != nil {
-            log.Printf("Error checking answer correctness: %v", err)
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                "success": false,
-                "message": "Error calculating results",
-            })
-        }
-        
-        if isCorrect {
-            totalCorrect++
+    // 2. Jawaban yang dikirim bersama submit menimpa jawaban tersimpan untuk soal yang sama.
+    // Bila submit datang setelah batas waktu ditambah ToleransiPengumpulan, jawaban yang dikirim diabaikan dan
+    // hanya jawaban tersimpan yang dinilai. Autosave juga diterima sampai batas yang sama, jadi jawaban
+    // tersimpan bisa berasal dari masa toleransi.
+    now := h.Clock.Now().UTC()
+    dikirim := make([]models.JawabanSiswa, 0, len(request.Answers)+len(request.JawabanTeks))
+    for soalID, dipilih := range request.Answers {
+        if fe := cekJawaban(kunci, soalID, dipilih, ""); fe != nil {
+            log.Printf("Invalid answer %v for soal %s", []string(dipilih), soalID)
+            return kirimFiberError(c, fe)
         }
+
+        dikirim = append(dikirim, jawabanSiswaBaru(kunci, request.UjianID, request.SiswaDetailID, soalID, dipilih, "", now))
     }
+    for soalID, teks := range request.JawabanTeks {
+        if fe := cekJawaban(kunci, soalID, nil, teks); fe != nil {
+            log.Printf("Invalid text answer for soal %s", soalID)
+            return kirimFiberError(c, fe)
+        }
 
-    // 3. Count cheating incidents
-    var totalCheating int
-    err = tx.QueryRow(
-    "SELECT COUNT(*) FROM kecurangan WHERE \"ujianId\" = $1 AND \"siswaDetailId\" = $2",
-    request.UjianID, request.SiswaDetailID,
-).Scan(&totalCheating)
+        dikirim = append(dikirim, jawabanSiswaBaru(kunci, request.UjianID, request.SiswaDetailID, soalID, nil, teks, now))
+    }
 
-    
-    if err != nil {
-        log.Printf("Error counting cheating incidents: %v", err)
-        // Non-fatal error, continue with 0 cheating count
-        totalCheating = 0
+    // Pengumpulan ulang dibandingkan dengan jawaban yang dikirim, walaupun terlambat dan tidak akan dinilai
+    jawabanDikirim := gabungJawaban(tersimpan, dikirim)
+    if sudahAda != nil {
+        return h.kirimPengumpulanUlang(c, sudahAda, jawabanDikirim)
     }
 
-    // 4. Calculate score
-    totalIncorrect := totalAnswers - totalCorrect
-    // Score calculation: (correct answers / total questions) * 100
-    nilai := int((float64(totalCorrect) / float64(totalAnswers)) * 100)
-
-    // Convert ints to strings for the Prisma schema
-    nilaiStr := fmt.Sprintf("%d", nilai)
-    benarStr := fmt.Sprintf("%d", totalCorrect)
-    salahStr := fmt.Sprintf("%d", totalIncorrect)
-    waktuPengerjaanStr := fmt.Sprintf("%d", request.WaktuPengerjaan)
-
-    // 5. Create hasil record - make sure types match Prisma schema (using strings)
-    hasilID := fmt.Sprintf("cm%s", uuid.New().String()[:20]) // Generate CUID-compatible ID
-    
-    // Debug the values being inserted for hasil
-    log.Printf("Inserting hasil: ID=%s, siswaDetailId=%s, ujianId=%s, waktuPengerjaan=%s, nilai=%s, benar=%s, salah=%s",
-        hasilID, request.SiswaDetailID, request.UjianID, waktuPengerjaanStr, nilaiStr, benarStr, salahStr)
-    
-   _, err = tx.Exec(
-    `INSERT INTO hasil 
-    ("id", "siswaDetailId", "ujianId", "waktuPengerjaan", "nilai", "benar", "salah") 
-    VALUES ($1, $2, $3, $4, $5, $6, $7)`, // ✅ Gunakan $1, $2, ...
-    hasilID, request.SiswaDetailID, request.UjianID, waktuPengerjaanStr, nilaiStr, benarStr, salahStr,
-)
+    jawabanBaru := dikirim
+    if terlambat {
+        log.Printf("Late submission: ujian %s siswa %s started %s, scoring %d saved answers", request.UjianID, request.SiswaDetailID, peserta.WaktuMulai, len(tersimpan))
+        jawabanBaru = nil
+    }
+    jawaban := gabungJawaban(tersimpan, jawabanBaru)
+    if len(jawaban) == 0 && !terlambat {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "success": false,
+            "message": "Belum ada jawaban yang dikirim atau tersimpan",
+        })
+    }
 
-    
+    // 3. Hitung nilai lalu simpan jawaban baru dan hasil dalam satu transaksi
+    hasil := kunci.NilaiHasil(request.UjianID, request.SiswaDetailID, jawaban, waktuPengerjaan)
+    hasil.IdempotencyKey = request.IdempotencyKey
+    // Sidik memakai jawaban yang dikirim supaya retry pengumpulan terlambat yang sama tidak ditolak
+    hasil.JawabanHash = services.HashJawaban(jawabanDikirim)
+    err = h.Hasil.SimpanHasil(hasil, jawabanBaru)
+    if err == repositories.ErrHasilSudahAda {
+        // Kalah balapan dengan pengumpulan lain yang datang bersamaan
+        sudahAda, err = h.Hasil.GetHasilSiswa(request.UjianID, request.SiswaDetailID)
+        if err == nil {
+            return h.kirimPengumpulanUlang(c, sudahAda, jawabanDikirim)
+        }
+    }
     if err != nil {
         log.Printf("Error saving hasil: %v", err)
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -204,26 +273,13 @@ for soalID, jawabanID := range request.Answers {
         })
     }
 
-    // Commit the transaction
-    if err := tx.Commit(); err != nil {
-        log.Printf("Error committing transaction: %v", err)
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "success": false,
-            "message": "Database error",
-        })
+    message := "Exam submitted successfully"
+    if terlambat {
+        message = "Waktu pengerjaan sudah habis, jawaban yang tersimpan sudah dinilai"
     }
 
     // Send successful response
-    return c.Status(fiber.StatusOK).JSON(models.SubmitUjianResponse{
-        Success:         true,
-        Message:         "Exam submitted successfully",
-        HasilID:         hasilID,
-        Nilai:           nilai,
-        Benar:           totalCorrect,
-        Salah:           totalIncorrect,
-        TotalKecurangan: totalCheating,
-        WaktuPengerjaan: request.WaktuPengerjaan,
-    })
+    return c.Status(fiber.StatusOK).JSON(h.responsHasil(&hasil, message))
 }
 
 
@@ -239,32 +295,9 @@ func (h *UjianHandler) GetHasilDetail(c *fiber.Ctx) error {
 		})
 	}
 
-	// Perbaikan: Gunakan placeholder PostgreSQL ($1, $2)
-	var hasil models.HasilDetail
-	var createdAtFloat float64
-	err := h.DB.QueryRow(
-	`SELECT h."id", h."siswaDetailId", h."ujianId", h."waktuPengerjaan", h."nilai", h."benar", h."salah",
-	        (SELECT COUNT(*) FROM kecurangan WHERE "ujianId" = h."ujianId" AND "siswaDetailId" = h."siswaDetailId") as totalKecurangan,
-	        EXTRACT(EPOCH FROM h."createdAt") as createdAt,
-	        mp."pelajaran", mp."tingkat" -- Ambil tingkat juga
-	 FROM hasil h
-	 JOIN ujian u ON h."ujianId" = u."id"
-	 JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp."id"
-	 WHERE h."id" = $1`, 
-	hasilID,
-).Scan(
-	&hasil.ID, &hasil.SiswaDetailID, &hasil.UjianID, 
-	&hasil.WaktuPengerjaan, &hasil.Nilai, &hasil.Benar, &hasil.Salah,
-	&hasil.TotalKecurangan, &createdAtFloat, 
-	&hasil.MataPelajaran, &hasil.Tingkat, // Tambahkan tingkat
-)
-
-
-	// Konversi float64 ke int64 jika perlu
-	hasil.CreatedAt = int64(createdAtFloat)
-
+	hasil, err := h.Hasil.GetHasilDetail(hasilID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if err == repositories.ErrNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"success": false,
 				"message": "Hasil not found",
@@ -277,67 +310,33 @@ func (h *UjianHandler) GetHasilDetail(c *fiber.Ctx) error {
 		})
 	}
 
-	// Ambil mataPelajaranId dari tabel ujian
-	var mataPelajaranID string
-	err = h.DB.QueryRow(
-		`SELECT "mataPelajaranId" FROM ujian WHERE "id" = $1`,
-		hasil.UjianID,
-	).Scan(&mataPelajaranID)
-
-	if err != nil {
-		log.Printf("Error fetching mataPelajaranId for ujianId %s: %v", hasil.UjianID, err)
-		mataPelajaranID = "" // Default kosong jika error
-	}
-
-	// Ambil nama mata pelajaran dari tabel mata_pelajaran
-	var mataPelajaran string
-	if mataPelajaranID != "" {
-		err = h.DB.QueryRow(
-			`SELECT "pelajaran" FROM mata_pelajaran WHERE "id" = $1`,
-			mataPelajaranID,
-		).Scan(&mataPelajaran)
-
-		if err != nil {
-			log.Printf("Error fetching pelajaran for mataPelajaranId %s: %v", mataPelajaranID, err)
-			mataPelajaran = "Unknown"
-		}
-	} else {
-		mataPelajaran = "Unknown"
-	}
-
-	// Tambahkan nama mata pelajaran ke hasil
-	hasil.MataPelajaran = mataPelajaran
-
 	// Ambil detail kecurangan berdasarkan type
-	rows, err := h.DB.Query(
-	`SELECT "type", COUNT(*) as count 
-	 FROM kecurangan 
-	 WHERE "ujianId" = $1 AND "siswaDetailId" = $2 
-	 GROUP BY "type"`,
-	hasil.UjianID, hasil.SiswaDetailID,
-)
-
-	if err != nil && err != sql.ErrNoRows {
+	byType, err := h.Kecurangan.CountKecuranganByType(hasil.UjianID, hasil.SiswaDetailID)
+	if err != nil {
 		log.Printf("Error fetching cheating details: %v", err)
 	} else {
-		defer rows.Close()
-		
-		byType := []models.CheatingCount{}
-		for rows.Next() {
-			var cheatType models.TypeKecurangan
-			var count int
-			if err := rows.Scan(&cheatType, &count); err != nil {
-				continue
-			}
-			byType = append(byType, models.CheatingCount{Type: cheatType, Count: count})
-		}
-		
 		hasil.Kecurangan = models.CheatingDetail{
 			TotalCount: hasil.TotalKecurangan,
 			ByType:     byType,
 		}
 	}
 
+	// Rincian per topik/KD bersifat pelengkap, hasil tetap dikirim walaupun soal gagal dimuat,
+	// kecuali lembar jawaban diminta dengan ?detail=true
+	detail := c.QueryBool("detail")
+	data, fe := h.muatJawabanHasil(hasil)
+	if fe != nil {
+		if detail {
+			return kirimFiberError(c, fe)
+		}
+		log.Printf("Error fetching rincian topik hasil %s: %s", hasilID, fe.Message)
+	} else {
+		hasil.RincianTopik = data.kunci.RincianTopik(data.soal, data.jawaban)
+		if detail {
+			hasil.KunciDitampilkan = data.ujian.TampilkanKunci
+			hasil.LembarJawaban = data.kunci.LembarJawaban(data.soal, data.jawaban, data.ujian.ID, hasil.SiswaDetailID, data.ujian.TampilkanKunci)
+		}
+	}
+
 	return c.Status(fiber.StatusOK).JSON(hasil)
 }
-
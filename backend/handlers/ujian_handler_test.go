@@ -0,0 +1,717 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSubmitUjian(t *testing.T) {
+	store := seedStore()
+	store.Kecurangan = []models.CheatingEvent{
+		{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", Type: models.TabHidden},
+		{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", Type: models.TabHidden},
+		{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", Type: models.Blurred},
+	}
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	request := models.SubmitUjianRequest{
+		UjianID:         "ujian-mtk",
+		SiswaDetailID:   "siswa-1",
+		Answers:         map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-a"}},
+		WaktuPengerjaan: 45,
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	// waktuPengerjaan dari client diabaikan, server menghitung 07:35 sampai 08:00
+	if resp.Nilai != 50 || resp.Benar != 1 || resp.Salah != 1 || resp.TotalKecurangan != 3 || resp.WaktuPengerjaan != 1500 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	store.Lock()
+	jawaban := len(store.JawabanSiswa)
+	store.Unlock()
+	if jawaban != 2 {
+		t.Errorf("expected 2 jawaban_siswa rows, got %d", jawaban)
+	}
+
+	var hasil models.HasilDetail
+	if status := doJSON(t, app, http.MethodGet, "/api/hasil/"+resp.HasilID, nil, &hasil); status != http.StatusOK {
+		t.Fatalf("GetHasilDetail status = %d", status)
+	}
+	if hasil.Nilai != 50 || hasil.Kosong != 0 || hasil.MataPelajaran != "MTK" || hasil.Tingkat != "X" || hasil.TotalKecurangan != 3 || hasil.WaktuPengerjaan != 1500 {
+		t.Errorf("unexpected hasil %+v", hasil)
+	}
+	if hasil.Kecurangan.TotalCount != 3 || len(hasil.Kecurangan.ByType) != 2 {
+		t.Errorf("unexpected kecurangan detail %+v", hasil.Kecurangan)
+	}
+
+	// Klik ganda dengan jawaban yang sama mendapat hasil yang sama, bukan hasil baru
+	var ulang models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &ulang); status != http.StatusOK {
+		t.Fatalf("repeat submit: status = %d, resp %+v", status, ulang)
+	}
+	if ulang.HasilID != resp.HasilID || ulang.Nilai != 50 || ulang.WaktuPengerjaan != 1500 {
+		t.Errorf("repeat submit returned %+v, want the original hasil %+v", ulang, resp)
+	}
+
+	// Jawaban berbeda untuk ujian yang sudah dinilai ditolak
+	request.Answers = map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-b"}}
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, nil); status != http.StatusConflict {
+		t.Errorf("different answers: status = %d, want 409", status)
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	if len(store.Hasil) != 1 || len(store.JawabanSiswa) != 2 {
+		t.Errorf("resubmissions must not change stored data, got %d hasil and %d jawaban", len(store.Hasil), len(store.JawabanSiswa))
+	}
+}
+
+func TestSubmitUjianIdempotencyKey(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, clk := newTestApp(t, store, pukul(8, 0))
+
+	request := models.SubmitUjianRequest{
+		UjianID:        "ujian-mtk",
+		SiswaDetailID:  "siswa-1",
+		Answers:        map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-b"}},
+		IdempotencyKey: "kirim-1",
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+
+	// Retry dengan key yang sama dikenali walaupun body berubah dan waktu sudah habis
+	clk.Set(pukul(12, 0))
+	retry := models.SubmitUjianRequest{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", Answers: map[string]models.JawabanPilihan{"soal-1": {"s1-a"}}}
+	data, _ := json.Marshal(retry)
+	req := httptest.NewRequest(http.MethodPost, "/api/ujian/submit", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "kirim-1")
+	status, body := doRequest(t, app, req)
+	if status != http.StatusOK {
+		t.Fatalf("retry: status = %d, body %s", status, body)
+	}
+	var ulang models.SubmitUjianResponse
+	if err := json.Unmarshal(body, &ulang); err != nil {
+		t.Fatal(err)
+	}
+	if ulang.HasilID != resp.HasilID || ulang.Nilai != 100 {
+		t.Errorf("retry returned %+v, want %+v", ulang, resp)
+	}
+}
+
+func TestSubmitUjianDitolak(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	tests := []struct {
+		name    string
+		request models.SubmitUjianRequest
+		status  int
+	}{
+		{"tanpa jawaban", models.SubmitUjianRequest{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1"}, http.StatusBadRequest},
+		{"ujian tidak ada", models.SubmitUjianRequest{UjianID: "tidak-ada", SiswaDetailID: "siswa-1",
+			Answers: map[string]models.JawabanPilihan{"soal-1": {"s1-b"}}}, http.StatusNotFound},
+		{"jawaban dari soal lain", models.SubmitUjianRequest{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1",
+			Answers: map[string]models.JawabanPilihan{"soal-1": {"s2-b"}}}, http.StatusBadRequest},
+		{"belum memulai", models.SubmitUjianRequest{UjianID: "ujian-mtk", SiswaDetailID: "siswa-2",
+			Answers: map[string]models.JawabanPilihan{"soal-1": {"s1-b"}}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", tt.request, nil); status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestSubmitUjianBatasWaktu(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 30)},
+		{ID: "peserta-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2", WaktuMulai: pukul(7, 30)},
+	}
+	// Batas waktu 120 menit berakhir 09:30, pengumpulan 09:31 masih masuk toleransi
+	app, clk := newTestApp(t, store, pukul(9, 31))
+
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	if resp.WaktuPengerjaan != 7200 {
+		t.Errorf("waktuPengerjaan = %d, want capped at 7200", resp.WaktuPengerjaan)
+	}
+	// Satu jawaban benar dari dua soal tetap bernilai 50, soal lain tercatat kosong
+	if resp.Nilai != 50 || resp.Benar != 1 || resp.Salah != 0 || resp.Kosong != 1 {
+		t.Errorf("unexpected score %+v", resp)
+	}
+
+	// Setelah toleransi habis hanya jawaban yang tersimpan sebelum batas waktu yang dinilai
+	store.JawabanSiswa = append(store.JawabanSiswa, models.JawabanSiswa{
+		ID: "js-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2", SoalID: "soal-2", JawabanID: "s2-a",
+	})
+	clk.Set(pukul(9, 33))
+	request.SiswaDetailID = "siswa-2"
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("late submit: status = %d, resp %+v", status, resp)
+	}
+	if resp.Nilai != 0 || resp.Benar != 0 || resp.Salah != 1 || resp.Kosong != 1 || resp.WaktuPengerjaan != 7200 {
+		t.Errorf("late submit must score only saved answers, got %+v", resp)
+	}
+
+	// Jawaban terlambat tidak dinilai, tetapi tetap dibandingkan saat pengumpulan ulang
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, nil); status != http.StatusOK {
+		t.Errorf("repeated late submit: status = %d, want 200", status)
+	}
+	request.Answers = map[string]models.JawabanPilihan{"soal-1": {"s1-a"}}
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, nil); status != http.StatusConflict {
+		t.Errorf("late resubmit with different answers: status = %d, want 409", status)
+	}
+}
+
+func TestSubmitUjianSetelahDitutupOtomatis(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 30)},
+	}
+	store.JawabanSiswa = []models.JawabanSiswa{
+		{ID: "js-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", SoalID: "soal-1", JawabanID: "s1-b"},
+	}
+	app, clk := newTestApp(t, store, pukul(9, 33))
+	if ditutup := services.NewPenutupUjian(repositories.NewMemoryRepositories(store), clk).TutupKedaluwarsa(); ditutup != 1 {
+		t.Fatalf("closed %d attempts, want 1", ditutup)
+	}
+
+	// Submit yang tertunda membawa jawaban lebih banyak dari yang tersimpan, tetap menerima hasil penutupan
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-b"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	hasil, err := store.GetHasilSiswa("ujian-mtk", "siswa-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.HasilID != hasil.ID || resp.Nilai != 50 || resp.Benar != 1 || resp.Kosong != 1 {
+		t.Errorf("expected the auto-closed hasil %+v, got %+v", hasil, resp)
+	}
+}
+
+func TestSubmitUjianBerbobot(t *testing.T) {
+	store := seedStore()
+	store.Soal[0].Bobot = 3
+	store.MataPelajaran[0].PenaltiSalah = 0.25
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	// soal-1 (bobot 3) benar, soal-2 (bobot 1) salah: (3 - 0.25) / 4 * 100 = 68
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-a"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	want := models.RincianNilai{TotalBobot: 4, BobotBenar: 3, BobotSalah: 1, PenaltiSalah: 0.25, Pengurangan: 0.25, Skor: 2.75}
+	if resp.Nilai != 68 || resp.Rincian == nil || *resp.Rincian != want {
+		t.Fatalf("unexpected response %+v (rincian %+v)", resp, resp.Rincian)
+	}
+
+	var hasil models.HasilDetail
+	if status := doJSON(t, app, http.MethodGet, "/api/hasil/"+resp.HasilID, nil, &hasil); status != http.StatusOK {
+		t.Fatalf("GetHasilDetail status = %d", status)
+	}
+	if hasil.Nilai != 68 || hasil.Rincian == nil || *hasil.Rincian != want {
+		t.Errorf("unexpected hasil %+v (rincian %+v)", hasil, hasil.Rincian)
+	}
+}
+
+func TestPenaltiSalahUjianMenimpaMataPelajaran(t *testing.T) {
+	store := seedStore()
+	tanpaPenalti := 0.0
+	store.MataPelajaran[0].PenaltiSalah = 0.5
+	store.Ujian[0].PenaltiSalah = &tanpaPenalti
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-a"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	if resp.Nilai != 50 || resp.Rincian.PenaltiSalah != 0 {
+		t.Errorf("ujian setting must override mata pelajaran, got %+v (rincian %+v)", resp, resp.Rincian)
+	}
+}
+
+func TestSubmitUjianPilihanGandaKompleks(t *testing.T) {
+	store := seedStore()
+	// soal-2 menjadi pilihan ganda kompleks dengan kunci s2-b dan s2-c
+	store.Soal[1].Tipe = models.TipePilihanGandaKompleks
+	store.Jawaban[7].Benar = true
+	nilaiParsial := true
+	store.Ujian[0].NilaiParsial = &nilaiParsial
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+		{ID: "peserta-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2", WaktuMulai: pukul(7, 35)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	// Soal pilihan ganda biasa tetap boleh dikirim sebagai string, soal kompleks sebagai array
+	tunggalGanda := map[string]interface{}{
+		"ujianId": "ujian-mtk", "siswaDetailId": "siswa-2",
+		"answers": map[string]interface{}{"soal-1": []string{"s1-b", "s1-c"}},
+	}
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", tunggalGanda, nil); status != http.StatusBadRequest {
+		t.Errorf("two answers for a single-answer soal: status = %d, want 400", status)
+	}
+
+	request := map[string]interface{}{
+		"ujianId": "ujian-mtk", "siswaDetailId": "siswa-1",
+		"answers": map[string]interface{}{"soal-1": "s1-b", "soal-2": []string{"s2-b"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	// soal-1 benar, soal-2 benar separuh: 1.5 / 2 * 100 = 75
+	want := models.RincianNilai{TotalBobot: 2, BobotBenar: 1, BobotSebagian: 1, NilaiParsial: true, SkorSebagian: 0.5, Skor: 1.5}
+	if resp.Nilai != 75 || resp.Benar != 1 || resp.Salah != 1 || resp.Rincian == nil || *resp.Rincian != want {
+		t.Fatalf("unexpected response %+v (rincian %+v)", resp, resp.Rincian)
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	for _, js := range store.JawabanSiswa {
+		if js.SoalID == "soal-2" && (js.JawabanID != "" || !reflect.DeepEqual(js.JawabanIDs, []string{"s2-b"})) {
+			t.Errorf("multi-select answer must be stored in jawabanIds, got %+v", js)
+		}
+	}
+}
+
+func TestGetHasilDetailTidakAda(t *testing.T) {
+	app, _ := newTestApp(t, seedStore(), pukul(8, 0))
+
+	if status := doJSON(t, app, http.MethodGet, "/api/hasil/tidak-ada", nil, nil); status != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", status)
+	}
+}
+
+func TestGetHasilDetailRincianTopik(t *testing.T) {
+	store := seedStore()
+	store.Soal[0].KD, store.Soal[0].Topik = "3.2", "Persamaan Linear"
+	store.Soal[1].KD, store.Soal[1].Topik = "3.10", "Statistika"
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("submit status = %d, resp %+v", status, resp)
+	}
+
+	var hasil models.HasilDetail
+	if status := doJSON(t, app, http.MethodGet, "/api/hasil/"+resp.HasilID, nil, &hasil); status != http.StatusOK {
+		t.Fatalf("GetHasilDetail status = %d", status)
+	}
+	want := []models.RincianTopik{
+		{KD: "3.2", Topik: "Persamaan Linear", Benar: 1, Total: 1},
+		{KD: "3.10", Topik: "Statistika", Kosong: 1, Total: 1},
+	}
+	if !reflect.DeepEqual(hasil.RincianTopik, want) {
+		t.Errorf("rincianTopik = %+v, want %+v", hasil.RincianTopik, want)
+	}
+}
+
+func TestGetHasilDetailRincianTopikBankSoalBerubah(t *testing.T) {
+	store := seedStore()
+	store.Soal[0].KD, store.Soal[0].Topik = "3.2", "Persamaan Linear"
+	store.Soal[1].KD, store.Soal[1].Topik = "3.10", "Statistika"
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35), SoalIDs: []string{"soal-1", "soal-2"}},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-a"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("submit status = %d, resp %+v", status, resp)
+	}
+
+	// Soal baru setelah ujian tidak dihitung kosong, soal yang diarsipkan tetap dihitung
+	store.Lock()
+	store.Soal = append(store.Soal, models.Soal{ID: "soal-3", Soal: "Jelaskan peluang", Tipe: models.TipeEsai, MataPelajaranID: "mp-mtk", KD: "3.12", Topik: "Peluang"})
+	store.Unlock()
+	if status := doJSON(t, app, http.MethodDelete, "/api/soal/soal-1", nil, nil); status != http.StatusOK {
+		t.Fatalf("HapusSoal status = %d", status)
+	}
+
+	var hasil models.HasilDetail
+	if status := doJSON(t, app, http.MethodGet, "/api/hasil/"+resp.HasilID, nil, &hasil); status != http.StatusOK {
+		t.Fatalf("GetHasilDetail status = %d", status)
+	}
+	want := []models.RincianTopik{
+		{KD: "3.2", Topik: "Persamaan Linear", Benar: 1, Total: 1},
+		{KD: "3.10", Topik: "Statistika", Salah: 1, Total: 1},
+	}
+	if !reflect.DeepEqual(hasil.RincianTopik, want) {
+		t.Errorf("rincianTopik = %+v, want %+v", hasil.RincianTopik, want)
+	}
+}
+
+func TestGetHasilDetailLembarJawaban(t *testing.T) {
+	store := seedStore()
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-c"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("submit status = %d", status)
+	}
+
+	var ringkas models.HasilDetail
+	doJSON(t, app, http.MethodGet, "/api/hasil/"+resp.HasilID, nil, &ringkas)
+	if ringkas.LembarJawaban != nil {
+		t.Errorf("lembar jawaban must only be returned with detail=true")
+	}
+
+	lembar := func() models.HasilDetail {
+		t.Helper()
+		var hasil models.HasilDetail
+		if status := doJSON(t, app, http.MethodGet, "/api/hasil/"+resp.HasilID+"?detail=true", nil, &hasil); status != http.StatusOK {
+			t.Fatalf("GetHasilDetail status = %d", status)
+		}
+		if len(hasil.LembarJawaban) != 2 {
+			t.Fatalf("expected 2 soal on the answer sheet, got %+v", hasil.LembarJawaban)
+		}
+		return hasil
+	}
+
+	hasil := lembar()
+	status := map[string]string{}
+	for _, soal := range hasil.LembarJawaban {
+		status[soal.SoalID] = soal.Status
+		for _, p := range soal.Pilihan {
+			if p.Benar != nil {
+				t.Errorf("correct answers must be hidden by default, got %+v", p)
+			}
+			if p.Dipilih != (p.ID == "s1-b" || p.ID == "s2-c") {
+				t.Errorf("pilihan %s dipilih = %v", p.ID, p.Dipilih)
+			}
+		}
+	}
+	if hasil.KunciDitampilkan || status["soal-1"] != "benar" || status["soal-2"] != "salah" {
+		t.Errorf("unexpected status %v (kunci %v)", status, hasil.KunciDitampilkan)
+	}
+
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/tampilkan-kunci", map[string]bool{"tampilkanKunci": true}, nil); status != http.StatusOK {
+		t.Fatalf("tampilkan kunci status = %d", status)
+	}
+	hasil = lembar()
+	for _, soal := range hasil.LembarJawaban {
+		for _, p := range soal.Pilihan {
+			if p.Benar == nil || *p.Benar != (p.ID == "s1-b" || p.ID == "s2-b") {
+				t.Errorf("pilihan %s benar = %v", p.ID, p.Benar)
+			}
+		}
+	}
+	if !hasil.KunciDitampilkan {
+		t.Error("expected kunciDitampilkan after enabling it")
+	}
+
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/ujian-mtk/tampilkan-kunci", map[string]string{}, nil); status != http.StatusBadRequest {
+		t.Errorf("missing tampilkanKunci status = %d, want 400", status)
+	}
+	if status := doJSON(t, app, http.MethodPut, "/api/ujian/tidak-ada/tampilkan-kunci", map[string]bool{"tampilkanKunci": true}, nil); status != http.StatusNotFound {
+		t.Errorf("unknown ujian status = %d, want 404", status)
+	}
+}
+
+func TestLembarJawabanBankSoalBerubah(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "active"
+	store.Ujian[0].Token = "ABCDE"
+	app, clk := newTestApp(t, store, pukul(7, 35))
+
+	start := models.MulaiUjianRequest{Token: "ABCDE", SiswaDetailID: "siswa-1"}
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/ujian-mtk/start", start, nil); status != http.StatusOK {
+		t.Fatalf("start status = %d", status)
+	}
+	var soal models.SoalUjianResponse
+	doJSON(t, app, http.MethodGet, "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=siswa-1", nil, &soal)
+	urutan := urutanSoal(soal)
+
+	clk.Set(pukul(8, 0))
+	request := models.SubmitUjianRequest{
+		UjianID:       "ujian-mtk",
+		SiswaDetailID: "siswa-1",
+		Answers:       map[string]models.JawabanPilihan{"soal-1": {"s1-b"}, "soal-2": {"s2-c"}},
+	}
+	var resp models.SubmitUjianResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/ujian/submit", request, &resp); status != http.StatusOK {
+		t.Fatalf("submit status = %d", status)
+	}
+
+	// Soal baru di depan bank soal dan soal yang diarsipkan tidak mengubah lembar jawaban
+	store.Lock()
+	store.Soal = append(store.Soal, models.Soal{ID: "soal-0", Soal: "Jelaskan bilangan prima", Tipe: models.TipeEsai, MataPelajaranID: "mp-mtk"})
+	store.Unlock()
+	if status := doJSON(t, app, http.MethodDelete, "/api/soal/soal-2", nil, nil); status != http.StatusOK {
+		t.Fatalf("HapusSoal status = %d", status)
+	}
+
+	var hasil models.HasilDetail
+	if status := doJSON(t, app, http.MethodGet, "/api/hasil/"+resp.HasilID+"?detail=true", nil, &hasil); status != http.StatusOK {
+		t.Fatalf("GetHasilDetail status = %d", status)
+	}
+	var lembar []string
+	for _, s := range hasil.LembarJawaban {
+		lembar = append(lembar, s.SoalID)
+		for _, p := range s.Pilihan {
+			lembar = append(lembar, p.ID)
+		}
+		if s.Status == "kosong" {
+			t.Errorf("soal %s shown as kosong", s.SoalID)
+		}
+	}
+	if !reflect.DeepEqual(lembar, urutan) {
+		t.Errorf("answer sheet order = %v, want the exam order %v", lembar, urutan)
+	}
+}
+
+func TestGetUjianTrackingData(t *testing.T) {
+	app, _ := newTestApp(t, seedStore(), pukul(7, 0))
+
+	var resp models.ResponseDataUjian
+	if status := doJSON(t, app, http.MethodGet, "/api/data-ujian", nil, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(resp.X) != 1 || len(resp.X[0].SesiUjian) != 2 {
+		t.Fatalf("expected one day with two sesi for tingkat X, got %+v", resp.X)
+	}
+	if resp.X[0].SesiUjian[0].Ujian[0].ID != "ujian-mtk" || len(resp.XI) != 0 {
+		t.Errorf("unexpected jadwal %+v", resp)
+	}
+}
+
+func TestRefreshJadwalUjian(t *testing.T) {
+	app, _ := newTestApp(t, seedStore(), pukul(7, 0))
+
+	var resp map[string]interface{}
+	if status := doJSON(t, app, http.MethodPost, "/api/data-ujian/refresh", nil, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if resp["success"] != true {
+		t.Errorf("unexpected response %v", resp)
+	}
+}
+
+func TestDownloadHasilUjian(t *testing.T) {
+	store := seedStore()
+	store.Hasil = []models.HasilDetail{
+		{ID: "hasil-1", SiswaDetailID: "siswa-1", UjianID: "ujian-mtk", WaktuPengerjaan: 3600, Nilai: 80, Benar: 8, Salah: 2},
+	}
+	app, _ := newTestApp(t, store, pukul(13, 0))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/ujian/download", nil)
+	status, body := doRequest(t, app, req)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+	if len(body) < 4 || string(body[:2]) != "PK" {
+		t.Errorf("expected a zip archive, got %d bytes", len(body))
+	}
+}
+
+func TestDownloadHasilUjianFilterDanFormat(t *testing.T) {
+	store := seedStore()
+	store.Hasil = []models.HasilDetail{
+		{ID: "hasil-1", SiswaDetailID: "siswa-1", UjianID: "ujian-mtk", WaktuPengerjaan: 3600, Nilai: 80, Benar: 8, Salah: 2, CreatedAt: pukul(8, 0).Unix()},
+		{ID: "hasil-2", SiswaDetailID: "siswa-2", UjianID: "ujian-mtk", WaktuPengerjaan: 1800, Nilai: 60, Benar: 6, Salah: 4, CreatedAt: pukul(8, 0).AddDate(0, 0, 1).Unix()},
+	}
+	app, _ := newTestApp(t, store, pukul(13, 0))
+	hari := pukul(8, 0).Format("2006-01-02")
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/ujian/download?format=json&kelas=RPL&sampai="+hari, nil)
+	status, body := doRequest(t, app, req)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+	var hasil []models.HasilUjianDetail
+	if err := json.Unmarshal(body, &hasil); err != nil {
+		t.Fatal(err)
+	}
+	if len(hasil) != 1 || hasil[0].ID != "hasil-1" || hasil[0].Kelas != "X-RPL" || hasil[0].UjianID != "ujian-mtk" {
+		t.Errorf("hasil = %+v", hasil)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "/api/ujian/download?format=csv&ujianId=ujian-mtk", nil)
+	status, body = doRequest(t, app, req)
+	if status != http.StatusOK {
+		t.Fatalf("csv status = %d, body %s", status, body)
+	}
+	baris := strings.Split(strings.TrimSpace(strings.TrimPrefix(string(body), "\ufeff")), "\n")
+	if len(baris) != 3 || !strings.HasPrefix(baris[0], "No,NIS,Nama") || !strings.HasPrefix(baris[1], "1,1001,Budi,X-RPL,X,") {
+		t.Errorf("csv = %q", body)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "/api/ujian/download?format=xlsx&dari="+hari, nil)
+	status, body = doRequest(t, app, req)
+	if status != http.StatusOK || string(body[:2]) != "PK" {
+		t.Errorf("xlsx status = %d, %d bytes", status, len(body))
+	}
+
+	for _, tc := range []struct {
+		query  string
+		status int
+	}{
+		{"tingkat=XII", http.StatusNotFound},
+		{"dari=01-06-2025", http.StatusBadRequest},
+		{"dari=2025-06-02&sampai=2025-06-01", http.StatusBadRequest},
+		{"format=docx", http.StatusBadRequest},
+	} {
+		req, _ = http.NewRequest(http.MethodGet, "/api/ujian/download?"+tc.query, nil)
+		if status, body = doRequest(t, app, req); status != tc.status {
+			t.Errorf("%s: status = %d, want %d, body %s", tc.query, status, tc.status, body)
+		}
+	}
+}
+
+func urutanSoal(resp models.SoalUjianResponse) []string {
+	var urutan []string
+	for _, soal := range resp.Soal {
+		urutan = append(urutan, soal.ID)
+		for _, pilihan := range soal.Pilihan {
+			urutan = append(urutan, pilihan.ID)
+		}
+	}
+	return urutan
+}
+
+func TestGetSoalUjian(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "active"
+	store.Ujian[0].Token = "ABCDE"
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 35)},
+		{ID: "peserta-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2", WaktuMulai: pukul(7, 36)},
+	}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/ujian/ujian-mtk/soal?token=abcde&siswaDetailId=siswa-1", nil)
+	status, body := doRequest(t, app, req)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+	if strings.Contains(string(body), "benar") {
+		t.Fatalf("answer key leaked to the student: %s", body)
+	}
+
+	var pertama models.SoalUjianResponse
+	doJSON(t, app, http.MethodGet, "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=siswa-1", nil, &pertama)
+	if len(pertama.Soal) != 2 || len(pertama.Soal[0].Pilihan) != 5 || pertama.WaktuPengerjaan != 120 {
+		t.Fatalf("unexpected soal %+v", pertama)
+	}
+
+	var ulang, lain models.SoalUjianResponse
+	doJSON(t, app, http.MethodGet, "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=siswa-1", nil, &ulang)
+	doJSON(t, app, http.MethodGet, "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=siswa-2", nil, &lain)
+	if !reflect.DeepEqual(urutanSoal(pertama), urutanSoal(ulang)) {
+		t.Errorf("same student got a different order: %v vs %v", urutanSoal(pertama), urutanSoal(ulang))
+	}
+	if reflect.DeepEqual(urutanSoal(pertama), urutanSoal(lain)) {
+		t.Errorf("neighbouring students got the same order %v", urutanSoal(pertama))
+	}
+}
+
+func TestGetSoalUjianDitolak(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "active"
+	store.Ujian[0].Token = "ABCDE"
+	store.Ujian[1].Token = "FGHIJ"
+	store.Peserta = []models.UjianPeserta{
+		{ID: "peserta-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2", WaktuMulai: pukul(7, 36)},
+	}
+	store.Hasil = []models.HasilDetail{{ID: "hasil-2", SiswaDetailID: "siswa-2", UjianID: "ujian-mtk"}}
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"tanpa token", "/api/ujian/ujian-mtk/soal?siswaDetailId=siswa-1", http.StatusBadRequest},
+		{"token salah", "/api/ujian/ujian-mtk/soal?token=ZZZZZ&siswaDetailId=siswa-1", http.StatusForbidden},
+		{"ujian belum aktif", "/api/ujian/ujian-bindo/soal?token=FGHIJ&siswaDetailId=siswa-1", http.StatusForbidden},
+		{"ujian tidak ada", "/api/ujian/tidak-ada/soal?token=ABCDE&siswaDetailId=siswa-1", http.StatusNotFound},
+		{"siswa tidak ada", "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=tidak-ada", http.StatusNotFound},
+		{"tingkat berbeda", "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=siswa-xi", http.StatusForbidden},
+		{"belum memulai", "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=siswa-1", http.StatusForbidden},
+		{"sudah mengumpulkan", "/api/ujian/ujian-mtk/soal?token=ABCDE&siswaDetailId=siswa-2", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := doJSON(t, app, http.MethodGet, tt.path, nil, nil); status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
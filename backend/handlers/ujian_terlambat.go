@@ -1,12 +1,12 @@
 package handlers
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
+	"backend/clock"
 	"backend/models"
 	"backend/repositories"
 	"backend/services"
@@ -36,7 +36,7 @@ type ActiveSessionInfo struct {
     SisaMenit     int
 }
 
-func AddUjianSusulan(db *sql.DB, ujianTracker *services.UjianTracker) fiber.Handler {
+func AddUjianSusulan(ujianRepo repositories.UjianRepository, ujianTracker *services.UjianTracker) fiber.Handler {
     return func(c *fiber.Ctx) error {
         var request UjianSusulanRequest
         if err := c.BodyParser(&request); err != nil {
@@ -55,7 +55,7 @@ func AddUjianSusulan(db *sql.DB, ujianTracker *services.UjianTracker) fiber.Hand
         
         // Validasi sesi aktif terlebih dahulu
         for _, item := range request.UjianIds {
-            if sessionInfo, hasActiveSoon := checkActiveSessionSoon(db, item.Tingkat); hasActiveSoon {
+            if sessionInfo, hasActiveSoon := checkActiveSessionSoon(ujianRepo, item.Tingkat, ujianTracker.Clock.Now()); hasActiveSoon {
                 return c.Status(http.StatusBadRequest).JSON(fiber.Map{
                     "success": false,
                     "message": fmt.Sprintf("Tidak dapat menambahkan ujian susulan untuk tingkat %s mata pelajaran %s karena sesi akan aktif dalam %d menit lagi (jam %s). Tunggu hingga sesi aktif terlebih dahulu.", 
@@ -67,17 +67,9 @@ func AddUjianSusulan(db *sql.DB, ujianTracker *services.UjianTracker) fiber.Hand
         var processedUjian []string
         var errors []string
         
-        tx, err := db.Begin()
-        if err != nil {
-            return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-                "success": false,
-                "message": "Failed to start transaction",
-            })
-        }
-        defer tx.Rollback()
-        
+        // Setiap ujian diproses sendiri, kegagalan satu ujian tidak membatalkan yang lain
         for _, item := range request.UjianIds {
-            err := processUjianSusulanInTx(tx, ujianTracker, item)
+            err := processUjianSusulan(ujianRepo, ujianTracker, item)
             if err != nil {
                 errors = append(errors, fmt.Sprintf("Error memproses ujian ID %s: %v", item.UjianId, err))
             } else {
@@ -86,13 +78,7 @@ func AddUjianSusulan(db *sql.DB, ujianTracker *services.UjianTracker) fiber.Hand
         }
         
         if len(processedUjian) > 0 {
-            if err := tx.Commit(); err != nil {
-                return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-                    "success": false,
-                    "message": "Failed to commit transaction",
-                })
-            }
-            
+            ujianTracker.NotifyJadwalChanged()
             ujianTracker.UpdateTrackingData()
         }
         
@@ -121,38 +107,20 @@ func AddUjianSusulan(db *sql.DB, ujianTracker *services.UjianTracker) fiber.Hand
     }
 }
 
-func checkActiveSessionSoon(db *sql.DB, tingkat string) (*ActiveSessionInfo, bool) {
-    now := time.Now()
-    
-    // Query untuk mencari sesi ujian yang akan dimulai dalam 5 menit ke depan
-    query := `
-        SELECT DISTINCT u.id, u."jamMulai", mp.pelajaran
-        FROM ujian u
-        JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp.id
-        JOIN sesi s ON u."sesiId" = s.id
-        WHERE mp.tingkat = $1 
-        AND u.status = 'pending'
-        AND u."jamMulai" IS NOT NULL
-        AND u."sesiId" IS NOT NULL
-    `
-    
-    rows, err := db.Query(query, tingkat)
+func checkActiveSessionSoon(ujianRepo repositories.UjianRepository, tingkat string, now time.Time) (*ActiveSessionInfo, bool) {
+
+    // Cari ujian pending yang akan dimulai dalam 5 menit ke depan
+    ujianList, err := ujianRepo.GetUjianPendingByTingkat(tingkat)
     if err != nil {
         log.Printf("Error checking active sessions: %v", err)
         return nil, false
     }
-    defer rows.Close()
     
-    for rows.Next() {
-        var ujianId, jamMulai, mataPelajaran string
-        err := rows.Scan(&ujianId, &jamMulai, &mataPelajaran)
-        if err != nil {
-            log.Printf("Error scanning session row: %v", err)
-            continue
-        }
+    for _, ujian := range ujianList {
+        jamMulai, mataPelajaran := ujian.JamMulai, ujian.MataPelajaran
         
         // Parse jam mulai
-        startTime, err := parseTimeString(jamMulai)
+        startTime, err := parseTimeString(now, jamMulai)
         if err != nil {
             log.Printf("Error parsing start time %s: %v", jamMulai, err)
             continue
@@ -175,9 +143,7 @@ func checkActiveSessionSoon(db *sql.DB, tingkat string) (*ActiveSessionInfo, boo
     return nil, false
 }
 
-func parseTimeString(timeStr string) (time.Time, error) {
-    now := time.Now()
-    
+func parseTimeString(now time.Time, timeStr string) (time.Time, error) {
     formats := []string{
         "15:04:05",
         "15:04",
@@ -199,20 +165,14 @@ func parseTimeString(timeStr string) (time.Time, error) {
     return time.Time{}, fmt.Errorf("unable to parse time string: %s", timeStr)
 }
 
-func processUjianSusulanInTx(tx *sql.Tx, ujianTracker *services.UjianTracker, item UjianSusulanItem) error {
+func processUjianSusulan(ujianRepo repositories.UjianRepository, ujianTracker *services.UjianTracker, item UjianSusulanItem) error {
     tingkat := models.Tingkat(item.Tingkat)
-    err := updateUjianStatusInTx(tx, item.UjianId)
+    ujianDetail, err := ujianRepo.AktifkanUjianSusulan(item.UjianId)
     if err != nil {
-        return fmt.Errorf("gagal memperbarui status ujian: %w", err)
-    }
-    ujianDetail, err := getUjianDetailInTx(tx, item.UjianId)
-    
-    if err != nil {
-        return fmt.Errorf("gagal mendapatkan detail ujian: %w", err)
+        return err
     }
     sesiId := item.SesiId
     if sesiId == "" {
-        sesiId = ""
         log.Printf("DEBUG: Using empty sesi ID for grouping")
     }
     err = ujianTracker.AddUjianSusulan(tingkat, *ujianDetail, ujianDetail.WaktuPengerjaan, sesiId)
@@ -222,75 +182,9 @@ func processUjianSusulanInTx(tx *sql.Tx, ujianTracker *services.UjianTracker, it
     return nil
 }
 
-func updateUjianStatusInTx(tx *sql.Tx, ujianId string) error {
-    query := "UPDATE ujian SET status = 'active' WHERE id = $1"
-    result, err := tx.Exec(query, ujianId)
-    if err != nil {
-        return fmt.Errorf("gagal memperbarui status ujian: %w", err)
-    }
-    
-    rowsAffected, _ := result.RowsAffected()
-    log.Printf("DEBUG: Updated %d rows for ujian ID: %s in transaction", rowsAffected, ujianId)
-    
-    if rowsAffected == 0 {
-        return fmt.Errorf("no rows affected - ujian ID might not exist: %s", ujianId)
-    }
-    
-    return nil
-}
-
-func getUjianDetailInTx(tx *sql.Tx, ujianId string) (*models.UjianData, error) {
-    log.Printf("DEBUG: Getting ujian detail for ID: %s in transaction", ujianId)
-    
-    query := `
-        SELECT u.id, u."jamMulai", u."jamSelesai", u.status, u.token, u."waktuPengerjaan",
-               mp.pelajaran
-        FROM ujian u
-        JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp.id
-        WHERE u.id = $1
-    `
-    var ujianData models.UjianData
-    var ujianJamMulai, ujianJamSelesai, token sql.NullString
-    var waktuPengerjaan sql.NullInt64
-    err := tx.QueryRow(query, ujianId).Scan(
-        &ujianData.ID,
-        &ujianJamMulai,
-        &ujianJamSelesai,
-        &ujianData.Status,
-        &token,
-        &waktuPengerjaan,
-        &ujianData.MataPelajaran,
-    )
-    if err != nil {
-        if err == sql.ErrNoRows {
-            return nil, fmt.Errorf("ujian not found with ID: %s", ujianId)
-        }
-        return nil, fmt.Errorf("gagal mendapatkan detail ujian: %w", err)
-    }
-    if ujianJamMulai.Valid {
-        ujianData.JamMulai = ujianJamMulai.String
-    }
-    if ujianJamSelesai.Valid {
-        ujianData.JamSelesai = ujianJamSelesai.String
-    }
-    if token.Valid {
-        ujianData.Token = token.String
-    }
-    if waktuPengerjaan.Valid {
-        ujianData.WaktuPengerjaan = int(waktuPengerjaan.Int64)
-    } else {
-        ujianData.WaktuPengerjaan = 90
-    }
-    ujianData.UjianBerikutnyaAda = false
-    ujianData.HitungMundurAktif = false
-    ujianData.SisaWaktuMulai = 0
-    ujianData.IsUjianSusulan = true
-    return &ujianData, nil
-}
-
-func GetUjianTerlewat(db *sql.DB) fiber.Handler {
+func GetUjianTerlewat(jadwalRepo repositories.JadwalRepository, clk clock.Clock) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ujianTerlewat, err := repositories.GetUjianTerlewat(db)
+		ujianTerlewat, err := jadwalRepo.GetUjianTerlewat(clk.Now())
 		if err != nil {
 			log.Printf("Error getting ujian terlewat: %v", err)
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
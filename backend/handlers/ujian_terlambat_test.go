@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestGetUjianTerlewat(t *testing.T) {
+	app, _ := newTestApp(t, seedStore(), pukul(14, 1))
+
+	var resp models.ResponseUjianTerlewat
+	if status := doJSON(t, app, http.MethodGet, "/api/data-ujian-terlewat", nil, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	var ujianIDs []string
+	for _, sesi := range resp.X {
+		for _, ujian := range sesi.Ujian {
+			ujianIDs = append(ujianIDs, ujian.ID)
+		}
+	}
+	if len(ujianIDs) != 2 {
+		t.Errorf("expected both ujian terlewat after reset, got %v", ujianIDs)
+	}
+}
+
+func TestAddUjianSusulan(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "selesai"
+	store.Ujian[1].Status = "selesai"
+	app, _ := newTestApp(t, store, pukul(12, 31))
+
+	request := UjianSusulanRequest{UjianIds: []UjianSusulanItem{
+		{UjianId: "ujian-mtk", Tingkat: "X", SesiId: "sesi-1"},
+		{UjianId: "tidak-ada", Tingkat: "X"},
+	}}
+	var resp UjianSusulanResponse
+	if status := doJSON(t, app, http.MethodPost, "/api/data-ujian-terlewat", request, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", status, resp)
+	}
+	if resp.Message != "Berhasil memproses 1 ujian, 1 gagal" {
+		t.Errorf("unexpected message %q", resp.Message)
+	}
+
+	ujian, err := store.GetUjian("ujian-mtk")
+	if err != nil || ujian.Status != "active" {
+		t.Fatalf("expected ujian-mtk active, got %+v (%v)", ujian, err)
+	}
+	susulan, err := store.GetUjianSusulanAktif(pukul(12, 31))
+	if err != nil || len(susulan) != 1 || susulan[0].UjianData.ID != "ujian-mtk" {
+		t.Errorf("expected persisted susulan for ujian-mtk, got %+v (%v)", susulan, err)
+	}
+}
+
+func TestAddUjianSusulanDitolakSebelumSesi(t *testing.T) {
+	store := seedStore()
+	store.Ujian[0].Status = "selesai"
+	app, _ := newTestApp(t, store, pukul(9, 57))
+
+	request := UjianSusulanRequest{UjianIds: []UjianSusulanItem{{UjianId: "ujian-mtk", Tingkat: "X", SesiId: "sesi-1"}}}
+	var resp map[string]interface{}
+	if status := doJSON(t, app, http.MethodPost, "/api/data-ujian-terlewat", request, &resp); status != http.StatusBadRequest {
+		t.Fatalf("status = %d, resp %v", status, resp)
+	}
+
+	ujian, _ := store.GetUjian("ujian-mtk")
+	if ujian.Status != "selesai" {
+		t.Errorf("rejected susulan must not change status, got %s", ujian.Status)
+	}
+}
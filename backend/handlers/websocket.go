@@ -2,11 +2,12 @@
 package handlers
 
 import (
-	"database/sql"
 	"log"
 	"sync"
 
+	"backend/clock"
 	"backend/models"
+	"backend/repositories"
 	"backend/services"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,14 +20,13 @@ var (
 	ujianRegister   = make(chan *websocket.Conn)
 	ujianUnregister = make(chan *websocket.Conn)
 	ujianBroadcast  = make(chan models.ResponseDataUjian)
-	db              *sql.DB
 	tracker *services.UjianTracker
 
 )
 
-func SetupWebSocketUjian(app *fiber.App, db *sql.DB, ujianBroadcast chan models.ResponseDataUjian) *services.UjianTracker {
+func SetupWebSocketUjian(app *fiber.App, repos repositories.Repositories, clk clock.Clock, ujianBroadcast chan models.ResponseDataUjian) *services.UjianTracker {
     // Setup tracker dengan broadcast channel yang sama
-    tracker = services.NewUjianTracker(db, ujianBroadcast)
+    tracker = services.NewUjianTracker(repos.Jadwal, repos.Ujian, clk, ujianBroadcast)
     
     // Setup websocket route
     app.Use("/ws", func(c *fiber.Ctx) error {
@@ -57,9 +57,6 @@ func SetupWebSocketUjian(app *fiber.App, db *sql.DB, ujianBroadcast chan models.
     // Start websocket handler dengan ujianBroadcast
     go handleUjianWebSocket(ujianBroadcast)
     
-    // Start tracking service
-    go tracker.StartTracking()
-    
     return tracker // RETURN tracker untuk digunakan di handler lain
 }
 
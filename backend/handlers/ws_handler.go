@@ -3,7 +3,7 @@ package handlers
 
 import (
 	"backend/models"
-	"database/sql"
+	"backend/repositories"
 	"encoding/json"
 	"log"
 	"sync"
@@ -11,15 +11,14 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
-	"github.com/google/uuid"
 )
 
 type CheatingHandler struct {
-    DB *sql.DB
+    Kecurangan repositories.KecuranganRepository
 }
 
-func NewCheatingHandler(db *sql.DB) *CheatingHandler {
-    return &CheatingHandler{DB: db}
+func NewCheatingHandler(kecuranganRepo repositories.KecuranganRepository) *CheatingHandler {
+    return &CheatingHandler{Kecurangan: kecuranganRepo}
 }
 
 
@@ -51,7 +50,7 @@ func (h *CheatingHandler) ReportCheating(c *fiber.Ctx) error {
     event.Timestamp = time.Now().UnixMilli()
 
     // Simpan ke database
-    saveCheatingEventToDB(event, h.DB)
+    saveCheatingEventToDB(event, h.Kecurangan)
 
     // Broadcast ke admin
     broadcast <- event
@@ -61,7 +60,7 @@ func (h *CheatingHandler) ReportCheating(c *fiber.Ctx) error {
 
 
 // SetupWebSocket - Setup websocket routes
-func SetupWebSocket(app *fiber.App, db *sql.DB) {
+func SetupWebSocket(app *fiber.App, kecuranganRepo repositories.KecuranganRepository, ujianHandler *UjianHandler) {
     app.Use("/ws", func(c *fiber.Ctx) error {
         if websocket.IsWebSocketUpgrade(c) {
             return c.Next()
@@ -71,13 +70,13 @@ func SetupWebSocket(app *fiber.App, db *sql.DB) {
 
     app.Get("/ws/admin", websocket.New(handleAdminConnection))
     app.Get("/ws/siswa", websocket.New(func(c *websocket.Conn) {
-        handleSiswaConnection(c, db)
+        handleSiswaConnection(c, kecuranganRepo, ujianHandler)
     }))
 
     go handleBroadcasts()
 
     app.Post("/api/kecurangan", func(c *fiber.Ctx) error {
-        return createCheatingRecord(c, db) // Kirim db ke fungsi
+        return createCheatingRecord(c, kecuranganRepo)
     })
 }
 
@@ -119,7 +118,7 @@ func handleAdminConnection(c *websocket.Conn) {
 }
 
 // handleSiswaConnection - Menangani koneksi WebSocket untuk siswa
-func handleSiswaConnection(c *websocket.Conn, db *sql.DB) {
+func handleSiswaConnection(c *websocket.Conn, kecuranganRepo repositories.KecuranganRepository, ujianHandler *UjianHandler) {
     // Ambil ID siswa dan ujian dari query params atau header
     ujianID := c.Query("ujianId")
     siswaDetailID := c.Query("siswaDetailId")
@@ -158,6 +157,16 @@ func handleSiswaConnection(c *websocket.Conn, db *sql.DB) {
         if err != nil {
             break
         }
+
+        // Autosave jawaban lewat koneksi yang sama, dibalas langsung ke siswa
+        var pesan models.SimpanJawabanRequest
+        if err := json.Unmarshal(msg, &pesan); err == nil && pesan.Type == models.PesanSimpanJawaban {
+            if err := c.WriteJSON(simpanJawabanWS(ujianHandler, ujianID, siswaDetailID, pesan)); err != nil {
+                log.Printf("Error writing to websocket: %v", err)
+                break
+            }
+            continue
+        }
         
         // Proses pesan kecurangan dan broadcast ke admin
         var cheatingEvent models.CheatingEvent
@@ -173,12 +182,37 @@ func handleSiswaConnection(c *websocket.Conn, db *sql.DB) {
                 broadcast <- cheatingEvent
                 
                 // Simpan ke database
-                saveCheatingEventToDB(cheatingEvent, db)
+                saveCheatingEventToDB(cheatingEvent, kecuranganRepo)
             }
         }
     }
 }
 
+// simpanJawabanWS - Simpan jawaban dari pesan websocket siswa, siswa diambil dari koneksi
+func simpanJawabanWS(ujianHandler *UjianHandler, ujianID, siswaDetailID string, pesan models.SimpanJawabanRequest) models.SimpanJawabanResponse {
+    if pesan.SiswaDetailID != "" && pesan.SiswaDetailID != siswaDetailID {
+        return models.SimpanJawabanResponse{
+            Type:    models.PesanJawabanGagal,
+            Message: "siswaDetailId tidak sesuai dengan koneksi",
+            SoalID:  pesan.SoalID,
+        }
+    }
+    pesan.SiswaDetailID = siswaDetailID
+
+    jawaban, fe := ujianHandler.simpanJawaban(ujianID, pesan)
+    if fe != nil {
+        return models.SimpanJawabanResponse{
+            Type:      models.PesanJawabanGagal,
+            Message:   fe.Message,
+            SoalID:    pesan.SoalID,
+            JawabanID: pesan.JawabanID,
+        }
+    }
+    resp := jawabanTersimpan(jawaban)
+    resp.Type = models.PesanJawabanTersimpan
+    return resp
+}
+
 // handleBroadcasts - Goroutine untuk broadcast pesan ke semua client admin
 func handleBroadcasts() {
     for {
@@ -202,34 +236,14 @@ func handleBroadcasts() {
 }
 
 // saveCheatingEventToDB - Simpan event kecurangan ke database
-func saveCheatingEventToDB(event models.CheatingEvent, db *sql.DB) {
-    var typeKecurangan string
-    switch event.Type {
-    case models.TabHidden:
-        typeKecurangan = "tabHidden"
-    case models.Blurred:
-        typeKecurangan = "blurred"
-    case models.SplitScreen:
-        typeKecurangan = "splitScreen"
-    case models.FloatingWindow:
-        typeKecurangan = "floatingWindow"
-    }
-
-    _, err := db.Exec(
-        "INSERT INTO kecurangan (id, \"ujianId\", \"siswaDetailId\", type) VALUES ($1, $2, $3, $4)",
-        uuid.New().String(),
-        event.UjianID,
-        event.SiswaDetailID,
-        typeKecurangan,
-    )
-
-    if err != nil {
+func saveCheatingEventToDB(event models.CheatingEvent, kecuranganRepo repositories.KecuranganRepository) {
+    if err := kecuranganRepo.SimpanKecurangan(event); err != nil {
         log.Printf("Error saving cheating event: %v", err)
     }
 }
 
 // createCheatingRecord - Endpoint REST untuk menyimpan kecurangan
-func createCheatingRecord(c *fiber.Ctx, db *sql.DB) error {
+func createCheatingRecord(c *fiber.Ctx, kecuranganRepo repositories.KecuranganRepository) error {
     var event models.CheatingEvent
 
     if err := c.BodyParser(&event); err != nil {
@@ -241,7 +255,7 @@ func createCheatingRecord(c *fiber.Ctx, db *sql.DB) error {
     event.Timestamp = time.Now().UnixMilli()
 
     // Simpan ke database
-    saveCheatingEventToDB(event, db) // Kirim db ke fungsi
+    saveCheatingEventToDB(event, kecuranganRepo)
 
     // Broadcast ke admin
     broadcast <- event
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestReportCheating(t *testing.T) {
+	store := seedStore()
+	app, _ := newTestApp(t, store, pukul(8, 0))
+
+	event := models.CheatingEvent{UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", Type: models.SplitScreen}
+	var resp models.CheatingEvent
+	if status := doJSON(t, app, http.MethodPost, "/api/kecurangan", event, &resp); status != http.StatusCreated {
+		t.Fatalf("status = %d", status)
+	}
+	if resp.Timestamp == 0 {
+		t.Errorf("expected server timestamp, got %+v", resp)
+	}
+
+	total, err := store.CountKecurangan("ujian-mtk", "siswa-1")
+	if err != nil || total != 1 {
+		t.Errorf("expected 1 kecurangan stored, got %d (%v)", total, err)
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"backend/clock"
 	"backend/config"
 	"backend/handlers"
-	"backend/models"
+	"backend/repositories"
+	"backend/services"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -23,8 +26,10 @@ func main() {
     
     defer db.Close()
 
-    // Initialize Fiber
-    app := fiber.New()
+    // Initialize Fiber, batas body dinaikkan untuk impor soal beserta ZIP gambarnya
+    app := fiber.New(fiber.Config{
+        BodyLimit: 64 * 1024 * 1024,
+    })
     
     // CORS - pindahkan sebelum route definitions
     app.Use(cors.New(cors.Config{
@@ -34,45 +39,13 @@ func main() {
         AllowCredentials: false,
     }))
 
-    // Initialize handlers
-    soalHandler := handlers.NewSoalHandler(db)
-     // Initialize handlers
-    cheatingHandler := handlers.NewCheatingHandler(db)
-    
-
-    // Tambahkan WebSocket & route
-
-    // Routes
-    app.Get("/", func(c *fiber.Ctx) error {
-        return c.JSON(fiber.Map{"message": "API bekerja!"})
-    })
-    app.Post("/api/soal", soalHandler.AddSoal)
-    app.Post("/api/kecurangan", cheatingHandler.ReportCheating)
-   // Add to your existing routes in main.go
-    ujianHandler := handlers.NewUjianHandler(db)
-   
-    app.Post("/api/ujian/submit", ujianHandler.SubmitUjian)
-    app.Get("/api/data-ujian-terlewat", handlers.GetUjianTerlewat(db))
- 
-      
-    app.Get("/api/hasil/:id", ujianHandler.GetHasilDetail)
-    app.Get("/api/ujian/download", func(c *fiber.Ctx) error {
-    return handlers.DownloadHasilUjian(c, db)
-    })
-    handlers.SetupWebSocket(app, db)
-
-   // Setup websocket dan tracker PERTAMA
-    ujianBroadcast := make(chan models.ResponseDataUjian, 10)
-    ujianTracker := handlers.SetupWebSocketUjian(app, db, ujianBroadcast)
-    
-    // Setup routes dengan tracker yang SAMA
-    app.Get("/api/data-ujian", handlers.GetUjianTrackingData(db))
-    app.Post("/api/data-ujian-terlewat", handlers.AddUjianSusulan(db, ujianTracker))
-
-
-
-
+    // Routes, websocket, dan tracker ujian
+    repos := repositories.NewPostgresRepositories(db)
+    tracker := handlers.SetupRoutes(app, repos, clock.Real{})
+    tracker.StartTracking()
 
+    // Tutup otomatis pengerjaan yang waktunya habis tanpa dikumpulkan
+    go services.NewPenutupUjian(repos, clock.Real{}).Start(time.Minute)
 
     // Start server - pindahkan ke akhir
     fmt.Println("Server starting on http://localhost:8050")
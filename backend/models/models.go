@@ -1,18 +1,58 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TipeSoal jenis soal, sama dengan enum TipeSoal di database
+const (
+	TipePilihanGanda         = "PILIHAN_GANDA"          // tepat satu jawaban benar
+	TipePilihanGandaKompleks = "PILIHAN_GANDA_KOMPLEKS" // satu atau lebih jawaban benar, siswa memilih beberapa
+	TipeIsianSingkat         = "ISIAN_SINGKAT"          // jawaban teks, pilihan berisi jawaban yang diterima
+	TipeEsai                 = "ESAI"                   // jawaban teks, dinilai guru lewat /api/hasil/:id/esai
+)
+
+// FormatSoal cara menampilkan teks soal dan pilihan, sama dengan enum FormatSoal di database
+const (
+	FormatPolos         = "PLAIN"
+	FormatMarkdown      = "MARKDOWN"
+	FormatMarkdownLatex = "MARKDOWN_LATEX" // markdown dengan rumus LaTeX di antara $...$ atau $$...$$
+)
+
+// Kesulitan tingkat kesulitan soal, sama dengan enum Kesulitan di database
+const (
+	KesulitanMudah  = "MUDAH"
+	KesulitanSedang = "SEDANG"
+	KesulitanSulit  = "SULIT"
+)
 
 type MataPelajaran struct {
-	ID        string `json:"id"`
-	Tingkat   string `json:"tingkat"`
-	Pelajaran string `json:"pelajaran"`
+	ID            string  `json:"id"`
+	Tingkat       string  `json:"tingkat"`
+	Pelajaran     string  `json:"pelajaran"`
+	PenaltiSalah  float64 `json:"penaltiSalah"`
+	NilaiParsial  bool    `json:"nilaiParsial"`
+	JumlahPilihan int     `json:"jumlahPilihan"` // jumlah pilihan soal pilihan ganda (2-6), 0 berarti default 5
 }
 
 type Soal struct {
-	ID              string  `json:"id"`
-	Gambar          *string `json:"gambar"`
-	Soal            string  `json:"soal"`
-	MataPelajaranID string  `json:"mataPelajaranId"`
+	ID              string     `json:"id"`
+	Gambar          *string    `json:"gambar"`
+	Soal            string     `json:"soal"`
+	Tipe            string     `json:"tipe"`
+	Format          string     `json:"format"`
+	Bobot           int        `json:"bobot"`
+	Topik           string     `json:"topik,omitempty"`
+	KD              string     `json:"kd,omitempty"`
+	Kesulitan       string     `json:"kesulitan,omitempty"`
+	JumlahPilihan   int        `json:"jumlahPilihan,omitempty"` // 0 berarti mengikuti mata pelajaran
+	MataPelajaranID string     `json:"mataPelajaranId"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty"` // soal yang sudah dijawab siswa hanya diarsipkan saat dihapus
 }
 
 type Jawaban struct {
@@ -23,10 +63,57 @@ type Jawaban struct {
 }
 
 type SoalInput struct {
-	ID      string    `json:"id"`
-	Soal    string    `json:"soal"`
-	Gambar  *string   `json:"gambar"`
-	Pilihan []Pilihan `json:"pilihan"`
+	ID            string    `json:"id"`
+	Soal          string    `json:"soal"`
+	Gambar        *string   `json:"gambar"`
+	Tipe          string    `json:"tipe,omitempty"`          // kosong berarti TipePilihanGanda
+	Format        string    `json:"format,omitempty"`        // kosong berarti FormatPolos, berlaku juga untuk pilihan
+	Bobot         int       `json:"bobot,omitempty"`         // 0 berarti bobot default 1
+	JumlahPilihan int       `json:"jumlahPilihan,omitempty"` // 0 berarti mengikuti mata pelajaran
+	Topik         string    `json:"topik,omitempty"`         // materi soal, dipakai untuk kuota soal ujian dan rincian hasil
+	KD            string    `json:"kd,omitempty"`            // kode kompetensi dasar, misalnya "3.2"
+	Kesulitan     string    `json:"kesulitan,omitempty"`     // MUDAH, SEDANG, atau SULIT; kosong berarti belum diisi
+	Pilihan       []Pilihan `json:"pilihan"`
+}
+
+// SoalDetail soal di bank soal beserta mata pelajarannya
+type SoalDetail struct {
+	SoalInput
+	Tingkat   string `json:"tingkat"`
+	Pelajaran string `json:"pelajaran"`
+}
+
+// SoalFilter filter daftar bank soal, Cari mencocokkan teks soal dan Halaman dimulai dari 1
+type SoalFilter struct {
+	Tingkat    string
+	Pelajaran  string
+	Cari       string
+	Halaman    int
+	PerHalaman int
+}
+
+// SoalListResponse satu halaman bank soal, Total jumlah semua soal yang cocok dengan filter
+type SoalListResponse struct {
+	Success    bool         `json:"success"`
+	Data       []SoalDetail `json:"data"`
+	Total      int          `json:"total"`
+	Halaman    int          `json:"halaman"`
+	PerHalaman int          `json:"perHalaman"`
+}
+
+// DuplikatSoal soal yang diunggah (Indeks pada soalData) yang sama atau mirip dengan soal SoalID di bank soal
+type DuplikatSoal struct {
+	Indeks    int     `json:"indeks"`
+	SoalID    string  `json:"soalId"`
+	Soal      string  `json:"soal"`
+	Kemiripan float64 `json:"kemiripan"` // 0-1, 1 untuk soal yang sama persis
+	Persis    bool    `json:"persis"`
+}
+
+// KesalahanImpor kesalahan pada satu baris file impor soal, Baris mengikuti penomoran baris di Excel
+type KesalahanImpor struct {
+	Baris int    `json:"baris"`
+	Pesan string `json:"pesan"`
 }
 
 type Pilihan struct {
@@ -35,6 +122,60 @@ type Pilihan struct {
 	Benar bool   `json:"benar"`
 }
 
+// MulaiUjianRequest data token yang dimasukkan siswa untuk memulai ujian
+type MulaiUjianRequest struct {
+	Token         string `json:"token"`
+	SiswaDetailID string `json:"siswaDetailId"`
+}
+
+// UjianPeserta catatan siswa yang sudah memulai ujian. SoalIDs soal yang dikerjakan siswa ini (hasil undian
+// atau semua soal mata pelajaran saat mulai), kosong hanya pada catatan lama sebelum soal peserta dicatat.
+// SeedSoal 0 bila ujian tidak memakai undian.
+type UjianPeserta struct {
+	ID            string    `json:"id"`
+	UjianID       string    `json:"ujianId"`
+	SiswaDetailID string    `json:"siswaDetailId"`
+	WaktuMulai    time.Time `json:"waktuMulai"`
+	SeedSoal      int64     `json:"seedSoal,omitempty"`
+	SoalIDs       []string  `json:"soalIds,omitempty"`
+}
+
+// MulaiUjianResponse respons ketika token ujian diterima
+type MulaiUjianResponse struct {
+	Success       bool      `json:"success"`
+	Message       string    `json:"message"`
+	UjianID       string    `json:"ujianId"`
+	MataPelajaran string    `json:"mataPelajaran"`
+	WaktuMulai    time.Time `json:"waktuMulai"`
+	BatasWaktu    time.Time `json:"batasWaktu"`
+	SisaDetik     int       `json:"sisaDetik"`
+}
+
+// SoalUjian soal yang dikirim ke siswa saat ujian, tanpa kunci jawaban
+type SoalUjian struct {
+	ID      string         `json:"id"`
+	Soal    string         `json:"soal"`
+	Gambar  *string        `json:"gambar"`
+	Tipe    string         `json:"tipe"`
+	Format  string         `json:"format"`
+	Pilihan []PilihanUjian `json:"pilihan"`
+}
+
+type PilihanUjian struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// SoalUjianResponse respons daftar soal untuk satu siswa
+type SoalUjianResponse struct {
+	Success         bool        `json:"success"`
+	UjianID         string      `json:"ujianId"`
+	MataPelajaran   string      `json:"mataPelajaran"`
+	WaktuPengerjaan int         `json:"waktuPengerjaan"`
+	BatasWaktu      time.Time   `json:"batasWaktu"`
+	Soal            []SoalUjian `json:"soal"`
+}
+
 type TypeKecurangan string
 
 const (
@@ -52,32 +193,259 @@ type CheatingEvent struct {
 }
 
 type JawabanSiswa struct {
-	ID            string `json:"id"`
-	SiswaDetailID string `json:"siswaDetailId"`
-	SoalID        string `json:"soalId"`
-	JawabanID     string `json:"jawabanId"`
-	UjianID       string `json:"ujianId"`
-	CreatedAt     int64  `json:"createdAt"`
+	ID            string   `json:"id"`
+	SiswaDetailID string   `json:"siswaDetailId"`
+	SoalID        string   `json:"soalId"`
+	JawabanID     string   `json:"jawabanId"`
+	JawabanIDs    []string `json:"jawabanIds,omitempty"`  // diisi untuk soal pilihan ganda kompleks, JawabanID kosong
+	JawabanTeks   string   `json:"jawabanTeks,omitempty"` // diisi untuk soal isian singkat dan esai, JawabanID kosong
+	SkorManual    *float64 `json:"skorManual,omitempty"`  // skor esai dari guru (0 sampai bobot soal), nil bila belum dinilai
+	UjianID       string   `json:"ujianId"`
+	CreatedAt     int64    `json:"createdAt"`
+}
+
+// Dipilih daftar jawabanId yang dipilih siswa untuk soal ini
+func (js JawabanSiswa) Dipilih() JawabanPilihan {
+	if len(js.JawabanIDs) > 0 {
+		return js.JawabanIDs
+	}
+	if js.JawabanID == "" {
+		return nil
+	}
+	return JawabanPilihan{js.JawabanID}
+}
+
+// JawabanPilihan jawaban siswa untuk satu soal. Di JSON boleh berupa satu jawabanId (pilihan ganda)
+// atau daftar jawabanId (pilihan ganda kompleks).
+type JawabanPilihan []string
+
+func (j *JawabanPilihan) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*j = nil
+		return nil
+	}
+	var satu string
+	if err := json.Unmarshal(data, &satu); err == nil {
+		*j = JawabanPilihan{satu}
+		return nil
+	}
+	var banyak []string
+	if err := json.Unmarshal(data, &banyak); err != nil {
+		return errors.New("jawaban harus berupa jawabanId atau daftar jawabanId")
+	}
+	*j = banyak
+	return nil
+}
+
+// PesanSimpanJawaban tipe pesan websocket /ws/siswa untuk menyimpan satu jawaban,
+// dibalas dengan PesanJawabanTersimpan atau PesanJawabanGagal
+const (
+	PesanSimpanJawaban    = "SIMPAN_JAWABAN"
+	PesanJawabanTersimpan = "JAWABAN_TERSIMPAN"
+	PesanJawabanGagal     = "JAWABAN_GAGAL"
+)
+
+// SimpanJawabanRequest satu jawaban yang dipilih siswa selama ujian (autosave)
+type SimpanJawabanRequest struct {
+	Type          string   `json:"type,omitempty"` // hanya dipakai lewat websocket
+	SiswaDetailID string   `json:"siswaDetailId"`
+	SoalID        string   `json:"soalId"`
+	JawabanID     string   `json:"jawabanId"`
+	JawabanIDs    []string `json:"jawabanIds,omitempty"`  // untuk soal pilihan ganda kompleks
+	JawabanTeks   string   `json:"jawabanTeks,omitempty"` // untuk soal isian singkat dan esai
+}
+
+// SimpanJawabanResponse respons autosave jawaban
+type SimpanJawabanResponse struct {
+	Type         string    `json:"type,omitempty"` // hanya dipakai lewat websocket
+	Success      bool      `json:"success"`
+	Message      string    `json:"message"`
+	SoalID       string    `json:"soalId"`
+	JawabanID    string    `json:"jawabanId"`
+	JawabanIDs   []string  `json:"jawabanIds,omitempty"`
+	JawabanTeks  string    `json:"jawabanTeks,omitempty"`
+	DisimpanPada time.Time `json:"disimpanPada"`
 }
 
 // SubmitUjianRequest struktur untuk menerima data dari client
 type SubmitUjianRequest struct {
-	UjianID         string            `json:"ujianId"`
-	SiswaDetailID   string            `json:"siswaDetailId"`
-	Answers         map[string]string `json:"answers"` // key: soalId, value: jawabanId
-	WaktuPengerjaan int               `json:"waktuPengerjaan"`
+	UjianID         string                    `json:"ujianId"`
+	SiswaDetailID   string                    `json:"siswaDetailId"`
+	Answers         map[string]JawabanPilihan `json:"answers"`         // key: soalId, value: jawabanId atau daftar jawabanId; boleh kosong bila semua jawaban sudah tersimpan lewat autosave
+	WaktuPengerjaan int                       `json:"waktuPengerjaan"` // diabaikan, durasi dihitung server dari waktu mulai
+	JawabanTeks     map[string]string         `json:"jawabanTeks"`     // key: soalId, value: jawaban soal isian singkat dan esai
+	IdempotencyKey  string                    `json:"idempotencyKey"`  // boleh juga lewat header Idempotency-Key
 }
 
 // SubmitUjianResponse struktur untuk respons ke client
 type SubmitUjianResponse struct {
-	Success         bool   `json:"success"`
-	Message         string `json:"message"`
-	HasilID         string `json:"hasilId"`
-	Nilai           int    `json:"nilai"`
-	Benar           int    `json:"benar"`
-	Salah           int    `json:"salah"`
-	TotalKecurangan int    `json:"totalKecurangan"`
-	WaktuPengerjaan int    `json:"waktuPengerjaan"`
+	Success         bool          `json:"success"`
+	Message         string        `json:"message"`
+	HasilID         string        `json:"hasilId"`
+	Nilai           int           `json:"nilai"`
+	Benar           int           `json:"benar"`
+	Salah           int           `json:"salah"`
+	Kosong          int           `json:"kosong"`
+	Rincian         *RincianNilai `json:"rincian,omitempty"`
+	TotalKecurangan int           `json:"totalKecurangan"`
+	WaktuPengerjaan int           `json:"waktuPengerjaan"`
+}
+
+// RincianNilai rincian perhitungan nilai berbobot. Nilai = Skor / TotalBobot * 100,
+// dengan Skor = BobotBenar + SkorSebagian + SkorEsai - PenaltiSalah * BobotSalah dan tidak pernah di bawah 0.
+// Esai yang belum dinilai guru bernilai 0 sampai nilainya diisi lewat penilaian esai.
+type RincianNilai struct {
+	TotalBobot       int     `json:"totalBobot"`
+	BobotBenar       int     `json:"bobotBenar"`
+	BobotSebagian    int     `json:"bobotSebagian"` // soal pilihan ganda kompleks yang benar sebagian
+	BobotSalah       int     `json:"bobotSalah"`
+	BobotEsai        int     `json:"bobotEsai"` // soal esai yang dijawab, sudah maupun belum dinilai
+	BobotKosong      int     `json:"bobotKosong"`
+	PenaltiSalah     float64 `json:"penaltiSalah"` // bagian bobot soal yang dikurangkan untuk jawaban salah
+	NilaiParsial     bool    `json:"nilaiParsial"`
+	SkorSebagian     float64 `json:"skorSebagian"`
+	SkorEsai         float64 `json:"skorEsai"`
+	EsaiBelumDinilai int     `json:"esaiBelumDinilai"`
+	Pengurangan      float64 `json:"pengurangan"`
+	Skor             float64 `json:"skor"`
+}
+
+// RincianTopik jumlah soal benar seorang siswa untuk satu kompetensi dasar/topik. Soal benar sebagian
+// dihitung salah seperti pada HasilDetail, Total jumlah soal dengan KD dan topik ini yang dikerjakan siswa.
+type RincianTopik struct {
+	KD           string `json:"kd,omitempty"`
+	Topik        string `json:"topik,omitempty"`
+	Benar        int    `json:"benar"`
+	Salah        int    `json:"salah"`
+	Kosong       int    `json:"kosong"`
+	BelumDinilai int    `json:"belumDinilai,omitempty"`
+	Total        int    `json:"total"`
+}
+
+// SoalLembarJawaban satu soal di lembar jawaban hasil ujian, urutan soal dan pilihan sama seperti yang dilihat siswa.
+// Benar pada pilihan dan KunciTeks hanya diisi bila kunci jawaban ditampilkan.
+type SoalLembarJawaban struct {
+	Nomor       int                    `json:"nomor"`
+	SoalID      string                 `json:"soalId"`
+	Soal        string                 `json:"soal"`
+	Gambar      *string                `json:"gambar"`
+	Tipe        string                 `json:"tipe"`
+	Format      string                 `json:"format"`
+	Bobot       int                    `json:"bobot"`
+	Pilihan     []PilihanLembarJawaban `json:"pilihan"`
+	JawabanTeks string                 `json:"jawabanTeks,omitempty"`
+	KunciTeks   []string               `json:"kunciTeks,omitempty"` // jawaban isian singkat yang diterima
+	Status      string                 `json:"status"`              // benar, sebagian, salah, kosong, atau belum_dinilai
+	Skor        float64                `json:"skor"`                // bagian bobot yang didapat, tanpa pengurangan jawaban salah
+}
+
+// PilihanLembarJawaban pilihan jawaban di lembar jawaban beserta tanda yang dipilih siswa
+type PilihanLembarJawaban struct {
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	Dipilih bool   `json:"dipilih"`
+	Benar   *bool  `json:"benar,omitempty"`
+}
+
+// AnalisisUjian analisis butir soal satu ujian dari hasil yang sudah dikumpulkan. KR20 nil bila reliabilitas
+// tidak bisa dihitung (kurang dari 2 peserta/soal, skor total seragam, atau siswa mendapat soal undian berbeda).
+type AnalisisUjian struct {
+	UjianID       string         `json:"ujianId"`
+	MataPelajaran string         `json:"mataPelajaran"`
+	Tingkat       string         `json:"tingkat"`
+	JumlahPeserta int            `json:"jumlahPeserta"`
+	RataRata      float64        `json:"rataRata"` // rata-rata skor mentah peserta (0-100), tanpa bobot dan penalti
+	KR20          *float64       `json:"kr20"`
+	Soal          []AnalisisSoal `json:"soal"`
+}
+
+// AnalisisSoal statistik satu butir soal. TingkatKesukaran adalah p-value (rata-rata bagian skor yang didapat,
+// 0-1), DayaBeda selisih p-value kelompok atas dan bawah (27% peserta dengan skor tertinggi dan terendah),
+// dan KorelasiBiserial korelasi point-biserial skor soal dengan skor total. Nilai nil berarti tidak bisa dihitung.
+type AnalisisSoal struct {
+	Nomor             int               `json:"nomor"`
+	SoalID            string            `json:"soalId"`
+	Soal              string            `json:"soal"`
+	Tipe              string            `json:"tipe"`
+	Format            string            `json:"format,omitempty"`
+	Topik             string            `json:"topik,omitempty"`
+	KD                string            `json:"kd,omitempty"`
+	JumlahPeserta     int               `json:"jumlahPeserta"` // peserta yang mendapat soal ini dan jawabannya sudah dinilai
+	Kosong            int               `json:"kosong"`
+	BelumDinilai      int               `json:"belumDinilai,omitempty"`
+	TingkatKesukaran  *float64          `json:"tingkatKesukaran"`
+	KategoriKesukaran string            `json:"kategoriKesukaran,omitempty"`
+	DayaBeda          *float64          `json:"dayaBeda"`
+	KorelasiBiserial  *float64          `json:"korelasiBiserial"`
+	KategoriDayaBeda  string            `json:"kategoriDayaBeda,omitempty"`
+	Pilihan           []AnalisisPilihan `json:"pilihan,omitempty"` // hanya soal pilihan ganda
+}
+
+// AnalisisPilihan sebaran jawaban satu pilihan. Pengecoh (pilihan salah) dianggap berfungsi bila dipilih
+// paling sedikit 5% peserta dan tidak lebih banyak dipilih kelompok atas daripada kelompok bawah.
+type AnalisisPilihan struct {
+	JawabanID    string  `json:"jawabanId"`
+	Huruf        string  `json:"huruf"`
+	Teks         string  `json:"teks"`
+	Benar        bool    `json:"benar"`
+	Dipilih      int     `json:"dipilih"`
+	Proporsi     float64 `json:"proporsi"`
+	DipilihAtas  int     `json:"dipilihAtas"`
+	DipilihBawah int     `json:"dipilihBawah"`
+	Berfungsi    bool    `json:"berfungsi"`
+}
+
+// SebaranPilihan ringkasan sebaran jawaban untuk laporan, misalnya "A*: 12 (40%); B: 3 (10%)".
+// Tanda * menandai pilihan benar, ! pengecoh yang tidak berfungsi.
+func (a AnalisisSoal) SebaranPilihan() string {
+	bagian := make([]string, 0, len(a.Pilihan))
+	for _, p := range a.Pilihan {
+		tanda := ""
+		switch {
+		case p.Benar:
+			tanda = "*"
+		case !p.Berfungsi:
+			tanda = "!"
+		}
+		bagian = append(bagian, fmt.Sprintf("%s%s: %d (%.0f%%)", p.Huruf, tanda, p.Dipilih, p.Proporsi*100))
+	}
+	return strings.Join(bagian, "; ")
+}
+
+// AngkaAnalisis statistik analisis dengan tiga desimal, "-" bila tidak bisa dihitung. Dipakai laporan
+// XLSX dan PDF supaya pembulatannya sama dengan nilai yang sudah dibulatkan di JSON.
+func AngkaAnalisis(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*v, 'f', 3, 64)
+}
+
+// JawabanEsai jawaban esai seorang siswa yang ditampilkan ke guru untuk dinilai
+type JawabanEsai struct {
+	SoalID      string   `json:"soalId"`
+	Soal        string   `json:"soal"`
+	Bobot       int      `json:"bobot"`
+	JawabanTeks string   `json:"jawabanTeks"`
+	SkorManual  *float64 `json:"skorManual"`
+}
+
+// JawabanEsaiResponse daftar jawaban esai satu hasil ujian
+type JawabanEsaiResponse struct {
+	Success bool          `json:"success"`
+	HasilID string        `json:"hasilId"`
+	Esai    []JawabanEsai `json:"esai"`
+}
+
+// NilaiEsaiRequest skor yang diberikan guru untuk jawaban esai satu hasil ujian
+type NilaiEsaiRequest struct {
+	Nilai []NilaiEsai `json:"nilai"`
+}
+
+// NilaiEsai skor satu soal esai, antara 0 dan bobot soal
+type NilaiEsai struct {
+	SoalID string  `json:"soalId"`
+	Skor   float64 `json:"skor"`
 }
 
 // CheatingCount menyimpan jumlah kecurangan berdasarkan tipe
@@ -100,41 +468,118 @@ type HasilDetail struct {
 	Nilai           int            `json:"nilai"`
 	Benar           int            `json:"benar"`
 	Salah           int            `json:"salah"`
-	TotalKecurangan int            `json:"totalKecurangan"`
-	Kecurangan      CheatingDetail `json:"kecurangan"`
-	CreatedAt       int64          `json:"createdAt"`
-	MataPelajaran   string         `json:"mataPelajaran"`
-	Tingkat         string         `json:"tingkat"` // Tambahkan tingkat
+	Kosong          int            `json:"kosong"` // soal yang tidak dijawab
+	Rincian         *RincianNilai  `json:"rincian,omitempty"`
+	RincianTopik    []RincianTopik `json:"rincianTopik,omitempty"` // hanya diisi GetHasilDetail bila soal ujian diberi topik/KD
+	// LembarJawaban hanya diisi GetHasilDetail dengan ?detail=true, KunciDitampilkan mengikuti pengaturan ujian
+	LembarJawaban    []SoalLembarJawaban `json:"lembarJawaban,omitempty"`
+	KunciDitampilkan bool                `json:"kunciDitampilkan,omitempty"`
+	TotalKecurangan  int                 `json:"totalKecurangan"`
+	Kecurangan       CheatingDetail      `json:"kecurangan"`
+	CreatedAt        int64               `json:"createdAt"`
+	MataPelajaran    string              `json:"mataPelajaran"`
+	Tingkat          string              `json:"tingkat"` // Tambahkan tingkat
+	IdempotencyKey   string              `json:"-"`
+	JawabanHash      string              `json:"-"`                         // sha256 himpunan jawaban yang dinilai, untuk mengenali pengumpulan ulang
+	DitutupOtomatis  bool                `json:"ditutupOtomatis,omitempty"` // disimpan PenutupUjian, bukan submit siswa
 }
 
 // Model Kelas
 type Kelas struct {
-	ID   string `json:"id"`
-	Nama string `json:"nama"`
+	ID      string `json:"id"`
+	Nama    string `json:"nama"`
+	Tingkat string `json:"tingkat"`
+	Jurusan string `json:"jurusan"`
 }
 
 // Model SiswaDetail untuk menyimpan informasi siswa
 type SiswaDetail struct {
-	ID      string `json:"id"`
-	Nama    string `json:"nama"`
-	NIS     string `json:"nis"`
-	KelasID string `json:"kelasId"`
+	ID         string `json:"id"`
+	Nama       string `json:"nama"`
+	NIS        string `json:"nis"`
+	KelasID    string `json:"kelasId"`
+	Kelamin    string `json:"kelamin"`
+	NomorUjian string `json:"nomorUjian"`
+	Kelas      Kelas  `json:"kelas"`
+}
+
+// Model Ujian beserta mata pelajarannya
+type Ujian struct {
+	ID              string        `json:"id"`
+	WaktuPengerjaan int           `json:"waktuPengerjaan"`
+	Token           string        `json:"token,omitempty"`
+	Status          string        `json:"status"`
+	SesiID          string        `json:"sesiId,omitempty"`
+	PenaltiSalah    float64       `json:"penaltiSalah"` // dari ujian, atau dari mata pelajaran bila ujian tidak mengaturnya
+	NilaiParsial    bool          `json:"nilaiParsial"` // nilai sebagian untuk pilihan ganda kompleks, aturannya sama seperti PenaltiSalah
+	JumlahSoal      int           `json:"jumlahSoal"`   // jumlah soal yang diundi per siswa, 0 berarti semua soal
+	KuotaSoal       []KuotaSoal   `json:"kuotaSoal"`
+	TampilkanKunci  bool          `json:"tampilkanKunci"` // lembar jawaban hasil menampilkan jawaban yang benar
+	MataPelajaran   MataPelajaran `json:"mataPelajaran"`
+}
+
+// KuotaSoal jumlah soal minimal yang diundi dari soal dengan topik dan/atau kesulitan tertentu.
+// Field yang kosong tidak membatasi, misalnya {Kesulitan: "SULIT", Jumlah: 5} berarti 5 soal sulit dari topik apa saja.
+type KuotaSoal struct {
+	Topik     string `json:"topik,omitempty"`
+	Kesulitan string `json:"kesulitan,omitempty"`
+	Jumlah    int    `json:"jumlah"`
+}
+
+// TampilkanKunciRequest mengatur apakah siswa boleh melihat kunci jawaban di lembar jawaban hasilnya
+type TampilkanKunciRequest struct {
+	TampilkanKunci *bool `json:"tampilkanKunci"`
+}
+
+// PengaturanPenilaianRequest pengaturan penilaian satu ujian, nilai null berarti mengikuti mata pelajaran
+type PengaturanPenilaianRequest struct {
+	PenaltiSalah *float64 `json:"penaltiSalah"`
+	NilaiParsial *bool    `json:"nilaiParsial"`
+}
+
+// PengaturanMataPelajaranRequest mengubah pengaturan satu mata pelajaran, field yang tidak dikirim tidak berubah
+type PengaturanMataPelajaranRequest struct {
+	Tingkat       string   `json:"tingkat"`
+	Pelajaran     string   `json:"pelajaran"`
+	PenaltiSalah  *float64 `json:"penaltiSalah"`
+	NilaiParsial  *bool    `json:"nilaiParsial"`
+	JumlahPilihan *int     `json:"jumlahPilihan"`
+}
+
+// PengaturanSoalRequest pengaturan undian soal satu ujian
+type PengaturanSoalRequest struct {
+	JumlahSoal int         `json:"jumlahSoal"`
+	KuotaSoal  []KuotaSoal `json:"kuotaSoal"`
 }
 
 // Model HasilUjian untuk menyimpan hasil ujian siswa
 
 type HasilUjianDetail struct {
-	ID              string `db:"id"`
-	SiswaNama       string
-	Kelas           string
-	Tingkat         string
-	MataPelajaran   string
-	Nilai           string
-	Benar           string
-	Salah           string
-	WaktuPengerjaan string
-	NIS             string
-	TotalKecurangan int
+	ID              string    `db:"id" json:"id"`
+	UjianID         string    `json:"ujianId"`
+	SiswaNama       string    `json:"siswaNama"`
+	Kelas           string    `json:"kelas"`
+	Tingkat         string    `json:"tingkat"`
+	MataPelajaran   string    `json:"mataPelajaran"`
+	Nilai           string    `json:"nilai"`
+	Benar           string    `json:"benar"`
+	Salah           string    `json:"salah"`
+	Kosong          string    `json:"kosong"`
+	WaktuPengerjaan string    `json:"waktuPengerjaan"` // detik
+	NIS             string    `json:"nis"`
+	TotalKecurangan int       `json:"totalKecurangan"`
+	CreatedAt       time.Time `json:"createdAt"` // waktu hasil dikumpulkan
+}
+
+// HasilFilter filter unduhan hasil ujian, field kosong tidak membatasi. Kelas boleh berupa nama kelas
+// seperti X-RPL atau jurusannya saja. Dari inklusif dan Sampai eksklusif.
+type HasilFilter struct {
+	Tingkat   string
+	Pelajaran string
+	Kelas     string
+	UjianID   string
+	Dari      time.Time
+	Sampai    time.Time
 }
 
 type ResponseDataUjian struct {
@@ -3,14 +3,53 @@ package repositories
 import (
 	"backend/models"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
-func GetHasilUjian(db *sql.DB) ([]models.HasilUjianDetail, error) {
+// GetHasilUjian mengambil hasil ujian yang cocok dengan filter, urut per tingkat, mata pelajaran, kelas, lalu nama siswa
+func GetHasilUjian(db *sql.DB, filter models.HasilFilter) ([]models.HasilUjianDetail, error) {
+	var where []string
+	var args []interface{}
+	if filter.Tingkat != "" {
+		args = append(args, filter.Tingkat)
+		where = append(where, fmt.Sprintf("mp.tingkat = $%d", len(args)))
+	}
+	if filter.Pelajaran != "" {
+		args = append(args, filter.Pelajaran)
+		where = append(where, fmt.Sprintf("mp.pelajaran = $%d", len(args)))
+	}
+	if filter.Kelas != "" {
+		args = append(args, filter.Kelas)
+		where = append(where, fmt.Sprintf(`(k.jurusan = $%d OR k.tingkat::text || COALESCE('-' || NULLIF(k.jurusan, ''), '') = $%[1]d)`, len(args)))
+	}
+	if filter.UjianID != "" {
+		args = append(args, filter.UjianID)
+		where = append(where, fmt.Sprintf(`h."ujianId" = $%d`, len(args)))
+	}
+	if !filter.Dari.IsZero() {
+		args = append(args, filter.Dari)
+		where = append(where, fmt.Sprintf(`h."createdAt" >= $%d`, len(args)))
+	}
+	if !filter.Sampai.IsZero() {
+		args = append(args, filter.Sampai)
+		where = append(where, fmt.Sprintf(`h."createdAt" < $%d`, len(args)))
+	}
+	kondisi := ""
+	if len(where) > 0 {
+		kondisi = "WHERE " + strings.Join(where, " AND ")
+	}
+
 	query := `
 	SELECT 
 		h."id", 
+		h."ujianId",
 		sd."name" AS siswa_nama, -- Pastikan pakai "name"
 		k."tingkat" AS kelas_tingkat, 
 		k."jurusan" AS kelas_jurusan, 
@@ -18,17 +57,21 @@ func GetHasilUjian(db *sql.DB) ([]models.HasilUjianDetail, error) {
 		h."nilai", 
 		h."benar", 
 		h."salah", 
+		h."kosong", 
 		h."waktuPengerjaan", 
 		sd."nis", 
-		(SELECT COUNT(*) FROM kecurangan kc WHERE kc."ujianId" = h."ujianId" AND kc."siswaDetailId" = h."siswaDetailId") as totalKecurangan
+		(SELECT COUNT(*) FROM kecurangan kc WHERE kc."ujianId" = h."ujianId" AND kc."siswaDetailId" = h."siswaDetailId") as totalKecurangan,
+		h."createdAt"
 	FROM hasil h
 	JOIN siswa_detail sd ON h."siswaDetailId" = sd."id"
 	JOIN kelas k ON sd."kelasId" = k."id"
 	JOIN ujian u ON h."ujianId" = u."id"
-	JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp."id";
+	JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp."id"
+	` + kondisi + `
+	ORDER BY mp."tingkat", mp."pelajaran", k."jurusan", sd."name";
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		log.Println("Error Query Database:", err)
 		return nil, err
@@ -40,27 +83,237 @@ func GetHasilUjian(db *sql.DB) ([]models.HasilUjianDetail, error) {
 	for rows.Next() {
     var h models.HasilUjianDetail
     var tingkatRaw string
-    var jurusanRaw string
+    var jurusan sql.NullString
 
     if err := rows.Scan(
-        &h.ID, &h.SiswaNama, &tingkatRaw, &jurusanRaw,
-        &h.MataPelajaran, &h.Nilai, &h.Benar, &h.Salah,
-        &h.WaktuPengerjaan, &h.NIS, &h.TotalKecurangan,
+        &h.ID, &h.UjianID, &h.SiswaNama, &tingkatRaw, &jurusan,
+        &h.MataPelajaran, &h.Nilai, &h.Benar, &h.Salah, &h.Kosong,
+        &h.WaktuPengerjaan, &h.NIS, &h.TotalKecurangan, &h.CreatedAt,
     ); err != nil {
-        log.Println("Error scanning:", err)
-        continue
+        return nil, fmt.Errorf("error scanning hasil ujian: %w", err)
     }
 
-    // Simpan tingkat dan kelas
+    // Simpan tingkat dan kelas, kelas tanpa jurusan cukup memakai tingkatnya
     h.Tingkat = tingkatRaw
-    h.Kelas = fmt.Sprintf("%s-%s", tingkatRaw, jurusanRaw)
-
-    // Debugging log
-    log.Println("Data masuk:", h)
+    h.Kelas = namaKelas(tingkatRaw, jurusan.String)
+    h.CreatedAt = h.CreatedAt.In(time.Local)
 
     hasil = append(hasil, h)
 }
-
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hasil ujian: %w", err)
+	}
 
 	return hasil, nil
 }
+
+type postgresHasilRepository struct {
+	db *sql.DB
+}
+
+func (r *postgresHasilRepository) ListHasilUjian(filter models.HasilFilter) ([]models.HasilUjianDetail, error) {
+	return GetHasilUjian(r.db, filter)
+}
+
+// SimpanHasil menyimpan jawaban siswa dan hasil ujian dalam satu transaksi.
+// Jawaban yang sudah tersimpan lewat autosave untuk soal yang sama ditimpa.
+func (r *postgresHasilRepository) SimpanHasil(hasil models.HasilDetail, jawaban []models.JawabanSiswa) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, js := range jawaban {
+		_, err := tx.Exec(upsertJawabanSiswa, argsJawabanSiswa(js)...)
+		if err != nil {
+			return fmt.Errorf("error saving answer for soal %s: %w", js.SoalID, err)
+		}
+	}
+
+	rincian, err := rincianNilaiJSON(hasil.Rincian)
+	if err != nil {
+		return err
+	}
+
+	// Kolom hasil bertipe string mengikuti schema Prisma
+	_, err = tx.Exec(
+		`INSERT INTO hasil 
+		("id", "siswaDetailId", "ujianId", "waktuPengerjaan", "nilai", "benar", "salah", "kosong", "idempotencyKey", "jawabanHash", "rincianNilai", "ditutupOtomatis") 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		hasil.ID, hasil.SiswaDetailID, hasil.UjianID,
+		strconv.Itoa(hasil.WaktuPengerjaan), strconv.Itoa(hasil.Nilai), strconv.Itoa(hasil.Benar), strconv.Itoa(hasil.Salah),
+		strconv.Itoa(hasil.Kosong),
+		nullString(hasil.IdempotencyKey), nullString(hasil.JawabanHash), rincian, hasil.DitutupOtomatis,
+	)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrHasilSudahAda
+	}
+	if err != nil {
+		return fmt.Errorf("error saving hasil: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// SimpanPenilaianManual menyimpan skor esai dari guru dan nilai hasil yang dihitung ulang dalam satu transaksi
+func (r *postgresHasilRepository) SimpanPenilaianManual(hasil models.HasilDetail, skor map[string]float64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for soalID, s := range skor {
+		_, err := tx.Exec(
+			`UPDATE jawaban_siswa SET "skorManual" = $1
+			 WHERE "ujianId" = $2 AND "siswaDetailId" = $3 AND "soalId" = $4`,
+			s, hasil.UjianID, hasil.SiswaDetailID, soalID,
+		)
+		if err != nil {
+			return fmt.Errorf("error saving score for soal %s: %w", soalID, err)
+		}
+	}
+
+	rincian, err := rincianNilaiJSON(hasil.Rincian)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`UPDATE hasil SET "nilai" = $1, "benar" = $2, "salah" = $3, "kosong" = $4, "rincianNilai" = $5
+		 WHERE "id" = $6`,
+		strconv.Itoa(hasil.Nilai), strconv.Itoa(hasil.Benar), strconv.Itoa(hasil.Salah), strconv.Itoa(hasil.Kosong),
+		rincian, hasil.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating hasil: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// GetHasilDetail mengambil hasil ujian beserta mata pelajaran dan jumlah kecurangannya
+func (r *postgresHasilRepository) GetHasilDetail(hasilID string) (*models.HasilDetail, error) {
+	var hasil models.HasilDetail
+	var createdAtFloat float64
+	var rincian []byte
+	err := r.db.QueryRow(
+		`SELECT h."id", h."siswaDetailId", h."ujianId", h."waktuPengerjaan", h."nilai", h."benar", h."salah", h."kosong", h."rincianNilai",
+		        (SELECT COUNT(*) FROM kecurangan WHERE "ujianId" = h."ujianId" AND "siswaDetailId" = h."siswaDetailId") as totalKecurangan,
+		        EXTRACT(EPOCH FROM h."createdAt") as createdAt,
+		        mp."pelajaran", mp."tingkat"
+		 FROM hasil h
+		 JOIN ujian u ON h."ujianId" = u."id"
+		 JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp."id"
+		 WHERE h."id" = $1`,
+		hasilID,
+	).Scan(
+		&hasil.ID, &hasil.SiswaDetailID, &hasil.UjianID,
+		&hasil.WaktuPengerjaan, &hasil.Nilai, &hasil.Benar, &hasil.Salah, &hasil.Kosong, &rincian,
+		&hasil.TotalKecurangan, &createdAtFloat,
+		&hasil.MataPelajaran, &hasil.Tingkat,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying hasil: %w", err)
+	}
+
+	hasil.CreatedAt = int64(createdAtFloat)
+	if hasil.Rincian, err = scanRincianNilai(rincian); err != nil {
+		return nil, err
+	}
+	return &hasil, nil
+}
+
+// GetHasilSiswa mengambil hasil satu siswa untuk satu ujian, ErrNotFound bila belum mengumpulkan
+func (r *postgresHasilRepository) GetHasilSiswa(ujianID, siswaDetailID string) (*models.HasilDetail, error) {
+	var hasil models.HasilDetail
+	var rincian []byte
+	err := r.db.QueryRow(
+		`SELECT "id", "siswaDetailId", "ujianId", "waktuPengerjaan", "nilai", "benar", "salah", "kosong", "rincianNilai",
+		        COALESCE("idempotencyKey", ''), COALESCE("jawabanHash", ''), "ditutupOtomatis"
+		 FROM hasil
+		 WHERE "ujianId" = $1 AND "siswaDetailId" = $2`,
+		ujianID, siswaDetailID,
+	).Scan(
+		&hasil.ID, &hasil.SiswaDetailID, &hasil.UjianID,
+		&hasil.WaktuPengerjaan, &hasil.Nilai, &hasil.Benar, &hasil.Salah, &hasil.Kosong, &rincian,
+		&hasil.IdempotencyKey, &hasil.JawabanHash, &hasil.DitutupOtomatis,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying hasil: %w", err)
+	}
+	if hasil.Rincian, err = scanRincianNilai(rincian); err != nil {
+		return nil, err
+	}
+	return &hasil, nil
+}
+
+// ListHasilPerUjian mengambil hasil semua siswa yang sudah mengumpulkan satu ujian, urut menurut waktu kumpul
+func (r *postgresHasilRepository) ListHasilPerUjian(ujianID string) ([]models.HasilDetail, error) {
+	rows, err := r.db.Query(
+		`SELECT "id", "siswaDetailId", "ujianId", "waktuPengerjaan", "nilai", "benar", "salah", "kosong", "rincianNilai"
+		 FROM hasil
+		 WHERE "ujianId" = $1
+		 ORDER BY "createdAt", "id"`,
+		ujianID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying hasil ujian: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.HasilDetail
+	for rows.Next() {
+		var hasil models.HasilDetail
+		var rincian []byte
+		if err := rows.Scan(
+			&hasil.ID, &hasil.SiswaDetailID, &hasil.UjianID,
+			&hasil.WaktuPengerjaan, &hasil.Nilai, &hasil.Benar, &hasil.Salah, &hasil.Kosong, &rincian,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning hasil ujian: %w", err)
+		}
+		if hasil.Rincian, err = scanRincianNilai(rincian); err != nil {
+			return nil, err
+		}
+		result = append(result, hasil)
+	}
+	return result, rows.Err()
+}
+
+// nullString menyimpan string kosong sebagai NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// rincianNilaiJSON menyimpan rincian nilai sebagai jsonb, NULL bila tidak ada
+func rincianNilaiJSON(rincian *models.RincianNilai) (interface{}, error) {
+	if rincian == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(rincian)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding rincian nilai: %w", err)
+	}
+	return string(data), nil
+}
+
+// scanRincianNilai membaca kolom rincianNilai, hasil lama sebelum penilaian berbobot bernilai NULL
+func scanRincianNilai(data []byte) (*models.RincianNilai, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var rincian models.RincianNilai
+	if err := json.Unmarshal(data, &rincian); err != nil {
+		return nil, fmt.Errorf("error decoding rincian nilai: %w", err)
+	}
+	return &rincian, nil
+}
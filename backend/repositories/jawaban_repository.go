@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"backend/models"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// upsertJawabanSiswa menyimpan satu jawaban, menimpa jawaban sebelumnya untuk soal yang sama.
+// Skor esai ikut ditimpa karena jawaban yang berubah harus dinilai ulang.
+const upsertJawabanSiswa = `
+	INSERT INTO jawaban_siswa ("id", "siswaDetailId", "ujianId", "soalId", "jawabanId", "jawabanIds", "jawabanTeks", "skorManual", "createdAt")
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT ("siswaDetailId", "ujianId", "soalId")
+	DO UPDATE SET "jawabanId" = EXCLUDED."jawabanId", "jawabanIds" = EXCLUDED."jawabanIds", "jawabanTeks" = EXCLUDED."jawabanTeks",
+	              "skorManual" = EXCLUDED."skorManual", "createdAt" = EXCLUDED."createdAt"`
+
+// argsJawabanSiswa argumen upsertJawabanSiswa untuk satu jawaban
+func argsJawabanSiswa(js models.JawabanSiswa) []interface{} {
+	jawabanIDs := js.JawabanIDs
+	if jawabanIDs == nil {
+		jawabanIDs = []string{}
+	}
+	return []interface{}{
+		js.ID, js.SiswaDetailID, js.UjianID, js.SoalID, js.JawabanID, pq.Array(jawabanIDs),
+		nullString(js.JawabanTeks), js.SkorManual, time.UnixMilli(js.CreatedAt).UTC(),
+	}
+}
+
+type postgresJawabanRepository struct {
+	db *sql.DB
+}
+
+// SimpanJawaban menyimpan jawaban yang dipilih siswa selama ujian berlangsung
+func (r *postgresJawabanRepository) SimpanJawaban(js models.JawabanSiswa) error {
+	_, err := r.db.Exec(upsertJawabanSiswa, argsJawabanSiswa(js)...)
+	if err != nil {
+		return fmt.Errorf("error saving answer for soal %s: %w", js.SoalID, err)
+	}
+	return nil
+}
+
+// GetJawabanSiswa mengambil jawaban tersimpan seorang siswa untuk satu ujian
+func (r *postgresJawabanRepository) GetJawabanSiswa(ujianID, siswaDetailID string) ([]models.JawabanSiswa, error) {
+	rows, err := r.db.Query(selectJawabanSiswa+`
+		WHERE "ujianId" = $1 AND "siswaDetailId" = $2
+		ORDER BY "createdAt"
+	`, ujianID, siswaDetailID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying jawaban siswa: %w", err)
+	}
+	return scanJawabanSiswa(rows)
+}
+
+// GetJawabanUjian mengambil jawaban tersimpan semua siswa untuk satu ujian, dikelompokkan per siswa
+func (r *postgresJawabanRepository) GetJawabanUjian(ujianID string) ([]models.JawabanSiswa, error) {
+	rows, err := r.db.Query(selectJawabanSiswa+`
+		WHERE "ujianId" = $1
+		ORDER BY "siswaDetailId", "createdAt"
+	`, ujianID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying jawaban ujian: %w", err)
+	}
+	return scanJawabanSiswa(rows)
+}
+
+const selectJawabanSiswa = `
+		SELECT "id", "siswaDetailId", "ujianId", "soalId", "jawabanId", COALESCE("jawabanIds", '{}'), COALESCE("jawabanTeks", ''), "skorManual", "createdAt"
+		FROM jawaban_siswa`
+
+func scanJawabanSiswa(rows *sql.Rows) ([]models.JawabanSiswa, error) {
+	defer rows.Close()
+
+	var result []models.JawabanSiswa
+	for rows.Next() {
+		var js models.JawabanSiswa
+		var createdAt time.Time
+		var skorManual sql.NullFloat64
+		if err := rows.Scan(&js.ID, &js.SiswaDetailID, &js.UjianID, &js.SoalID, &js.JawabanID, pq.Array(&js.JawabanIDs), &js.JawabanTeks, &skorManual, &createdAt); err != nil {
+			return nil, fmt.Errorf("error scanning jawaban siswa: %w", err)
+		}
+		if skorManual.Valid {
+			js.SkorManual = &skorManual.Float64
+		}
+		js.CreatedAt = createdAt.UnixMilli()
+		result = append(result, js)
+	}
+	return result, rows.Err()
+}
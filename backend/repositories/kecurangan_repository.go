@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"backend/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type postgresKecuranganRepository struct {
+	db *sql.DB
+}
+
+func (r *postgresKecuranganRepository) SimpanKecurangan(event models.CheatingEvent) error {
+	_, err := r.db.Exec(
+		"INSERT INTO kecurangan (id, \"ujianId\", \"siswaDetailId\", type) VALUES ($1, $2, $3, $4)",
+		uuid.New().String(),
+		event.UjianID,
+		event.SiswaDetailID,
+		kecuranganTypeDB(event.Type),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving cheating event: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresKecuranganRepository) CountKecurangan(ujianID, siswaDetailID string) (int, error) {
+	var total int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM kecurangan WHERE \"ujianId\" = $1 AND \"siswaDetailId\" = $2",
+		ujianID, siswaDetailID,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("error counting cheating incidents: %w", err)
+	}
+	return total, nil
+}
+
+func (r *postgresKecuranganRepository) CountKecuranganByType(ujianID, siswaDetailID string) ([]models.CheatingCount, error) {
+	rows, err := r.db.Query(
+		`SELECT "type", COUNT(*) as count 
+		 FROM kecurangan 
+		 WHERE "ujianId" = $1 AND "siswaDetailId" = $2 
+		 GROUP BY "type"`,
+		ujianID, siswaDetailID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching cheating details: %w", err)
+	}
+	defer rows.Close()
+
+	byType := []models.CheatingCount{}
+	for rows.Next() {
+		var cheatType models.TypeKecurangan
+		var count int
+		if err := rows.Scan(&cheatType, &count); err != nil {
+			continue
+		}
+		byType = append(byType, models.CheatingCount{Type: cheatType, Count: count})
+	}
+	return byType, rows.Err()
+}
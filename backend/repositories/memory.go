@@ -0,0 +1,1205 @@
+package repositories
+
+import (
+	"backend/clock"
+	"backend/models"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryJadwal baris tabel jadwal pada MemoryStore
+type MemoryJadwal struct {
+	ID      string
+	Tanggal time.Time
+	Tingkat string
+}
+
+// MemorySesi baris tabel sesi pada MemoryStore
+type MemorySesi struct {
+	ID         string
+	JadwalID   string
+	Sesi       int
+	JamMulai   string
+	JamSelesai string
+}
+
+// MemoryUjian baris tabel ujian pada MemoryStore, WaktuPengerjaan 0 berarti belum diisi
+type MemoryUjian struct {
+	ID              string
+	MataPelajaranID string
+	SesiID          string
+	JamMulai        string
+	JamSelesai      string
+	Status          string
+	Token           string
+	WaktuPengerjaan int
+	PenaltiSalah    *float64 // nil berarti mengikuti mata pelajaran
+	NilaiParsial    *bool    // nil berarti mengikuti mata pelajaran
+	JumlahSoal      int
+	KuotaSoal       []models.KuotaSoal
+	TampilkanKunci  bool
+}
+
+// MemoryUjianSusulan baris tabel ujian_susulan pada MemoryStore
+type MemoryUjianSusulan struct {
+	UjianID       string
+	Tingkat       models.Tingkat
+	SesiID        string
+	WaktuDibuat   time.Time
+	WaktuBerakhir time.Time
+}
+
+// MemoryStore implementasi semua repository di memori, dipakai untuk menjalankan
+// aplikasi di test tanpa Postgres. Field tabel boleh diisi langsung sebelum dipakai,
+// setelah itu akses harus lewat method agar aman dari goroutine tracker. Clock dipakai untuk
+// timestamp yang diisi store sendiri, test mengisinya dengan jam palsu yang sama dengan handler.
+type MemoryStore struct {
+	mu    sync.Mutex
+	Clock clock.Clock
+
+	Jadwal        []MemoryJadwal
+	Sesi          []MemorySesi
+	Ujian         []MemoryUjian
+	MataPelajaran []models.MataPelajaran
+	Soal          []models.Soal
+	Jawaban       []models.Jawaban
+	Kelas         []models.Kelas
+	Siswa         []models.SiswaDetail
+	Hasil         []models.HasilDetail
+	JawabanSiswa  []models.JawabanSiswa
+	Kecurangan    []models.CheatingEvent
+	UjianSusulan  []MemoryUjianSusulan
+	Peserta       []models.UjianPeserta
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{Clock: clock.Real{}}
+}
+
+// Lock dan Unlock dipakai test yang membaca tabel ketika tracker sedang berjalan
+func (s *MemoryStore) Lock()   { s.mu.Lock() }
+func (s *MemoryStore) Unlock() { s.mu.Unlock() }
+
+func (s *MemoryStore) findJadwal(id string) *MemoryJadwal {
+	for i := range s.Jadwal {
+		if s.Jadwal[i].ID == id {
+			return &s.Jadwal[i]
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) findSesi(id string) *MemorySesi {
+	for i := range s.Sesi {
+		if s.Sesi[i].ID == id {
+			return &s.Sesi[i]
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) findUjian(id string) *MemoryUjian {
+	for i := range s.Ujian {
+		if s.Ujian[i].ID == id {
+			return &s.Ujian[i]
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) findMataPelajaran(id string) *models.MataPelajaran {
+	for i := range s.MataPelajaran {
+		if s.MataPelajaran[i].ID == id {
+			return &s.MataPelajaran[i]
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) findKelas(id string) *models.Kelas {
+	for i := range s.Kelas {
+		if s.Kelas[i].ID == id {
+			return &s.Kelas[i]
+		}
+	}
+	return nil
+}
+
+// jadwalUjianRows meniru join jadwal, sesi, ujian, dan mata pelajaran
+func (s *MemoryStore) jadwalUjianRows() []UjianData {
+	var rows []UjianData
+	for _, u := range s.Ujian {
+		sesi := s.findSesi(u.SesiID)
+		if sesi == nil {
+			continue
+		}
+		jadwal := s.findJadwal(sesi.JadwalID)
+		mp := s.findMataPelajaran(u.MataPelajaranID)
+		if jadwal == nil || mp == nil {
+			continue
+		}
+		rows = append(rows, UjianData{
+			Tanggal:         jadwal.Tanggal,
+			TingkatStr:      jadwal.Tingkat,
+			SesiID:          sesi.ID,
+			Sesi:            sesi.Sesi,
+			SesiJamMulai:    sesi.JamMulai,
+			SesiJamSelesai:  sesi.JamSelesai,
+			UjianID:         u.ID,
+			UjianJamMulai:   u.JamMulai,
+			UjianJamSelesai: u.JamSelesai,
+			Status:          u.Status,
+			Pelajaran:       mp.Pelajaran,
+		})
+	}
+	return rows
+}
+
+func (s *MemoryStore) GetJadwalUjian(now time.Time) (map[models.Tingkat][]models.TingkatData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mulai := now.Format("2006-01-02")
+	akhir := now.AddDate(0, 0, 3).Format("2006-01-02")
+
+	var jadwalRows []jadwalRow
+	for _, row := range s.jadwalUjianRows() {
+		tanggal := row.Tanggal.Format("2006-01-02")
+		if tanggal < mulai || tanggal > akhir {
+			continue
+		}
+		u := s.findUjian(row.UjianID)
+		jr := jadwalRow{
+			JadwalID:      s.findSesi(row.SesiID).JadwalID,
+			Tanggal:       row.Tanggal,
+			Tingkat:       row.TingkatStr,
+			SesiID:        row.SesiID,
+			SesiNum:       row.Sesi,
+			UjianID:       row.UjianID,
+			Status:        row.Status,
+			MataPelajaran: row.Pelajaran,
+		}
+		jr.SesiJamMulai.String, jr.SesiJamMulai.Valid = row.SesiJamMulai, row.SesiJamMulai != ""
+		jr.SesiJamSelesai.String, jr.SesiJamSelesai.Valid = row.SesiJamSelesai, row.SesiJamSelesai != ""
+		jr.UjianJamMulai.String, jr.UjianJamMulai.Valid = row.UjianJamMulai, row.UjianJamMulai != ""
+		jr.UjianJamSelesai.String, jr.UjianJamSelesai.Valid = row.UjianJamSelesai, row.UjianJamSelesai != ""
+		jr.Token.String, jr.Token.Valid = u.Token, u.Token != ""
+		jr.WaktuPengerjaan.Int64, jr.WaktuPengerjaan.Valid = int64(u.WaktuPengerjaan), u.WaktuPengerjaan != 0
+		jadwalRows = append(jadwalRows, jr)
+	}
+
+	sort.SliceStable(jadwalRows, func(i, j int) bool {
+		a, b := jadwalRows[i], jadwalRows[j]
+		if !a.Tanggal.Equal(b.Tanggal) {
+			return a.Tanggal.Before(b.Tanggal)
+		}
+		if a.Tingkat != b.Tingkat {
+			return a.Tingkat < b.Tingkat
+		}
+		if a.SesiNum != b.SesiNum {
+			return a.SesiNum < b.SesiNum
+		}
+		return a.UjianJamMulai.String < b.UjianJamMulai.String
+	})
+	return susunJadwalUjian(jadwalRows, now), nil
+}
+
+func (s *MemoryStore) GetUjianTerlewat(now time.Time) (models.ResponseUjianTerlewat, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mulai := now.AddDate(0, 0, -30).Format("2006-01-02")
+	akhir := now.Format("2006-01-02")
+
+	var ujianRows []UjianData
+	for _, row := range s.jadwalUjianRows() {
+		tanggal := row.Tanggal.Format("2006-01-02")
+		if tanggal < mulai || tanggal > akhir {
+			continue
+		}
+		ujianRows = append(ujianRows, row)
+	}
+
+	sort.SliceStable(ujianRows, func(i, j int) bool {
+		a, b := ujianRows[i], ujianRows[j]
+		if !a.Tanggal.Equal(b.Tanggal) {
+			return a.Tanggal.After(b.Tanggal)
+		}
+		if a.TingkatStr != b.TingkatStr {
+			return a.TingkatStr < b.TingkatStr
+		}
+		if a.Sesi != b.Sesi {
+			return a.Sesi < b.Sesi
+		}
+		return a.UjianJamMulai < b.UjianJamMulai
+	})
+	return susunUjianTerlewat(ujianRows, now), nil
+}
+
+func (s *MemoryStore) SimpanUjianSusulan(susulan models.UjianSusulanData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findUjian(susulan.UjianData.ID) == nil {
+		return fmt.Errorf("error saving ujian susulan: ujian %s tidak ada", susulan.UjianData.ID)
+	}
+	s.UjianSusulan = append(s.UjianSusulan, MemoryUjianSusulan{
+		UjianID:       susulan.UjianData.ID,
+		Tingkat:       susulan.Tingkat,
+		SesiID:        susulan.SesiId,
+		WaktuDibuat:   susulan.WaktuDibuat,
+		WaktuBerakhir: susulan.WaktuBerakhir,
+	})
+	return nil
+}
+
+func (s *MemoryStore) GetUjianSusulanAktif(now time.Time) ([]models.UjianSusulanData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	aktif := make([]MemoryUjianSusulan, 0, len(s.UjianSusulan))
+	for _, us := range s.UjianSusulan {
+		if !us.WaktuBerakhir.Before(now) {
+			aktif = append(aktif, us)
+		}
+	}
+	sort.SliceStable(aktif, func(i, j int) bool {
+		return aktif[i].WaktuDibuat.Before(aktif[j].WaktuDibuat)
+	})
+
+	var result []models.UjianSusulanData
+	for _, us := range aktif {
+		u := s.findUjian(us.UjianID)
+		if u == nil {
+			continue
+		}
+		mp := s.findMataPelajaran(u.MataPelajaranID)
+		if mp == nil {
+			continue
+		}
+		susulan := models.UjianSusulanData{
+			Tingkat:       us.Tingkat,
+			SesiId:        us.SesiID,
+			WaktuDibuat:   us.WaktuDibuat.In(time.Local),
+			WaktuBerakhir: us.WaktuBerakhir.In(time.Local),
+		}
+		susulan.UjianData = s.ujianData(u, mp)
+		susulan.UjianData.IsUjianSusulan = true
+		susulan.UjianData.WaktuDibuat = susulan.WaktuDibuat
+		susulan.UjianData.WaktuBerakhir = susulan.WaktuBerakhir
+		susulan.UjianData.TiedToSesiID = susulan.SesiId
+		result = append(result, susulan)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) HapusUjianSusulan(ujianIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hapus := make(map[string]bool, len(ujianIDs))
+	for _, id := range ujianIDs {
+		hapus[id] = true
+	}
+	sisa := s.UjianSusulan[:0]
+	for _, us := range s.UjianSusulan {
+		if !hapus[us.UjianID] {
+			sisa = append(sisa, us)
+		}
+	}
+	s.UjianSusulan = sisa
+	return nil
+}
+
+func (s *MemoryStore) ujianData(u *MemoryUjian, mp *models.MataPelajaran) models.UjianData {
+	waktuPengerjaan := u.WaktuPengerjaan
+	if waktuPengerjaan == 0 {
+		waktuPengerjaan = 90
+	}
+	return models.UjianData{
+		ID:              u.ID,
+		MataPelajaran:   mp.Pelajaran,
+		JamMulai:        u.JamMulai,
+		JamSelesai:      u.JamSelesai,
+		Status:          u.Status,
+		Token:           u.Token,
+		WaktuPengerjaan: waktuPengerjaan,
+	}
+}
+
+func (s *MemoryStore) GetUjian(ujianID string) (*models.Ujian, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUjian(ujianID)
+	if u == nil {
+		return nil, ErrNotFound
+	}
+	mp := s.findMataPelajaran(u.MataPelajaranID)
+	if mp == nil {
+		return nil, ErrNotFound
+	}
+	penaltiSalah := mp.PenaltiSalah
+	if u.PenaltiSalah != nil {
+		penaltiSalah = *u.PenaltiSalah
+	}
+	nilaiParsial := mp.NilaiParsial
+	if u.NilaiParsial != nil {
+		nilaiParsial = *u.NilaiParsial
+	}
+	return &models.Ujian{
+		ID:              u.ID,
+		WaktuPengerjaan: s.ujianData(u, mp).WaktuPengerjaan,
+		Token:           u.Token,
+		Status:          u.Status,
+		SesiID:          u.SesiID,
+		PenaltiSalah:    penaltiSalah,
+		NilaiParsial:    nilaiParsial,
+		JumlahSoal:      u.JumlahSoal,
+		KuotaSoal:       append([]models.KuotaSoal(nil), u.KuotaSoal...),
+		TampilkanKunci:  u.TampilkanKunci,
+		MataPelajaran:   *mp,
+	}, nil
+}
+
+func (s *MemoryStore) SimpanPengaturanSoal(ujianID string, pengaturan models.PengaturanSoalRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUjian(ujianID)
+	if u == nil {
+		return ErrNotFound
+	}
+	u.JumlahSoal = pengaturan.JumlahSoal
+	u.KuotaSoal = append([]models.KuotaSoal(nil), pengaturan.KuotaSoal...)
+	return nil
+}
+
+func (s *MemoryStore) SimpanTampilkanKunci(ujianID string, tampilkan bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUjian(ujianID)
+	if u == nil {
+		return ErrNotFound
+	}
+	u.TampilkanKunci = tampilkan
+	return nil
+}
+
+func (s *MemoryStore) SimpanPengaturanPenilaian(ujianID string, pengaturan models.PengaturanPenilaianRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUjian(ujianID)
+	if u == nil {
+		return ErrNotFound
+	}
+	u.PenaltiSalah = nil
+	if pengaturan.PenaltiSalah != nil {
+		penalti := *pengaturan.PenaltiSalah
+		u.PenaltiSalah = &penalti
+	}
+	u.NilaiParsial = nil
+	if pengaturan.NilaiParsial != nil {
+		parsial := *pengaturan.NilaiParsial
+		u.NilaiParsial = &parsial
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateUjianStatus(ujianID, status, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u := s.findUjian(ujianID); u != nil {
+		u.Status = status
+		u.Token = token
+	}
+	return nil
+}
+
+func (s *MemoryStore) SelesaikanUjian(ujianIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ujianIDs {
+		if s.findUjian(id) == nil {
+			return fmt.Errorf("gagal update status ujian ID %s: no rows affected", id)
+		}
+	}
+	for _, id := range ujianIDs {
+		s.findUjian(id).Status = "selesai"
+	}
+	return nil
+}
+
+func (s *MemoryStore) AktifkanUjianSusulan(ujianID string) (*models.UjianData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUjian(ujianID)
+	if u == nil {
+		return nil, fmt.Errorf("gagal memperbarui status ujian: no rows affected - ujian ID might not exist: %s", ujianID)
+	}
+	mp := s.findMataPelajaran(u.MataPelajaranID)
+	if mp == nil {
+		return nil, fmt.Errorf("ujian not found with ID: %s", ujianID)
+	}
+	u.Status = "active"
+	ujianData := s.ujianData(u, mp)
+	ujianData.IsUjianSusulan = true
+	return &ujianData, nil
+}
+
+func (s *MemoryStore) GetUjianPendingByTingkat(tingkat string) ([]models.UjianData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.UjianData
+	for _, u := range s.Ujian {
+		if u.Status != "pending" || u.JamMulai == "" || s.findSesi(u.SesiID) == nil {
+			continue
+		}
+		mp := s.findMataPelajaran(u.MataPelajaranID)
+		if mp == nil || mp.Tingkat != tingkat {
+			continue
+		}
+		result = append(result, models.UjianData{
+			ID:            u.ID,
+			JamMulai:      u.JamMulai,
+			MataPelajaran: mp.Pelajaran,
+			Status:        "pending",
+		})
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) SimpanSoal(tingkat, pelajaran string, soalDataArr []models.SoalInput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.simpanSoal(tingkat, pelajaran, soalDataArr)
+	return nil
+}
+
+// SimpanSoalDanTimpa meniru transaksi Postgres: bila satu soal gagal ditimpa, tabel soal dan jawaban
+// dikembalikan seperti sebelum dipanggil
+func (s *MemoryStore) SimpanSoalDanTimpa(tingkat, pelajaran string, baru, timpa []models.SoalInput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mataPelajaran := append([]models.MataPelajaran(nil), s.MataPelajaran...)
+	soal := append([]models.Soal(nil), s.Soal...)
+	jawaban := append([]models.Jawaban(nil), s.Jawaban...)
+
+	s.simpanSoal(tingkat, pelajaran, baru)
+	for _, input := range timpa {
+		if err := s.updateSoal(input); err != nil {
+			s.MataPelajaran, s.Soal, s.Jawaban = mataPelajaran, soal, jawaban
+			return fmt.Errorf("gagal menimpa soal %s: %w", input.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) simpanSoal(tingkat, pelajaran string, soalDataArr []models.SoalInput) {
+	var mataPelajaranID string
+	for _, mp := range s.MataPelajaran {
+		if mp.Tingkat == tingkat && mp.Pelajaran == pelajaran {
+			mataPelajaranID = mp.ID
+			break
+		}
+	}
+	if mataPelajaranID == "" {
+		mataPelajaranID = uuid.New().String()
+		s.MataPelajaran = append(s.MataPelajaran, models.MataPelajaran{
+			ID: mataPelajaranID, Tingkat: tingkat, Pelajaran: pelajaran,
+		})
+	}
+
+	for _, soalInput := range soalDataArr {
+		soalID := uuid.New().String()
+		s.Soal = append(s.Soal, models.Soal{
+			ID: soalID, Gambar: soalInput.Gambar, Soal: soalInput.Soal, Bobot: bobotSoal(soalInput.Bobot),
+			Tipe: tipeSoal(soalInput.Tipe), Format: formatSoal(soalInput.Format), MataPelajaranID: mataPelajaranID,
+			Topik: soalInput.Topik, KD: soalInput.KD, Kesulitan: soalInput.Kesulitan, JumlahPilihan: soalInput.JumlahPilihan,
+		})
+		for _, pilihan := range soalInput.Pilihan {
+			s.Jawaban = append(s.Jawaban, models.Jawaban{
+				ID: uuid.New().String(), SoalID: soalID, Jawaban: pilihan.Text, Benar: pilihanBenar(soalInput, pilihan),
+			})
+		}
+	}
+}
+
+func (s *MemoryStore) GetMataPelajaran(tingkat, pelajaran string) (*models.MataPelajaran, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, mp := range s.MataPelajaran {
+		if mp.Tingkat == tingkat && mp.Pelajaran == pelajaran {
+			result := mp
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) SimpanPengaturanMataPelajaran(mp models.MataPelajaran) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lama := s.findMataPelajaran(mp.ID)
+	if lama == nil {
+		return ErrNotFound
+	}
+	for i := range s.Ujian {
+		u := &s.Ujian[i]
+		if u.MataPelajaranID != mp.ID || u.Status == "pending" {
+			continue
+		}
+		if u.PenaltiSalah == nil && lama.PenaltiSalah != mp.PenaltiSalah {
+			penalti := lama.PenaltiSalah
+			u.PenaltiSalah = &penalti
+		}
+		if u.NilaiParsial == nil && lama.NilaiParsial != mp.NilaiParsial {
+			parsial := lama.NilaiParsial
+			u.NilaiParsial = &parsial
+		}
+	}
+	if lama.JumlahPilihan != mp.JumlahPilihan {
+		for i := range s.Soal {
+			soal := &s.Soal[i]
+			if soal.MataPelajaranID != mp.ID || soal.JumlahPilihan != 0 {
+				continue
+			}
+			if soal.Tipe != "" && soal.Tipe != models.TipePilihanGanda && soal.Tipe != models.TipePilihanGandaKompleks {
+				continue
+			}
+			jumlah := 0
+			for _, j := range s.Jawaban {
+				if j.SoalID == soal.ID {
+					jumlah++
+				}
+			}
+			if jumlah > 0 && jumlah != mp.JumlahPilihan {
+				soal.JumlahPilihan = jumlah
+			}
+		}
+	}
+	lama.PenaltiSalah = mp.PenaltiSalah
+	lama.NilaiParsial = mp.NilaiParsial
+	lama.JumlahPilihan = mp.JumlahPilihan
+	return nil
+}
+
+func (s *MemoryStore) GetSoalUjian(ujianID string) ([]models.SoalInput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUjian(ujianID)
+	if u == nil {
+		return nil, ErrNotFound
+	}
+	return s.soalMataPelajaran(u.MataPelajaranID, nil), nil
+}
+
+func (s *MemoryStore) GetSoalUjianTercatat(ujianID string, soalIDs []string) ([]models.SoalInput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUjian(ujianID)
+	if u == nil {
+		return nil, ErrNotFound
+	}
+	return s.soalMataPelajaran(u.MataPelajaranID, soalIDs), nil
+}
+
+func (s *MemoryStore) GetSoalMataPelajaran(tingkat, pelajaran string) ([]models.SoalInput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, mp := range s.MataPelajaran {
+		if mp.Tingkat == tingkat && mp.Pelajaran == pelajaran {
+			return s.soalMataPelajaran(mp.ID, nil), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) soalMataPelajaran(mataPelajaranID string, arsip []string) []models.SoalInput {
+	tercatat := make(map[string]bool, len(arsip))
+	for _, id := range arsip {
+		tercatat[id] = true
+	}
+	var result []models.SoalInput
+	for _, soal := range s.Soal {
+		if soal.MataPelajaranID != mataPelajaranID || (soal.DeletedAt != nil && !tercatat[soal.ID]) {
+			continue
+		}
+		input := s.soalInput(soal)
+		// Sama seperti query Postgres, soal tanpa pilihan tidak ikut kecuali esai
+		if len(input.Pilihan) == 0 && input.Tipe != models.TipeEsai {
+			continue
+		}
+		result = append(result, input)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// soalInput soal beserta pilihannya yang diurutkan seperti query Postgres
+func (s *MemoryStore) soalInput(soal models.Soal) models.SoalInput {
+	input := models.SoalInput{
+		ID: soal.ID, Soal: soal.Soal, Gambar: soal.Gambar, Bobot: bobotSoal(soal.Bobot), Tipe: tipeSoal(soal.Tipe), Format: formatSoal(soal.Format),
+		Topik: soal.Topik, KD: soal.KD, Kesulitan: soal.Kesulitan, JumlahPilihan: soal.JumlahPilihan,
+	}
+	for _, j := range s.Jawaban {
+		if j.SoalID == soal.ID {
+			input.Pilihan = append(input.Pilihan, models.Pilihan{ID: j.ID, Text: j.Jawaban, Benar: j.Benar})
+		}
+	}
+	sort.Slice(input.Pilihan, func(i, j int) bool { return input.Pilihan[i].ID < input.Pilihan[j].ID })
+	return input
+}
+
+func (s *MemoryStore) soalDetail(soal models.Soal) models.SoalDetail {
+	detail := models.SoalDetail{SoalInput: s.soalInput(soal)}
+	if mp := s.findMataPelajaran(soal.MataPelajaranID); mp != nil {
+		detail.Tingkat, detail.Pelajaran = mp.Tingkat, mp.Pelajaran
+	}
+	return detail
+}
+
+func (s *MemoryStore) findSoal(id string) *models.Soal {
+	for i := range s.Soal {
+		if s.Soal[i].ID == id && s.Soal[i].DeletedAt == nil {
+			return &s.Soal[i]
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) ListSoal(filter models.SoalFilter) ([]models.SoalDetail, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cari := strings.ToLower(filter.Cari)
+	var cocok []models.SoalDetail
+	for _, soal := range s.Soal {
+		if soal.DeletedAt != nil || !strings.Contains(strings.ToLower(soal.Soal), cari) {
+			continue
+		}
+		detail := s.soalDetail(soal)
+		if (filter.Tingkat != "" && detail.Tingkat != filter.Tingkat) || (filter.Pelajaran != "" && detail.Pelajaran != filter.Pelajaran) {
+			continue
+		}
+		cocok = append(cocok, detail)
+	}
+	sort.SliceStable(cocok, func(i, j int) bool {
+		a, b := cocok[i], cocok[j]
+		if a.Tingkat != b.Tingkat {
+			return a.Tingkat < b.Tingkat
+		}
+		if a.Pelajaran != b.Pelajaran {
+			return a.Pelajaran < b.Pelajaran
+		}
+		return a.ID < b.ID
+	})
+
+	result := []models.SoalDetail{}
+	mulai := (filter.Halaman - 1) * filter.PerHalaman
+	if mulai < len(cocok) {
+		result = append(result, cocok[mulai:min(mulai+filter.PerHalaman, len(cocok))]...)
+	}
+	return result, len(cocok), nil
+}
+
+func (s *MemoryStore) GetSoal(id string) (*models.SoalDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	soal := s.findSoal(id)
+	if soal == nil {
+		return nil, ErrNotFound
+	}
+	detail := s.soalDetail(*soal)
+	return &detail, nil
+}
+
+func (s *MemoryStore) UpdateSoal(input models.SoalInput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateSoal(input)
+}
+
+func (s *MemoryStore) updateSoal(input models.SoalInput) error {
+	soal := s.findSoal(input.ID)
+	if soal == nil {
+		return ErrNotFound
+	}
+	lamaJawaban := make(map[string]models.Jawaban)
+	for _, j := range s.Jawaban {
+		if j.SoalID == input.ID {
+			lamaJawaban[j.ID] = j
+		}
+	}
+	dijawab := false
+	for _, js := range s.JawabanSiswa {
+		dijawab = dijawab || js.SoalID == input.ID
+	}
+	if dijawab && kunciBerubah(soal.Tipe, lamaJawaban, input) {
+		return ErrKunciSoalTerkunci
+	}
+	soal.Soal, soal.Gambar, soal.Bobot, soal.Tipe = input.Soal, input.Gambar, bobotSoal(input.Bobot), tipeSoal(input.Tipe)
+	soal.Format = formatSoal(input.Format)
+	soal.Topik = input.Topik
+	soal.KD = input.KD
+	soal.Kesulitan = input.Kesulitan
+	soal.JumlahPilihan = input.JumlahPilihan
+
+	dikirim := make(map[string]models.Pilihan)
+	for _, p := range input.Pilihan {
+		if p.ID != "" {
+			dikirim[p.ID] = p
+		}
+	}
+	var jawaban []models.Jawaban
+	lama := make(map[string]bool)
+	for _, j := range s.Jawaban {
+		if j.SoalID != input.ID {
+			jawaban = append(jawaban, j)
+			continue
+		}
+		lama[j.ID] = true
+		if p, ok := dikirim[j.ID]; ok {
+			j.Jawaban, j.Benar = p.Text, pilihanBenar(input, p)
+			jawaban = append(jawaban, j)
+		}
+	}
+	for _, p := range input.Pilihan {
+		if !lama[p.ID] {
+			jawaban = append(jawaban, models.Jawaban{
+				ID: uuid.New().String(), SoalID: input.ID, Jawaban: p.Text, Benar: pilihanBenar(input, p),
+			})
+		}
+	}
+	s.Jawaban = jawaban
+	return nil
+}
+
+func (s *MemoryStore) HapusSoal(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	soal := s.findSoal(id)
+	if soal == nil {
+		return false, ErrNotFound
+	}
+	dikerjakan := false
+	for _, js := range s.JawabanSiswa {
+		dikerjakan = dikerjakan || js.SoalID == id
+	}
+	for _, p := range s.Peserta {
+		for _, soalID := range p.SoalIDs {
+			dikerjakan = dikerjakan || soalID == id
+		}
+	}
+	if dikerjakan {
+		now := s.Clock.Now()
+		soal.DeletedAt = &now
+		return true, nil
+	}
+
+	var sisa []models.Soal
+	for _, so := range s.Soal {
+		if so.ID != id {
+			sisa = append(sisa, so)
+		}
+	}
+	s.Soal = sisa
+	var jawaban []models.Jawaban
+	for _, j := range s.Jawaban {
+		if j.SoalID != id {
+			jawaban = append(jawaban, j)
+		}
+	}
+	s.Jawaban = jawaban
+	return false, nil
+}
+
+func (s *MemoryStore) SimpanHasil(hasil models.HasilDetail, jawaban []models.JawabanSiswa) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Meniru unique constraint (siswaDetailId, ujianId) pada tabel hasil
+	for _, h := range s.Hasil {
+		if h.SiswaDetailID == hasil.SiswaDetailID && h.UjianID == hasil.UjianID {
+			return ErrHasilSudahAda
+		}
+	}
+	if hasil.CreatedAt == 0 {
+		hasil.CreatedAt = s.Clock.Now().Unix()
+	}
+	for _, js := range jawaban {
+		s.upsertJawaban(js)
+	}
+	s.Hasil = append(s.Hasil, hasil)
+	return nil
+}
+
+func (s *MemoryStore) SimpanPenilaianManual(hasil models.HasilDetail, skor map[string]float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tersimpan *models.HasilDetail
+	for i := range s.Hasil {
+		if s.Hasil[i].ID == hasil.ID {
+			tersimpan = &s.Hasil[i]
+		}
+	}
+	if tersimpan == nil {
+		return ErrNotFound
+	}
+	for i := range s.JawabanSiswa {
+		js := &s.JawabanSiswa[i]
+		if nilai, ok := skor[js.SoalID]; ok && js.UjianID == hasil.UjianID && js.SiswaDetailID == hasil.SiswaDetailID {
+			js.SkorManual = &nilai
+		}
+	}
+	tersimpan.Nilai = hasil.Nilai
+	tersimpan.Benar = hasil.Benar
+	tersimpan.Salah = hasil.Salah
+	tersimpan.Kosong = hasil.Kosong
+	tersimpan.Rincian = hasil.Rincian
+	return nil
+}
+
+func (s *MemoryStore) GetHasilDetail(hasilID string) (*models.HasilDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, h := range s.Hasil {
+		if h.ID != hasilID {
+			continue
+		}
+		u := s.findUjian(h.UjianID)
+		if u == nil {
+			return nil, ErrNotFound
+		}
+		mp := s.findMataPelajaran(u.MataPelajaranID)
+		if mp == nil {
+			return nil, ErrNotFound
+		}
+		hasil := h
+		hasil.TotalKecurangan = s.countKecurangan(h.UjianID, h.SiswaDetailID)
+		hasil.MataPelajaran = mp.Pelajaran
+		hasil.Tingkat = mp.Tingkat
+		return &hasil, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) ListHasilUjian(filter models.HasilFilter) ([]models.HasilUjianDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.HasilUjianDetail
+	for _, h := range s.Hasil {
+		siswa := s.findSiswa(h.SiswaDetailID)
+		u := s.findUjian(h.UjianID)
+		if siswa == nil || u == nil {
+			continue
+		}
+		kelas := s.findKelas(siswa.KelasID)
+		mp := s.findMataPelajaran(u.MataPelajaranID)
+		if kelas == nil || mp == nil {
+			continue
+		}
+		nama := namaKelas(kelas.Tingkat, kelas.Jurusan)
+		createdAt := time.Unix(h.CreatedAt, 0).In(time.Local)
+		if (filter.Tingkat != "" && mp.Tingkat != filter.Tingkat) ||
+			(filter.Pelajaran != "" && mp.Pelajaran != filter.Pelajaran) ||
+			(filter.Kelas != "" && nama != filter.Kelas && kelas.Jurusan != filter.Kelas) ||
+			(filter.UjianID != "" && h.UjianID != filter.UjianID) ||
+			(!filter.Dari.IsZero() && createdAt.Before(filter.Dari)) ||
+			(!filter.Sampai.IsZero() && !createdAt.Before(filter.Sampai)) {
+			continue
+		}
+		result = append(result, models.HasilUjianDetail{
+			ID:              h.ID,
+			UjianID:         h.UjianID,
+			SiswaNama:       siswa.Nama,
+			Kelas:           nama,
+			Tingkat:         kelas.Tingkat,
+			MataPelajaran:   mp.Pelajaran,
+			Nilai:           fmt.Sprintf("%d", h.Nilai),
+			Benar:           fmt.Sprintf("%d", h.Benar),
+			Salah:           fmt.Sprintf("%d", h.Salah),
+			Kosong:          fmt.Sprintf("%d", h.Kosong),
+			WaktuPengerjaan: fmt.Sprintf("%d", h.WaktuPengerjaan),
+			NIS:             siswa.NIS,
+			TotalKecurangan: s.countKecurangan(h.UjianID, h.SiswaDetailID),
+			CreatedAt:       createdAt,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		switch {
+		case a.Tingkat != b.Tingkat:
+			return a.Tingkat < b.Tingkat
+		case a.MataPelajaran != b.MataPelajaran:
+			return a.MataPelajaran < b.MataPelajaran
+		case a.Kelas != b.Kelas:
+			return a.Kelas < b.Kelas
+		}
+		return a.SiswaNama < b.SiswaNama
+	})
+	return result, nil
+}
+
+func (s *MemoryStore) ListHasilPerUjian(ujianID string) ([]models.HasilDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.HasilDetail
+	for _, h := range s.Hasil {
+		if h.UjianID == ujianID {
+			result = append(result, h)
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) SimpanKecurangan(event models.CheatingEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kecuranganTypeDB(event.Type) == "" {
+		return fmt.Errorf("error saving cheating event: tipe %q tidak dikenal", event.Type)
+	}
+	s.Kecurangan = append(s.Kecurangan, event)
+	return nil
+}
+
+func (s *MemoryStore) countKecurangan(ujianID, siswaDetailID string) int {
+	total := 0
+	for _, k := range s.Kecurangan {
+		if k.UjianID == ujianID && k.SiswaDetailID == siswaDetailID {
+			total++
+		}
+	}
+	return total
+}
+
+func (s *MemoryStore) CountKecurangan(ujianID, siswaDetailID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.countKecurangan(ujianID, siswaDetailID), nil
+}
+
+func (s *MemoryStore) CountKecuranganByType(ujianID, siswaDetailID string) ([]models.CheatingCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byType := []models.CheatingCount{}
+	index := make(map[models.TypeKecurangan]int)
+	for _, k := range s.Kecurangan {
+		if k.UjianID != ujianID || k.SiswaDetailID != siswaDetailID {
+			continue
+		}
+		// Tipe disimpan dengan nilai enum database, sama seperti hasil query Postgres
+		t := models.TypeKecurangan(kecuranganTypeDB(k.Type))
+		i, ok := index[t]
+		if !ok {
+			byType = append(byType, models.CheatingCount{Type: t})
+			i = len(byType) - 1
+			index[t] = i
+		}
+		byType[i].Count++
+	}
+	return byType, nil
+}
+
+func (s *MemoryStore) findSiswa(id string) *models.SiswaDetail {
+	for i := range s.Siswa {
+		if s.Siswa[i].ID == id {
+			return &s.Siswa[i]
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetSiswaDetail(siswaDetailID string) (*models.SiswaDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	siswa := s.findSiswa(siswaDetailID)
+	if siswa == nil {
+		return nil, ErrNotFound
+	}
+	kelas := s.findKelas(siswa.KelasID)
+	if kelas == nil {
+		return nil, ErrNotFound
+	}
+	result := *siswa
+	result.Kelas = *kelas
+	result.Kelas.Nama = namaKelas(kelas.Tingkat, kelas.Jurusan)
+	return &result, nil
+}
+
+func (s *MemoryStore) GetHasilSiswa(ujianID, siswaDetailID string) (*models.HasilDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, h := range s.Hasil {
+		if h.UjianID == ujianID && h.SiswaDetailID == siswaDetailID {
+			hasil := h
+			return &hasil, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) findPeserta(ujianID, siswaDetailID string) *models.UjianPeserta {
+	for i := range s.Peserta {
+		if s.Peserta[i].UjianID == ujianID && s.Peserta[i].SiswaDetailID == siswaDetailID {
+			return &s.Peserta[i]
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) MulaiUjian(ujianID, siswaDetailID string, waktuMulai time.Time) (*models.UjianPeserta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.findPeserta(ujianID, siswaDetailID); p != nil {
+		peserta := *p
+		return &peserta, nil
+	}
+	peserta := models.UjianPeserta{
+		ID:            uuid.New().String(),
+		UjianID:       ujianID,
+		SiswaDetailID: siswaDetailID,
+		WaktuMulai:    waktuMulai,
+	}
+	s.Peserta = append(s.Peserta, peserta)
+	return &peserta, nil
+}
+
+func (s *MemoryStore) GetPeserta(ujianID, siswaDetailID string) (*models.UjianPeserta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.findPeserta(ujianID, siswaDetailID)
+	if p == nil {
+		return nil, ErrNotFound
+	}
+	peserta := *p
+	return &peserta, nil
+}
+
+// SimpanSoalPeserta mencatat hasil undian sekali saja, undian yang sudah tercatat tidak ditimpa
+func (s *MemoryStore) SimpanSoalPeserta(pesertaID string, seed int64, soalIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.Peserta {
+		p := &s.Peserta[i]
+		if p.ID != pesertaID {
+			continue
+		}
+		if len(p.SoalIDs) == 0 {
+			p.SeedSoal = seed
+			p.SoalIDs = append([]string(nil), soalIDs...)
+		}
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryStore) ListPesertaBelumSelesai() ([]models.UjianPeserta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.UjianPeserta
+	for _, p := range s.Peserta {
+		selesai := false
+		for _, h := range s.Hasil {
+			if h.UjianID == p.UjianID && h.SiswaDetailID == p.SiswaDetailID {
+				selesai = true
+				break
+			}
+		}
+		if !selesai {
+			result = append(result, p)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].WaktuMulai.Before(result[j].WaktuMulai) })
+	return result, nil
+}
+
+// upsertJawaban meniru unique constraint (siswaDetailId, ujianId, soalId) pada jawaban_siswa
+func (s *MemoryStore) upsertJawaban(js models.JawabanSiswa) {
+	for i := range s.JawabanSiswa {
+		lama := &s.JawabanSiswa[i]
+		if lama.SiswaDetailID == js.SiswaDetailID && lama.UjianID == js.UjianID && lama.SoalID == js.SoalID {
+			lama.JawabanID = js.JawabanID
+			lama.JawabanIDs = js.JawabanIDs
+			lama.JawabanTeks = js.JawabanTeks
+			lama.SkorManual = js.SkorManual
+			lama.CreatedAt = js.CreatedAt
+			return
+		}
+	}
+	s.JawabanSiswa = append(s.JawabanSiswa, js)
+}
+
+func (s *MemoryStore) SimpanJawaban(js models.JawabanSiswa) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.upsertJawaban(js)
+	return nil
+}
+
+func (s *MemoryStore) GetJawabanSiswa(ujianID, siswaDetailID string) ([]models.JawabanSiswa, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.JawabanSiswa
+	for _, js := range s.JawabanSiswa {
+		if js.UjianID == ujianID && js.SiswaDetailID == siswaDetailID {
+			result = append(result, js)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CreatedAt < result[j].CreatedAt })
+	return result, nil
+}
+
+func (s *MemoryStore) GetJawabanUjian(ujianID string) ([]models.JawabanSiswa, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.JawabanSiswa
+	for _, js := range s.JawabanSiswa {
+		if js.UjianID == ujianID {
+			result = append(result, js)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].SiswaDetailID != result[j].SiswaDetailID {
+			return result[i].SiswaDetailID < result[j].SiswaDetailID
+		}
+		return result[i].CreatedAt < result[j].CreatedAt
+	})
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"backend/models"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type postgresPesertaRepository struct {
+	db *sql.DB
+}
+
+// MulaiUjian mencatat waktu mulai siswa. Bila siswa sudah pernah memulai (misalnya memuat ulang halaman),
+// catatan lama dikembalikan sehingga waktu mulai tidak bergeser.
+func (r *postgresPesertaRepository) MulaiUjian(ujianID, siswaDetailID string, waktuMulai time.Time) (*models.UjianPeserta, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO ujian_peserta ("id", "ujianId", "siswaDetailId", "waktuMulai")
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("siswaDetailId", "ujianId") DO NOTHING
+	`, uuid.New().String(), ujianID, siswaDetailID, waktuMulai.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error saving peserta ujian: %w", err)
+	}
+	return r.GetPeserta(ujianID, siswaDetailID)
+}
+
+func (r *postgresPesertaRepository) GetPeserta(ujianID, siswaDetailID string) (*models.UjianPeserta, error) {
+	var peserta models.UjianPeserta
+	err := r.db.QueryRow(`
+		SELECT "id", "ujianId", "siswaDetailId", "waktuMulai", COALESCE("seedSoal", 0), "soalIds"
+		FROM ujian_peserta
+		WHERE "ujianId" = $1 AND "siswaDetailId" = $2
+	`, ujianID, siswaDetailID).Scan(&peserta.ID, &peserta.UjianID, &peserta.SiswaDetailID, &peserta.WaktuMulai,
+		&peserta.SeedSoal, pq.Array(&peserta.SoalIDs))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying peserta ujian: %w", err)
+	}
+	peserta.WaktuMulai = peserta.WaktuMulai.In(time.Local)
+	return &peserta, nil
+}
+
+// SimpanSoalPeserta mencatat seed dan hasil undian soal siswa. Undian yang sudah tercatat tidak ditimpa,
+// sehingga dua permintaan mulai yang datang bersamaan tetap menghasilkan satu set soal.
+func (r *postgresPesertaRepository) SimpanSoalPeserta(pesertaID string, seed int64, soalIDs []string) error {
+	_, err := r.db.Exec(`
+		UPDATE ujian_peserta SET "seedSoal" = $2, "soalIds" = $3
+		WHERE "id" = $1 AND COALESCE(cardinality("soalIds"), 0) = 0
+	`, pesertaID, seed, pq.Array(soalIDs))
+	if err != nil {
+		return fmt.Errorf("error saving soal peserta: %w", err)
+	}
+	return nil
+}
+
+// ListPesertaBelumSelesai mengambil siswa yang sudah memulai ujian tetapi belum memiliki hasil
+func (r *postgresPesertaRepository) ListPesertaBelumSelesai() ([]models.UjianPeserta, error) {
+	rows, err := r.db.Query(`
+		SELECT p."id", p."ujianId", p."siswaDetailId", p."waktuMulai", COALESCE(p."seedSoal", 0), p."soalIds"
+		FROM ujian_peserta p
+		WHERE NOT EXISTS (
+			SELECT 1 FROM hasil h WHERE h."ujianId" = p."ujianId" AND h."siswaDetailId" = p."siswaDetailId"
+		)
+		ORDER BY p."waktuMulai"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying peserta ujian: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.UjianPeserta
+	for rows.Next() {
+		var peserta models.UjianPeserta
+		if err := rows.Scan(&peserta.ID, &peserta.UjianID, &peserta.SiswaDetailID, &peserta.WaktuMulai,
+			&peserta.SeedSoal, pq.Array(&peserta.SoalIDs)); err != nil {
+			return nil, fmt.Errorf("error scanning peserta ujian: %w", err)
+		}
+		peserta.WaktuMulai = peserta.WaktuMulai.In(time.Local)
+		result = append(result, peserta)
+	}
+	return result, rows.Err()
+}
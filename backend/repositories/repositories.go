@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrNotFound dikembalikan repository ketika data yang diminta tidak ada
+var ErrNotFound = errors.New("data tidak ditemukan")
+
+// ErrHasilSudahAda dikembalikan SimpanHasil ketika siswa sudah memiliki hasil untuk ujian tersebut
+var ErrHasilSudahAda = errors.New("hasil ujian siswa sudah ada")
+
+// ErrKunciSoalTerkunci dikembalikan UpdateSoal ketika soal yang sudah dijawab siswa akan kehilangan pilihan
+// atau berubah kuncinya, karena penilaian ulang hasil lama membaca kunci yang sama
+var ErrKunciSoalTerkunci = errors.New("kunci soal yang sudah dijawab tidak dapat diubah")
+
+// JadwalRepository akses jadwal, sesi, dan registrasi ujian susulan
+type JadwalRepository interface {
+	GetJadwalUjian(now time.Time) (map[models.Tingkat][]models.TingkatData, error)
+	GetUjianTerlewat(now time.Time) (models.ResponseUjianTerlewat, error)
+	SimpanUjianSusulan(susulan models.UjianSusulanData) error
+	GetUjianSusulanAktif(now time.Time) ([]models.UjianSusulanData, error)
+	HapusUjianSusulan(ujianIDs []string) error
+}
+
+// UjianRepository akses data dan status ujian
+type UjianRepository interface {
+	GetUjian(ujianID string) (*models.Ujian, error)
+	UpdateUjianStatus(ujianID, status, token string) error
+	SelesaikanUjian(ujianIDs []string) error
+	AktifkanUjianSusulan(ujianID string) (*models.UjianData, error)
+	GetUjianPendingByTingkat(tingkat string) ([]models.UjianData, error)
+	SimpanPengaturanSoal(ujianID string, pengaturan models.PengaturanSoalRequest) error
+	SimpanTampilkanKunci(ujianID string, tampilkan bool) error
+	SimpanPengaturanPenilaian(ujianID string, pengaturan models.PengaturanPenilaianRequest) error
+}
+
+// SoalRepository akses bank soal dan pilihan jawabannya
+type SoalRepository interface {
+	SimpanSoal(tingkat, pelajaran string, soal []models.SoalInput) error
+	GetSoalUjian(ujianID string) ([]models.SoalInput, error)
+	GetSoalUjianTercatat(ujianID string, soalIDs []string) ([]models.SoalInput, error)
+	GetMataPelajaran(tingkat, pelajaran string) (*models.MataPelajaran, error)
+	GetSoalMataPelajaran(tingkat, pelajaran string) ([]models.SoalInput, error)
+	ListSoal(filter models.SoalFilter) ([]models.SoalDetail, int, error)
+	GetSoal(id string) (*models.SoalDetail, error)
+	UpdateSoal(soal models.SoalInput) error
+	SimpanSoalDanTimpa(tingkat, pelajaran string, baru, timpa []models.SoalInput) error
+	HapusSoal(id string) (diarsipkan bool, err error)
+	SimpanPengaturanMataPelajaran(mp models.MataPelajaran) error
+}
+
+// HasilRepository akses hasil ujian dan jawaban siswa
+type HasilRepository interface {
+	SimpanHasil(hasil models.HasilDetail, jawaban []models.JawabanSiswa) error
+	GetHasilDetail(hasilID string) (*models.HasilDetail, error)
+	ListHasilUjian(filter models.HasilFilter) ([]models.HasilUjianDetail, error)
+	GetHasilSiswa(ujianID, siswaDetailID string) (*models.HasilDetail, error)
+	ListHasilPerUjian(ujianID string) ([]models.HasilDetail, error)
+	SimpanPenilaianManual(hasil models.HasilDetail, skor map[string]float64) error
+}
+
+// KecuranganRepository akses catatan kecurangan siswa selama ujian
+type KecuranganRepository interface {
+	SimpanKecurangan(event models.CheatingEvent) error
+	CountKecurangan(ujianID, siswaDetailID string) (int, error)
+	CountKecuranganByType(ujianID, siswaDetailID string) ([]models.CheatingCount, error)
+}
+
+// SiswaRepository akses data siswa beserta kelasnya
+type SiswaRepository interface {
+	GetSiswaDetail(siswaDetailID string) (*models.SiswaDetail, error)
+}
+
+// PesertaRepository akses catatan siswa yang sudah memulai ujian
+type PesertaRepository interface {
+	MulaiUjian(ujianID, siswaDetailID string, waktuMulai time.Time) (*models.UjianPeserta, error)
+	GetPeserta(ujianID, siswaDetailID string) (*models.UjianPeserta, error)
+	SimpanSoalPeserta(pesertaID string, seed int64, soalIDs []string) error
+	ListPesertaBelumSelesai() ([]models.UjianPeserta, error)
+}
+
+// JawabanRepository akses jawaban siswa yang disimpan selama ujian berlangsung
+type JawabanRepository interface {
+	SimpanJawaban(jawaban models.JawabanSiswa) error
+	GetJawabanSiswa(ujianID, siswaDetailID string) ([]models.JawabanSiswa, error)
+	GetJawabanUjian(ujianID string) ([]models.JawabanSiswa, error)
+}
+
+// Repositories kumpulan repository yang dipakai handler dan tracker
+type Repositories struct {
+	Jadwal     JadwalRepository
+	Ujian      UjianRepository
+	Soal       SoalRepository
+	Hasil      HasilRepository
+	Kecurangan KecuranganRepository
+	Siswa      SiswaRepository
+	Peserta    PesertaRepository
+	Jawaban    JawabanRepository
+}
+
+// NewPostgresRepositories membuat semua repository di atas koneksi Postgres
+func NewPostgresRepositories(db *sql.DB) Repositories {
+	return Repositories{
+		Jadwal:     &postgresJadwalRepository{db: db},
+		Ujian:      &postgresUjianRepository{db: db},
+		Soal:       &postgresSoalRepository{db: db},
+		Hasil:      &postgresHasilRepository{db: db},
+		Kecurangan: &postgresKecuranganRepository{db: db},
+		Siswa:      &postgresSiswaRepository{db: db},
+		Peserta:    &postgresPesertaRepository{db: db},
+		Jawaban:    &postgresJawabanRepository{db: db},
+	}
+}
+
+// NewMemoryRepositories membuat semua repository di atas satu MemoryStore
+func NewMemoryRepositories(store *MemoryStore) Repositories {
+	return Repositories{
+		Jadwal:     store,
+		Ujian:      store,
+		Soal:       store,
+		Hasil:      store,
+		Kecurangan: store,
+		Siswa:      store,
+		Peserta:    store,
+		Jawaban:    store,
+	}
+}
+
+// kecuranganTypeDB memetakan tipe kecurangan dari client ke nilai enum TypeKecurangan di database
+func kecuranganTypeDB(t models.TypeKecurangan) string {
+	switch t {
+	case models.TabHidden:
+		return "tabHidden"
+	case models.Blurred:
+		return "blurred"
+	case models.SplitScreen:
+		return "splitScreen"
+	case models.FloatingWindow:
+		return "floatingWindow"
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"backend/models"
+	"database/sql"
+	"fmt"
+)
+
+type postgresSiswaRepository struct {
+	db *sql.DB
+}
+
+// GetSiswaDetail mengambil data siswa beserta kelasnya
+func (r *postgresSiswaRepository) GetSiswaDetail(siswaDetailID string) (*models.SiswaDetail, error) {
+	query := `
+		SELECT sd.id, sd.name, sd.nis, sd.kelamin, sd.nomor_ujian,
+		       k.id, k.tingkat, k.jurusan
+		FROM siswa_detail sd
+		JOIN kelas k ON sd."kelasId" = k.id
+		WHERE sd.id = $1
+	`
+	var siswa models.SiswaDetail
+	var jurusan sql.NullString
+	err := r.db.QueryRow(query, siswaDetailID).Scan(
+		&siswa.ID, &siswa.Nama, &siswa.NIS, &siswa.Kelamin, &siswa.NomorUjian,
+		&siswa.Kelas.ID, &siswa.Kelas.Tingkat, &jurusan,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying siswa: %w", err)
+	}
+
+	siswa.KelasID = siswa.Kelas.ID
+	siswa.Kelas.Jurusan = jurusan.String
+	siswa.Kelas.Nama = namaKelas(siswa.Kelas.Tingkat, siswa.Kelas.Jurusan)
+	return &siswa, nil
+}
+
+// namaKelas membentuk nama kelas seperti "X-RPL", sama dengan format di laporan hasil
+func namaKelas(tingkat, jurusan string) string {
+	if jurusan == "" {
+		return tingkat
+	}
+	return fmt.Sprintf("%s-%s", tingkat, jurusan)
+}
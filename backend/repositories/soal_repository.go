@@ -0,0 +1,554 @@
+package repositories
+
+import (
+	"backend/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type postgresSoalRepository struct {
+	db *sql.DB
+}
+
+// SimpanSoal menyimpan soal dan pilihan jawabannya ke mata pelajaran tingkat/pelajaran dalam satu transaksi,
+// mata pelajaran dibuat bila belum ada
+func (r *postgresSoalRepository) SimpanSoal(tingkat, pelajaran string, soalDataArr []models.SoalInput) error {
+	return r.SimpanSoalDanTimpa(tingkat, pelajaran, soalDataArr, nil)
+}
+
+// SimpanSoalDanTimpa menyimpan soal baru dan menimpa soal bank (dengan aturan UpdateSoal) dalam satu transaksi,
+// sehingga kegagalan menimpa satu soal tidak meninggalkan soal baru yang sudah tersimpan
+func (r *postgresSoalRepository) SimpanSoalDanTimpa(tingkat, pelajaran string, baru, timpa []models.SoalInput) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaksi: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := simpanSoalTx(tx, tingkat, pelajaran, baru); err != nil {
+		return err
+	}
+	for _, soal := range timpa {
+		if err := updateSoalTx(tx, soal); err != nil {
+			return fmt.Errorf("gagal menimpa soal %s: %w", soal.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal menyimpan data: %w", err)
+	}
+	return nil
+}
+
+func simpanSoalTx(tx *sql.Tx, tingkat, pelajaran string, soalDataArr []models.SoalInput) error {
+	mataPelajaranID, err := getOrCreateMataPelajaranInTx(tx, tingkat, pelajaran)
+	if err != nil {
+		return err
+	}
+
+	for i, soalInput := range soalDataArr {
+		soalID := uuid.New().String()
+		_, err = tx.Exec(`
+			INSERT INTO soal (id, gambar, soal, bobot, tipe, format, topik, kd, kesulitan, "jumlahPilihan", "mataPelajaranId")
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, soalID, soalInput.Gambar, soalInput.Soal, bobotSoal(soalInput.Bobot), tipeSoal(soalInput.Tipe), formatSoal(soalInput.Format),
+			nullString(soalInput.Topik), nullString(soalInput.KD), nullString(soalInput.Kesulitan), nullJumlahPilihan(soalInput.JumlahPilihan), mataPelajaranID)
+		if err != nil {
+			return fmt.Errorf("gagal menyimpan soal %d: %w", i+1, err)
+		}
+
+		for j, pilihan := range soalInput.Pilihan {
+			_, err = tx.Exec(`
+				INSERT INTO jawaban (id, "soalId", jawaban, benar)
+				VALUES ($1, $2, $3, $4)
+			`, uuid.New().String(), soalID, pilihan.Text, pilihanBenar(soalInput, pilihan))
+			if err != nil {
+				return fmt.Errorf("gagal menyimpan jawaban %d untuk soal %d: %w", j+1, i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetSoalUjian mengambil semua soal dari mata pelajaran ujian beserta pilihan dan kunci jawabannya
+func (r *postgresSoalRepository) GetSoalUjian(ujianID string) ([]models.SoalInput, error) {
+	return r.GetSoalUjianTercatat(ujianID, nil)
+}
+
+// GetSoalUjianTercatat sama dengan GetSoalUjian ditambah soal soalIDs yang sudah diarsipkan, dipakai untuk
+// menilai ulang dan menampilkan soal yang tercatat untuk siswa walaupun bank soal sudah berubah
+func (r *postgresSoalRepository) GetSoalUjianTercatat(ujianID string, soalIDs []string) ([]models.SoalInput, error) {
+	var mataPelajaranID string
+	err := r.db.QueryRow(`SELECT "mataPelajaranId" FROM ujian WHERE id = $1`, ujianID).Scan(&mataPelajaranID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying ujian: %w", err)
+	}
+	return r.soalMataPelajaran(mataPelajaranID, soalIDs)
+}
+
+// GetSoalMataPelajaran mengambil bank soal satu mata pelajaran beserta kuncinya, ErrNotFound bila
+// mata pelajaran belum pernah dibuat
+func (r *postgresSoalRepository) GetSoalMataPelajaran(tingkat, pelajaran string) ([]models.SoalInput, error) {
+	mp, err := r.GetMataPelajaran(tingkat, pelajaran)
+	if err != nil {
+		return nil, err
+	}
+	return r.soalMataPelajaran(mp.ID, nil)
+}
+
+// soalMataPelajaran soal yang belum dihapus, ditambah soal arsip yang ID-nya ada di arsip
+func (r *postgresSoalRepository) soalMataPelajaran(mataPelajaranID string, arsip []string) ([]models.SoalInput, error) {
+	// LEFT JOIN karena soal esai tidak punya pilihan, soal pilihan ganda tanpa pilihan tetap dilewati
+	rows, err := r.db.Query(`
+		SELECT s.id, s.soal, s.gambar, s.bobot, s.tipe, s.format, COALESCE(s.topik, ''), COALESCE(s.kd, ''), COALESCE(s.kesulitan::text, ''), COALESCE(s."jumlahPilihan", 0),
+		       j.id, j.jawaban, j.benar
+		FROM soal s
+		LEFT JOIN jawaban j ON j."soalId" = s.id
+		WHERE s."mataPelajaranId" = $1 AND (s."deletedAt" IS NULL OR s.id = ANY($2))
+		  AND (j.id IS NOT NULL OR s.tipe = 'ESAI')
+		ORDER BY s.id, j.id
+	`, mataPelajaranID, pq.Array(arsip))
+	if err != nil {
+		return nil, fmt.Errorf("error querying soal: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.SoalInput
+	index := make(map[string]int)
+	for rows.Next() {
+		var soal models.SoalInput
+		var gambar, pilihanID, pilihanText sql.NullString
+		var pilihanBenar sql.NullBool
+		if err := rows.Scan(&soal.ID, &soal.Soal, &gambar, &soal.Bobot, &soal.Tipe, &soal.Format, &soal.Topik, &soal.KD, &soal.Kesulitan, &soal.JumlahPilihan, &pilihanID, &pilihanText, &pilihanBenar); err != nil {
+			return nil, fmt.Errorf("error scanning soal: %w", err)
+		}
+
+		i, ok := index[soal.ID]
+		if !ok {
+			if gambar.Valid {
+				soal.Gambar = &gambar.String
+			}
+			result = append(result, soal)
+			i = len(result) - 1
+			index[soal.ID] = i
+		}
+		if pilihanID.Valid {
+			result[i].Pilihan = append(result[i].Pilihan, models.Pilihan{ID: pilihanID.String, Text: pilihanText.String, Benar: pilihanBenar.Bool})
+		}
+	}
+	return result, rows.Err()
+}
+
+// GetMataPelajaran mengambil pengaturan mata pelajaran, ErrNotFound bila belum pernah dibuat
+func (r *postgresSoalRepository) GetMataPelajaran(tingkat, pelajaran string) (*models.MataPelajaran, error) {
+	var mp models.MataPelajaran
+	err := r.db.QueryRow(`
+		SELECT id, tingkat, pelajaran, "penaltiSalah", "nilaiParsial", "jumlahPilihan"
+		FROM mata_pelajaran WHERE tingkat = $1 AND pelajaran = $2
+	`, tingkat, pelajaran).Scan(&mp.ID, &mp.Tingkat, &mp.Pelajaran, &mp.PenaltiSalah, &mp.NilaiParsial, &mp.JumlahPilihan)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mencari mata pelajaran: %w", err)
+	}
+	return &mp, nil
+}
+
+// SimpanPengaturanMataPelajaran menyimpan pengaturan mata pelajaran. Ujian yang sudah dimulai dan masih
+// mengikuti mata pelajaran dibekukan dengan nilai lama supaya nilai siswa yang sudah keluar tidak berubah
+// saat hasilnya dihitung ulang, misalnya ketika esai dinilai. Begitu juga soal pilihan ganda yang mengikuti
+// jumlah pilihan mata pelajaran, jumlah pilihannya sendiri dicatat agar tetap bisa diedit.
+func (r *postgresSoalRepository) SimpanPengaturanMataPelajaran(mp models.MataPelajaran) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaksi: %w", err)
+	}
+	defer tx.Rollback()
+
+	var lama models.MataPelajaran
+	err = tx.QueryRow(`
+		SELECT "penaltiSalah", "nilaiParsial", "jumlahPilihan" FROM mata_pelajaran WHERE id = $1 FOR UPDATE
+	`, mp.ID).Scan(&lama.PenaltiSalah, &lama.NilaiParsial, &lama.JumlahPilihan)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mencari mata pelajaran: %w", err)
+	}
+
+	if lama.PenaltiSalah != mp.PenaltiSalah {
+		if _, err := tx.Exec(`
+			UPDATE ujian SET "penaltiSalah" = $2
+			WHERE "mataPelajaranId" = $1 AND status <> 'pending' AND "penaltiSalah" IS NULL
+		`, mp.ID, lama.PenaltiSalah); err != nil {
+			return fmt.Errorf("gagal membekukan penalti ujian: %w", err)
+		}
+	}
+	if lama.NilaiParsial != mp.NilaiParsial {
+		if _, err := tx.Exec(`
+			UPDATE ujian SET "nilaiParsial" = $2
+			WHERE "mataPelajaranId" = $1 AND status <> 'pending' AND "nilaiParsial" IS NULL
+		`, mp.ID, lama.NilaiParsial); err != nil {
+			return fmt.Errorf("gagal membekukan nilai parsial ujian: %w", err)
+		}
+	}
+	if lama.JumlahPilihan != mp.JumlahPilihan {
+		if _, err := tx.Exec(`
+			UPDATE soal s SET "jumlahPilihan" = j.jumlah
+			FROM (SELECT "soalId", COUNT(*)::INTEGER AS jumlah FROM jawaban GROUP BY "soalId") j
+			WHERE j."soalId" = s.id AND s."mataPelajaranId" = $1 AND s."jumlahPilihan" IS NULL
+			  AND s.tipe IN ('PILIHAN_GANDA', 'PILIHAN_GANDA_KOMPLEKS') AND j.jumlah <> $2
+		`, mp.ID, mp.JumlahPilihan); err != nil {
+			return fmt.Errorf("gagal mencatat jumlah pilihan soal: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`
+		UPDATE mata_pelajaran SET "penaltiSalah" = $2, "nilaiParsial" = $3, "jumlahPilihan" = $4 WHERE id = $1
+	`, mp.ID, mp.PenaltiSalah, mp.NilaiParsial, mp.JumlahPilihan); err != nil {
+		return fmt.Errorf("gagal menyimpan pengaturan mata pelajaran: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal menyimpan data: %w", err)
+	}
+	return nil
+}
+
+// ListSoal mengambil satu halaman bank soal yang belum dihapus beserta jumlah semua soal yang cocok
+func (r *postgresSoalRepository) ListSoal(filter models.SoalFilter) ([]models.SoalDetail, int, error) {
+	where := []string{`s."deletedAt" IS NULL`}
+	var args []interface{}
+	if filter.Tingkat != "" {
+		args = append(args, filter.Tingkat)
+		where = append(where, fmt.Sprintf("mp.tingkat = $%d", len(args)))
+	}
+	if filter.Pelajaran != "" {
+		args = append(args, filter.Pelajaran)
+		where = append(where, fmt.Sprintf("mp.pelajaran = $%d", len(args)))
+	}
+	if filter.Cari != "" {
+		// % dan _ dari pencarian dicari apa adanya, bukan sebagai wildcard
+		cari := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Cari)
+		args = append(args, cari)
+		where = append(where, fmt.Sprintf("s.soal ILIKE '%%' || $%d || '%%'", len(args)))
+	}
+	from := `FROM soal s JOIN mata_pelajaran mp ON mp.id = s."mataPelajaranId" WHERE ` + strings.Join(where, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) `+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("error counting soal: %w", err)
+	}
+
+	args = append(args, filter.PerHalaman, (filter.Halaman-1)*filter.PerHalaman)
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT s.id, s.soal, s.gambar, s.bobot, s.tipe, s.format, COALESCE(s.topik, ''), COALESCE(s.kd, ''), COALESCE(s.kesulitan::text, ''), COALESCE(s."jumlahPilihan", 0),
+		       mp.tingkat, mp.pelajaran `+from+`
+		ORDER BY mp.tingkat, mp.pelajaran, s.id
+		LIMIT $%d OFFSET $%d
+	`, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error querying soal: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.SoalDetail{}
+	for rows.Next() {
+		soal, err := scanSoalDetail(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, soal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := r.isiPilihan(result); err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+// GetSoal mengambil satu soal bank soal beserta pilihannya, ErrNotFound bila tidak ada atau sudah dihapus
+func (r *postgresSoalRepository) GetSoal(id string) (*models.SoalDetail, error) {
+	row := r.db.QueryRow(`
+		SELECT s.id, s.soal, s.gambar, s.bobot, s.tipe, s.format, COALESCE(s.topik, ''), COALESCE(s.kd, ''), COALESCE(s.kesulitan::text, ''), COALESCE(s."jumlahPilihan", 0),
+		       mp.tingkat, mp.pelajaran
+		FROM soal s JOIN mata_pelajaran mp ON mp.id = s."mataPelajaranId"
+		WHERE s.id = $1 AND s."deletedAt" IS NULL
+	`, id)
+	soal, err := scanSoalDetail(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := []models.SoalDetail{soal}
+	if err := r.isiPilihan(result); err != nil {
+		return nil, err
+	}
+	return &result[0], nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSoalDetail(row scanner) (models.SoalDetail, error) {
+	var soal models.SoalDetail
+	var gambar sql.NullString
+	err := row.Scan(&soal.ID, &soal.Soal, &gambar, &soal.Bobot, &soal.Tipe, &soal.Format, &soal.Topik, &soal.KD, &soal.Kesulitan, &soal.JumlahPilihan, &soal.Tingkat, &soal.Pelajaran)
+	if err == sql.ErrNoRows {
+		return soal, err
+	}
+	if err != nil {
+		return soal, fmt.Errorf("error scanning soal: %w", err)
+	}
+	if gambar.Valid {
+		soal.Gambar = &gambar.String
+	}
+	return soal, nil
+}
+
+// isiPilihan mengisi pilihan semua soal dengan satu query
+func (r *postgresSoalRepository) isiPilihan(soalList []models.SoalDetail) error {
+	if len(soalList) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(soalList))
+	ids := make([]string, len(soalList))
+	for i, soal := range soalList {
+		index[soal.ID] = i
+		ids[i] = soal.ID
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, "soalId", jawaban, benar FROM jawaban WHERE "soalId" = ANY($1) ORDER BY id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error querying jawaban: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pilihan models.Pilihan
+		var soalID string
+		if err := rows.Scan(&pilihan.ID, &soalID, &pilihan.Text, &pilihan.Benar); err != nil {
+			return fmt.Errorf("error scanning jawaban: %w", err)
+		}
+		i := index[soalID]
+		soalList[i].Pilihan = append(soalList[i].Pilihan, pilihan)
+	}
+	return rows.Err()
+}
+
+// UpdateSoal mengganti isi soal dan menyamakan pilihannya: pilihan dengan ID lama diubah, pilihan tanpa ID
+// ditambahkan, dan pilihan lama yang tidak dikirim lagi dihapus. ErrNotFound bila soal tidak ada atau sudah dihapus,
+// ErrKunciSoalTerkunci bila soal sudah dijawab siswa dan perubahan menghapus pilihan atau mengubah kunci.
+func (r *postgresSoalRepository) UpdateSoal(soal models.SoalInput) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaksi: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateSoalTx(tx, soal); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal menyimpan data: %w", err)
+	}
+	return nil
+}
+
+func updateSoalTx(tx *sql.Tx, soal models.SoalInput) error {
+	// Baris soal dikunci supaya dua perubahan bersamaan tidak saling menimpa pemeriksaan kunci
+	var tipeLama string
+	var dijawab bool
+	err := tx.QueryRow(`
+		SELECT tipe, EXISTS(SELECT 1 FROM jawaban_siswa WHERE "soalId" = soal.id)
+		FROM soal WHERE id = $1 AND "deletedAt" IS NULL FOR UPDATE
+	`, soal.ID).Scan(&tipeLama, &dijawab)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal memeriksa soal: %w", err)
+	}
+
+	rows, err := tx.Query(`SELECT id, jawaban, benar FROM jawaban WHERE "soalId" = $1`, soal.ID)
+	if err != nil {
+		return fmt.Errorf("gagal membaca jawaban: %w", err)
+	}
+	lama := make(map[string]models.Jawaban)
+	for rows.Next() {
+		var j models.Jawaban
+		if err := rows.Scan(&j.ID, &j.Jawaban, &j.Benar); err != nil {
+			rows.Close()
+			return fmt.Errorf("gagal membaca jawaban: %w", err)
+		}
+		lama[j.ID] = j
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if dijawab && kunciBerubah(tipeLama, lama, soal) {
+		return ErrKunciSoalTerkunci
+	}
+
+	_, err = tx.Exec(`
+		UPDATE soal SET soal = $2, gambar = $3, bobot = $4, tipe = $5, format = $6, topik = $7, kd = $8, kesulitan = $9, "jumlahPilihan" = $10
+		WHERE id = $1
+	`, soal.ID, soal.Soal, soal.Gambar, bobotSoal(soal.Bobot), tipeSoal(soal.Tipe), formatSoal(soal.Format),
+		nullString(soal.Topik), nullString(soal.KD), nullString(soal.Kesulitan), nullJumlahPilihan(soal.JumlahPilihan))
+	if err != nil {
+		return fmt.Errorf("gagal mengubah soal: %w", err)
+	}
+
+	var dipakai []string
+	for j, pilihan := range soal.Pilihan {
+		if _, ok := lama[pilihan.ID]; ok {
+			_, err = tx.Exec(`UPDATE jawaban SET jawaban = $2, benar = $3 WHERE id = $1`,
+				pilihan.ID, pilihan.Text, pilihanBenar(soal, pilihan))
+			dipakai = append(dipakai, pilihan.ID)
+		} else {
+			_, err = tx.Exec(`
+				INSERT INTO jawaban (id, "soalId", jawaban, benar)
+				VALUES ($1, $2, $3, $4)
+			`, uuid.New().String(), soal.ID, pilihan.Text, pilihanBenar(soal, pilihan))
+		}
+		if err != nil {
+			return fmt.Errorf("gagal menyimpan jawaban %d: %w", j+1, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM jawaban WHERE "soalId" = $1 AND NOT (id = ANY($2))`, soal.ID, pq.Array(dipakai)); err != nil {
+		return fmt.Errorf("gagal menghapus jawaban lama: %w", err)
+	}
+	return nil
+}
+
+// HapusSoal menghapus soal beserta pilihannya. Soal yang sudah dijawab siswa hanya diarsipkan lewat deletedAt
+// supaya jawaban_siswa dan hasil lama tetap utuh, diarsipkan bernilai true untuk kasus ini.
+func (r *postgresSoalRepository) HapusSoal(id string) (diarsipkan bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("gagal memulai transaksi: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Soal yang pernah dikerjakan siswa (dijawab atau tercatat di soal peserta) diarsipkan
+	// supaya hasil lama tetap bisa dinilai ulang dan ditampilkan
+	var ada, dijawab bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM soal WHERE id = $1 AND "deletedAt" IS NULL),
+		       EXISTS(SELECT 1 FROM jawaban_siswa WHERE "soalId" = $1)
+		       OR EXISTS(SELECT 1 FROM ujian_peserta WHERE $1 = ANY("soalIds"))
+	`, id).Scan(&ada, &dijawab)
+	if err != nil {
+		return false, fmt.Errorf("gagal memeriksa soal: %w", err)
+	}
+	if !ada {
+		return false, ErrNotFound
+	}
+
+	if dijawab {
+		_, err = tx.Exec(`UPDATE soal SET "deletedAt" = NOW() WHERE id = $1`, id)
+	} else {
+		_, err = tx.Exec(`DELETE FROM soal WHERE id = $1`, id)
+	}
+	if err != nil {
+		return false, fmt.Errorf("gagal menghapus soal: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("gagal menyimpan data: %w", err)
+	}
+	return dijawab, nil
+}
+
+func getOrCreateMataPelajaranInTx(tx *sql.Tx, tingkat, pelajaran string) (string, error) {
+	var mataPelajaranID string
+	err := tx.QueryRow(`
+		SELECT id FROM mata_pelajaran WHERE tingkat = $1 AND pelajaran = $2
+	`, tingkat, pelajaran).Scan(&mataPelajaranID)
+	if err == nil {
+		return mataPelajaranID, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("gagal mencari mata pelajaran: %w", err)
+	}
+
+	mataPelajaranID = uuid.New().String()
+	_, err = tx.Exec(`
+		INSERT INTO mata_pelajaran (id, tingkat, pelajaran)
+		VALUES ($1, $2, $3)
+	`, mataPelajaranID, tingkat, pelajaran)
+	if err != nil {
+		return "", fmt.Errorf("gagal menyimpan mata pelajaran: %w", err)
+	}
+	return mataPelajaranID, nil
+}
+
+// bobotSoal bobot default untuk soal yang tidak mengisi bobot
+func bobotSoal(bobot int) int {
+	if bobot <= 0 {
+		return 1
+	}
+	return bobot
+}
+
+// nullJumlahPilihan menyimpan jumlah pilihan 0 sebagai NULL, artinya mengikuti mata pelajaran
+func nullJumlahPilihan(jumlah int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(jumlah), Valid: jumlah > 0}
+}
+
+// tipeSoal tipe default untuk soal yang tidak mengisi tipe
+func tipeSoal(tipe string) string {
+	if tipe == "" {
+		return models.TipePilihanGanda
+	}
+	return tipe
+}
+
+// formatSoal format default untuk soal yang tidak mengisi format
+func formatSoal(format string) string {
+	if format == "" {
+		return models.FormatPolos
+	}
+	return format
+}
+
+// pilihanBenar semua pilihan soal isian singkat adalah jawaban yang diterima, jadi selalu benar
+func pilihanBenar(soal models.SoalInput, pilihan models.Pilihan) bool {
+	return pilihan.Benar || soal.Tipe == models.TipeIsianSingkat
+}
+
+// kunciBerubah apakah perubahan soal menghapus pilihan lama atau mengubah cara jawaban dinilai: tipe soal,
+// tanda benar pilihan, pilihan benar yang baru, atau teks jawaban yang diterima pada isian singkat
+func kunciBerubah(tipeLama string, lama map[string]models.Jawaban, baru models.SoalInput) bool {
+	if tipeSoal(baru.Tipe) != tipeSoal(tipeLama) {
+		return true
+	}
+	dikirim := 0
+	for _, p := range baru.Pilihan {
+		j, ok := lama[p.ID]
+		if !ok {
+			if pilihanBenar(baru, p) {
+				return true
+			}
+			continue
+		}
+		dikirim++
+		if j.Benar != pilihanBenar(baru, p) || (baru.Tipe == models.TipeIsianSingkat && j.Jawaban != p.Text) {
+			return true
+		}
+	}
+	return dikirim != len(lama)
+}
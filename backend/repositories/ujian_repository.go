@@ -11,10 +11,51 @@ import (
 	"time"
 )
 
-// GetJadwalUjian mengambil jadwal ujian untuk 3 hari ke depan
-func GetJadwalUjian(db *sql.DB) (map[models.Tingkat][]models.TingkatData, error) {
-    result := make(map[models.Tingkat][]models.TingkatData)
-    today := time.Now()
+type postgresJadwalRepository struct {
+    db *sql.DB
+}
+
+func (r *postgresJadwalRepository) GetJadwalUjian(now time.Time) (map[models.Tingkat][]models.TingkatData, error) {
+    return GetJadwalUjian(r.db, now)
+}
+
+func (r *postgresJadwalRepository) GetUjianTerlewat(now time.Time) (models.ResponseUjianTerlewat, error) {
+    return GetUjianTerlewat(r.db, now)
+}
+
+func (r *postgresJadwalRepository) SimpanUjianSusulan(susulan models.UjianSusulanData) error {
+    return SimpanUjianSusulan(r.db, susulan)
+}
+
+func (r *postgresJadwalRepository) GetUjianSusulanAktif(now time.Time) ([]models.UjianSusulanData, error) {
+    return GetUjianSusulanAktif(r.db, now)
+}
+
+func (r *postgresJadwalRepository) HapusUjianSusulan(ujianIDs []string) error {
+    return HapusUjianSusulan(r.db, ujianIDs)
+}
+
+// jadwalRow satu baris hasil join jadwal, sesi, ujian, dan mata pelajaran
+type jadwalRow struct {
+    JadwalID        string
+    Tanggal         time.Time
+    Tingkat         string
+    SesiID          string
+    SesiNum         int
+    SesiJamMulai    sql.NullString
+    SesiJamSelesai  sql.NullString
+    UjianID         string
+    UjianJamMulai   sql.NullString
+    UjianJamSelesai sql.NullString
+    Status          string
+    Token           sql.NullString
+    WaktuPengerjaan sql.NullInt64
+    MataPelajaran   string
+}
+
+// GetJadwalUjian mengambil jadwal ujian untuk 3 hari ke depan dihitung dari now
+func GetJadwalUjian(db *sql.DB, now time.Time) (map[models.Tingkat][]models.TingkatData, error) {
+    today := now
     query := `
         SELECT j.id, j.tanggal, j.tingkat,  
         s.id as sesi_id, s.sesi, s."jamMulai" as sesi_jam_mulai, s."jamSelesai" as sesi_jam_selesai, 
@@ -33,26 +74,36 @@ func GetJadwalUjian(db *sql.DB) (map[models.Tingkat][]models.TingkatData, error)
         return nil, fmt.Errorf("error querying jadwal: %v", err)
     }
     defer rows.Close()
-    jadwalMap := make(map[string]*models.TingkatData)
-    sesiMap := make(map[string]*models.SesiData)
-    tingkatTanggalMap := make(map[models.Tingkat]map[string]bool)
+    var jadwalRows []jadwalRow
     for rows.Next() {
-        var jadwalID, sesiID, ujianID, mataPelajaran string
-        var tanggal time.Time
-        var tingkatStr, status string
-        var sesiNum int
-        var sesiJamMulai, sesiJamSelesai, ujianJamMulai, ujianJamSelesai, token sql.NullString
-        var waktuPengerjaan sql.NullInt64
+        var row jadwalRow
         err := rows.Scan(
-            &jadwalID, &tanggal, &tingkatStr,
-            &sesiID, &sesiNum, &sesiJamMulai, &sesiJamSelesai,
-            &ujianID, &ujianJamMulai, &ujianJamSelesai, &status, &token, &waktuPengerjaan,
-            &mataPelajaran,
+            &row.JadwalID, &row.Tanggal, &row.Tingkat,
+            &row.SesiID, &row.SesiNum, &row.SesiJamMulai, &row.SesiJamSelesai,
+            &row.UjianID, &row.UjianJamMulai, &row.UjianJamSelesai, &row.Status, &row.Token, &row.WaktuPengerjaan,
+            &row.MataPelajaran,
         )
         if err != nil {
             log.Printf("Error scanning row: %v", err)
             continue
         }
+        jadwalRows = append(jadwalRows, row)
+    }
+    return susunJadwalUjian(jadwalRows, today), nil
+}
+
+// susunJadwalUjian mengelompokkan baris jadwal per tingkat, tanggal, dan sesi
+func susunJadwalUjian(jadwalRows []jadwalRow, today time.Time) map[models.Tingkat][]models.TingkatData {
+    result := make(map[models.Tingkat][]models.TingkatData)
+    jadwalMap := make(map[string]*models.TingkatData)
+    sesiMap := make(map[string]*models.SesiData)
+    tingkatTanggalMap := make(map[models.Tingkat]map[string]bool)
+    for _, row := range jadwalRows {
+        sesiID, ujianID, mataPelajaran := row.SesiID, row.UjianID, row.MataPelajaran
+        tanggal, tingkatStr, status, sesiNum := row.Tanggal, row.Tingkat, row.Status, row.SesiNum
+        sesiJamMulai, sesiJamSelesai := row.SesiJamMulai, row.SesiJamSelesai
+        ujianJamMulai, ujianJamSelesai, token := row.UjianJamMulai, row.UjianJamSelesai, row.Token
+        waktuPengerjaan := row.WaktuPengerjaan
         tingkat := models.Tingkat(tingkatStr)
         tanggalStr := tanggal.Format("2006-01-02")
         sisaHari := int(tanggal.Sub(today).Hours() / 24)
@@ -151,7 +202,7 @@ func GetJadwalUjian(db *sql.DB) (map[models.Tingkat][]models.TingkatData, error)
         }
         result[tingkat] = tingkatDataArr
     }
-    return result, nil
+    return result
 }
 
 
@@ -174,27 +225,24 @@ func parseFlexibleDate(dateStr string) (time.Time, error) {
     return time.Time{}, fmt.Errorf("cannot parse date: %s", dateStr)
 }
 
-func parseTime(timeStr string) (time.Time, error) {
-    now := time.Now()
+// parseTime mengubah jam "15:04" menjadi waktu pada tanggal dan zona yang sama dengan now
+func parseTime(now time.Time, timeStr string) (time.Time, error) {
     timeFormat := "15:04"
     t, err := time.Parse(timeFormat, timeStr)
     if err != nil {
         return time.Time{}, err
     }
     
-    location := time.Local
-    return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, location), nil
+    return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), nil
 }
 
-func GetUjianTerlewat(db *sql.DB) (models.ResponseUjianTerlewat, error) {
+func GetUjianTerlewat(db *sql.DB, now time.Time) (models.ResponseUjianTerlewat, error) {
     result := models.ResponseUjianTerlewat{
         X:   []models.UjianTerlewat{},
         XI:  []models.UjianTerlewat{},
         XII: []models.UjianTerlewat{},
     }
 
-    now := time.Now().In(time.Local)
-    
     startDate := now.AddDate(0, 0, -30).Format("2006-01-02")
     endDate := now.Format("2006-01-02")
 
@@ -229,7 +277,7 @@ func GetUjianTerlewat(db *sql.DB) (models.ResponseUjianTerlewat, error) {
     }
     defer rows.Close()
 
-    ujianGrouped := make(map[string]map[string][]UjianData)
+    var ujianRows []UjianData
     totalRows := 0
 
     for rows.Next() {
@@ -255,14 +303,6 @@ func GetUjianTerlewat(db *sql.DB) (models.ResponseUjianTerlewat, error) {
             continue
         }
 
-        tanggalStr := tanggal.Format("2006-01-02")
-        tingkatKey := tingkatStr
-        tanggalKey := tanggalStr
-        
-        if ujianGrouped[tingkatKey] == nil {
-            ujianGrouped[tingkatKey] = make(map[string][]UjianData)
-        }
-        
         ujianData := UjianData{
             Tanggal:         tanggal,
             TingkatStr:      tingkatStr,
@@ -277,14 +317,28 @@ func GetUjianTerlewat(db *sql.DB) (models.ResponseUjianTerlewat, error) {
             Pelajaran:       pelajaran,
         }
         
-        ujianGrouped[tingkatKey][tanggalKey] = append(ujianGrouped[tingkatKey][tanggalKey], ujianData)
+        ujianRows = append(ujianRows, ujianData)
     }
 
+    return susunUjianTerlewat(ujianRows, now), nil
+}
+
+// susunUjianTerlewat mengelompokkan ujian per tingkat dan tanggal lalu memilih yang sudah terlewat
+func susunUjianTerlewat(ujianRows []UjianData, now time.Time) models.ResponseUjianTerlewat {
+    result := models.ResponseUjianTerlewat{
+        X:   []models.UjianTerlewat{},
+        XI:  []models.UjianTerlewat{},
+        XII: []models.UjianTerlewat{},
+    }
 
-    currentTrackingData, err := GetJadwalUjian(db)
-    if err != nil {
-        log.Printf("DEBUG: Error getting current tracking data: %v", err)
-        return result, nil
+    ujianGrouped := make(map[string]map[string][]UjianData)
+    for _, ujianData := range ujianRows {
+        tingkatKey := ujianData.TingkatStr
+        tanggalKey := ujianData.Tanggal.Format("2006-01-02")
+        if ujianGrouped[tingkatKey] == nil {
+            ujianGrouped[tingkatKey] = make(map[string][]UjianData)
+        }
+        ujianGrouped[tingkatKey][tanggalKey] = append(ujianGrouped[tingkatKey][tanggalKey], ujianData)
     }
 
     for tingkatStr, tanggalMap := range ujianGrouped {
@@ -293,7 +347,7 @@ func GetUjianTerlewat(db *sql.DB) (models.ResponseUjianTerlewat, error) {
         for tanggalStr, ujianList := range tanggalMap {
             tanggal, _ := parseFlexibleDate(tanggalStr + "T00:00:00Z")
             
-            ujianTerlewat := processUjianForDate(db, now, tanggal, ujianList, currentTrackingData, tingkat)
+            ujianTerlewat := processUjianForDate(now, tanggal, ujianList)
             switch tingkat {
             case models.TingkatX:
                 result.X = append(result.X, ujianTerlewat...)
@@ -306,7 +360,7 @@ func GetUjianTerlewat(db *sql.DB) (models.ResponseUjianTerlewat, error) {
         }
     }
 
-    return result, nil
+    return result
 }
 
 type UjianData struct {
@@ -323,8 +377,7 @@ type UjianData struct {
     Pelajaran       string
 }
 
-func processUjianForDate(db *sql.DB, now time.Time, tanggal time.Time, ujianList []UjianData, 
-    currentTrackingData map[models.Tingkat][]models.TingkatData, tingkat models.Tingkat) []models.UjianTerlewat {
+func processUjianForDate(now time.Time, tanggal time.Time, ujianList []UjianData) []models.UjianTerlewat {
     
     var result []models.UjianTerlewat
     
@@ -441,8 +494,8 @@ func analyzeSesiTransition(ujianList []UjianData, now time.Time) SesiTransitionI
             continue
         }
         
-        sesiMulai, errMulai := parseTime(ujianInSesi[0].SesiJamMulai)
-        sesiSelesai, errSelesai := parseTime(ujianInSesi[0].SesiJamSelesai)
+        sesiMulai, errMulai := parseTime(now, ujianInSesi[0].SesiJamMulai)
+        sesiSelesai, errSelesai := parseTime(now, ujianInSesi[0].SesiJamSelesai)
         
         if errMulai != nil || errSelesai != nil {
             continue
@@ -488,7 +541,7 @@ func isSesiStillDisplayed(sesiNumber int, transisiInfo SesiTransitionInfo, ujian
         sesiBerikutnya := transisiInfo.SesiNumbers[transisiInfo.SesiBerikutnyaIndex]
         ujianSesiBerikutnya := transisiInfo.SesiDataMap[sesiBerikutnya]
         if len(ujianSesiBerikutnya) > 0 {
-            sesiBerikutnyaMulai, err := parseTime(ujianSesiBerikutnya[0].SesiJamMulai)
+            sesiBerikutnyaMulai, err := parseTime(now, ujianSesiBerikutnya[0].SesiJamMulai)
             if err != nil {
                 return false
             }
@@ -497,7 +550,7 @@ func isSesiStillDisplayed(sesiNumber int, transisiInfo SesiTransitionInfo, ujian
                 return sesiIndex == transisiInfo.SesiBerikutnyaIndex
             } else if transisiInfo.SesiTerakhirIndex != -1 && sesiIndex == transisiInfo.SesiTerakhirIndex {
                 if transisiInfo.IsLastSession {
-                    sesiSelesai, err := parseTime(ujianInSesi[0].SesiJamSelesai)
+                    sesiSelesai, err := parseTime(now, ujianInSesi[0].SesiJamSelesai)
                     if err != nil {
                         return false
                     }
@@ -511,7 +564,7 @@ func isSesiStillDisplayed(sesiNumber int, transisiInfo SesiTransitionInfo, ujian
         }
     } else if transisiInfo.SesiTerakhirIndex != -1 && sesiIndex == transisiInfo.SesiTerakhirIndex {
         if transisiInfo.IsLastSession {
-            sesiSelesai, err := parseTime(ujianInSesi[0].SesiJamSelesai)
+            sesiSelesai, err := parseTime(now, ujianInSesi[0].SesiJamSelesai)
             if err != nil {
                 return false
             }
@@ -533,7 +586,7 @@ func isUjianSelesai(now time.Time, tanggal time.Time, ujian UjianData) bool {
         return false
     }
     
-    ujianSelesai, err := parseTime(ujian.UjianJamSelesai)
+    ujianSelesai, err := parseTime(now, ujian.UjianJamSelesai)
     if err != nil {
         return false
     }
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+)
+
+var hariUjian = time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+func pukul(jam, menit int) time.Time {
+	return hariUjian.Add(time.Duration(jam)*time.Hour + time.Duration(menit)*time.Minute)
+}
+
+func ujianDuaSesi() []UjianData {
+	return []UjianData{
+		{Tanggal: hariUjian, TingkatStr: "X", SesiID: "sesi-1", Sesi: 1, SesiJamMulai: "07:30", SesiJamSelesai: "09:30",
+			UjianID: "ujian-mtk", UjianJamMulai: "07:30", UjianJamSelesai: "09:30", Status: "pending", Pelajaran: "MTK"},
+		{Tanggal: hariUjian, TingkatStr: "X", SesiID: "sesi-2", Sesi: 2, SesiJamMulai: "10:00", SesiJamSelesai: "12:00",
+			UjianID: "ujian-bindo", UjianJamMulai: "10:00", UjianJamSelesai: "12:00", Status: "pending", Pelajaran: "BIndo"},
+	}
+}
+
+func TestIsSesiStillDisplayed(t *testing.T) {
+	ujianList := ujianDuaSesi()
+	tests := []struct {
+		name  string
+		now   time.Time
+		sesi1 bool
+		sesi2 bool
+	}{
+		{"sebelum jendela 5 menit", pukul(7, 0), false, false},
+		{"jendela 5 menit sebelum sesi 1", pukul(7, 26), true, false},
+		{"sesi 1 berjalan", pukul(8, 0), true, false},
+		{"jeda antar sesi", pukul(9, 40), true, false},
+		{"jendela 5 menit sebelum sesi 2", pukul(9, 56), false, true},
+		{"sesi 2 berjalan", pukul(10, 30), false, true},
+		{"setelah sesi terakhir", pukul(12, 30), false, true},
+		{"reset 120 menit setelah sesi terakhir", pukul(14, 1), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := analyzeSesiTransition(ujianList, tt.now)
+			got1 := isSesiStillDisplayed(1, info, info.SesiDataMap[1], tt.now)
+			got2 := isSesiStillDisplayed(2, info, info.SesiDataMap[2], tt.now)
+			if got1 != tt.sesi1 || got2 != tt.sesi2 {
+				t.Errorf("displayed = (%t, %t), want (%t, %t)", got1, got2, tt.sesi1, tt.sesi2)
+			}
+		})
+	}
+}
+
+func TestProcessUjianForDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		terlewat []string
+	}{
+		{"sesi 1 berjalan", pukul(8, 0), nil},
+		{"sesi 1 masih tampil di jeda", pukul(9, 40), nil},
+		{"sesi 1 tergeser sesi 2", pukul(9, 56), []string{"ujian-mtk"}},
+		{"setelah reset", pukul(14, 1), []string{"ujian-mtk", "ujian-bindo"}},
+		{"hari berikutnya", pukul(24+8, 0), []string{"ujian-mtk", "ujian-bindo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processUjianForDate(tt.now, hariUjian, ujianDuaSesi())
+			var got []string
+			for _, sesi := range result {
+				for _, ujian := range sesi.Ujian {
+					got = append(got, ujian.ID)
+				}
+			}
+			if len(got) != len(tt.terlewat) {
+				t.Fatalf("terlewat = %v, want %v", got, tt.terlewat)
+			}
+			for i := range got {
+				if got[i] != tt.terlewat[i] {
+					t.Fatalf("terlewat = %v, want %v", got, tt.terlewat)
+				}
+			}
+		})
+	}
+}
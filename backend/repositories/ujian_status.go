@@ -1,10 +1,16 @@
 package repositories
 
 import (
+	"backend/models"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"log"
 )
 
+type postgresUjianRepository struct {
+	db *sql.DB
+}
 
 func UpdateUjianStatus(db *sql.DB, ujianID string, status string, token string) error {
 	query := `UPDATE ujian SET status = $1, token = $2 WHERE id = $3`
@@ -13,4 +19,230 @@ func UpdateUjianStatus(db *sql.DB, ujianID string, status string, token string)
 		return fmt.Errorf("error updating ujian status: %w", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *postgresUjianRepository) UpdateUjianStatus(ujianID, status, token string) error {
+	return UpdateUjianStatus(r.db, ujianID, status, token)
+}
+
+// GetUjian mengambil ujian beserta mata pelajarannya
+func (r *postgresUjianRepository) GetUjian(ujianID string) (*models.Ujian, error) {
+	query := `
+		SELECT u.id, u."waktuPengerjaan", u.token, u.status, u."sesiId",
+		       COALESCE(u."penaltiSalah", mp."penaltiSalah"), COALESCE(u."nilaiParsial", mp."nilaiParsial"),
+		       COALESCE(u."jumlahSoal", 0), u."kuotaSoal", u."tampilkanKunci",
+		       mp.id, mp.pelajaran, mp.tingkat, mp."penaltiSalah", mp."nilaiParsial"
+		FROM ujian u
+		JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp.id
+		WHERE u.id = $1
+	`
+	var ujian models.Ujian
+	var waktuPengerjaan sql.NullInt64
+	var token, sesiID sql.NullString
+	var kuotaSoal []byte
+	err := r.db.QueryRow(query, ujianID).Scan(
+		&ujian.ID, &waktuPengerjaan, &token, &ujian.Status, &sesiID,
+		&ujian.PenaltiSalah, &ujian.NilaiParsial,
+		&ujian.JumlahSoal, &kuotaSoal, &ujian.TampilkanKunci,
+		&ujian.MataPelajaran.ID, &ujian.MataPelajaran.Pelajaran, &ujian.MataPelajaran.Tingkat, &ujian.MataPelajaran.PenaltiSalah, &ujian.MataPelajaran.NilaiParsial,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying ujian: %w", err)
+	}
+
+	ujian.WaktuPengerjaan = waktuPengerjaanDefault(waktuPengerjaan)
+	ujian.Token = token.String
+	ujian.SesiID = sesiID.String
+	if len(kuotaSoal) > 0 {
+		if err := json.Unmarshal(kuotaSoal, &ujian.KuotaSoal); err != nil {
+			return nil, fmt.Errorf("error decoding kuota soal ujian %s: %w", ujianID, err)
+		}
+	}
+	return &ujian, nil
+}
+
+// SimpanPengaturanSoal menyimpan jumlah soal dan kuota undian, jumlah 0 dan kuota kosong disimpan sebagai NULL
+func (r *postgresUjianRepository) SimpanPengaturanSoal(ujianID string, pengaturan models.PengaturanSoalRequest) error {
+	var jumlahSoal, kuotaSoal interface{}
+	if pengaturan.JumlahSoal > 0 {
+		jumlahSoal = pengaturan.JumlahSoal
+	}
+	if len(pengaturan.KuotaSoal) > 0 {
+		data, err := json.Marshal(pengaturan.KuotaSoal)
+		if err != nil {
+			return err
+		}
+		kuotaSoal = data
+	}
+	res, err := r.db.Exec(`UPDATE ujian SET "jumlahSoal" = $2, "kuotaSoal" = $3 WHERE id = $1`, ujianID, jumlahSoal, kuotaSoal)
+	if err != nil {
+		return fmt.Errorf("error updating pengaturan soal: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SimpanPengaturanPenilaian menyimpan penilaian khusus ujian, nilai nil disimpan sebagai NULL (mengikuti mata pelajaran)
+func (r *postgresUjianRepository) SimpanPengaturanPenilaian(ujianID string, pengaturan models.PengaturanPenilaianRequest) error {
+	res, err := r.db.Exec(`UPDATE ujian SET "penaltiSalah" = $2, "nilaiParsial" = $3 WHERE id = $1`, ujianID, pengaturan.PenaltiSalah, pengaturan.NilaiParsial)
+	if err != nil {
+		return fmt.Errorf("error updating pengaturan penilaian: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SimpanTampilkanKunci mengatur apakah kunci jawaban ditampilkan di lembar jawaban siswa
+func (r *postgresUjianRepository) SimpanTampilkanKunci(ujianID string, tampilkan bool) error {
+	res, err := r.db.Exec(`UPDATE ujian SET "tampilkanKunci" = $2 WHERE id = $1`, ujianID, tampilkan)
+	if err != nil {
+		return fmt.Errorf("error updating tampilkan kunci: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SelesaikanUjian mengubah status ujian menjadi 'selesai' dalam satu transaksi
+func (r *postgresUjianRepository) SelesaikanUjian(ujianIDs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal mulai transaksi: %w", err)
+	}
+
+	for _, id := range ujianIDs {
+		if err := updateUjianStatusInTx(tx, id, "selesai"); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("gagal update status ujian ID %s: %w", id, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AktifkanUjianSusulan mengaktifkan kembali ujian untuk susulan dan mengembalikan detailnya
+func (r *postgresUjianRepository) AktifkanUjianSusulan(ujianID string) (*models.UjianData, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mulai transaksi: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateUjianStatusInTx(tx, ujianID, "active"); err != nil {
+		return nil, fmt.Errorf("gagal memperbarui status ujian: %w", err)
+	}
+
+	ujianDetail, err := getUjianDetailInTx(tx, ujianID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("gagal commit transaksi: %w", err)
+	}
+	return ujianDetail, nil
+}
+
+// GetUjianPendingByTingkat mengambil ujian pending yang sudah terjadwal di sesi untuk satu tingkat
+func (r *postgresUjianRepository) GetUjianPendingByTingkat(tingkat string) ([]models.UjianData, error) {
+	query := `
+		SELECT DISTINCT u.id, u."jamMulai", mp.pelajaran
+		FROM ujian u
+		JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp.id
+		JOIN sesi s ON u."sesiId" = s.id
+		WHERE mp.tingkat = $1
+		AND u.status = 'pending'
+		AND u."jamMulai" IS NOT NULL
+		AND u."sesiId" IS NOT NULL
+	`
+	rows, err := r.db.Query(query, tingkat)
+	if err != nil {
+		return nil, fmt.Errorf("error querying pending ujian: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.UjianData
+	for rows.Next() {
+		var ujian models.UjianData
+		if err := rows.Scan(&ujian.ID, &ujian.JamMulai, &ujian.MataPelajaran); err != nil {
+			log.Printf("Error scanning session row: %v", err)
+			continue
+		}
+		ujian.Status = "pending"
+		result = append(result, ujian)
+	}
+	return result, rows.Err()
+}
+
+func updateUjianStatusInTx(tx *sql.Tx, ujianId string, status string) error {
+	query := "UPDATE ujian SET status = $1 WHERE id = $2"
+	result, err := tx.Exec(query, status, ujianId)
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui status ujian: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	log.Printf("DEBUG: Updated %d rows for ujian ID: %s in transaction", rowsAffected, ujianId)
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no rows affected - ujian ID might not exist: %s", ujianId)
+	}
+
+	return nil
+}
+
+func getUjianDetailInTx(tx *sql.Tx, ujianId string) (*models.UjianData, error) {
+	query := `
+		SELECT u.id, u."jamMulai", u."jamSelesai", u.status, u.token, u."waktuPengerjaan",
+		       mp.pelajaran
+		FROM ujian u
+		JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp.id
+		WHERE u.id = $1
+	`
+	var ujianData models.UjianData
+	var ujianJamMulai, ujianJamSelesai, token sql.NullString
+	var waktuPengerjaan sql.NullInt64
+	err := tx.QueryRow(query, ujianId).Scan(
+		&ujianData.ID,
+		&ujianJamMulai,
+		&ujianJamSelesai,
+		&ujianData.Status,
+		&token,
+		&waktuPengerjaan,
+		&ujianData.MataPelajaran,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("ujian not found with ID: %s", ujianId)
+		}
+		return nil, fmt.Errorf("gagal mendapatkan detail ujian: %w", err)
+	}
+	ujianData.JamMulai = ujianJamMulai.String
+	ujianData.JamSelesai = ujianJamSelesai.String
+	ujianData.Token = token.String
+	ujianData.WaktuPengerjaan = waktuPengerjaanDefault(waktuPengerjaan)
+	ujianData.IsUjianSusulan = true
+	return &ujianData, nil
+}
+
+// waktuPengerjaanDefault memakai 90 menit bila ujian belum diberi waktu pengerjaan
+func waktuPengerjaanDefault(waktuPengerjaan sql.NullInt64) int {
+	if waktuPengerjaan.Valid {
+		return int(waktuPengerjaan.Int64)
+	}
+	return 90
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"backend/models"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// SimpanUjianSusulan menyimpan registrasi ujian susulan agar tidak hilang saat backend restart
+func SimpanUjianSusulan(db *sql.DB, susulan models.UjianSusulanData) error {
+	var sesiID sql.NullString
+	if susulan.SesiId != "" {
+		sesiID = sql.NullString{String: susulan.SesiId, Valid: true}
+	}
+
+	query := `
+		INSERT INTO ujian_susulan ("id", "ujianId", "tingkat", "sesiId", "waktuDibuat", "waktuBerakhir")
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := db.Exec(query,
+		uuid.New().String(), susulan.UjianData.ID, string(susulan.Tingkat), sesiID,
+		susulan.WaktuDibuat.UTC(), susulan.WaktuBerakhir.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving ujian susulan: %w", err)
+	}
+	return nil
+}
+
+// GetUjianSusulanAktif mengambil ujian susulan yang belum berakhir pada waktu now
+func GetUjianSusulanAktif(db *sql.DB, now time.Time) ([]models.UjianSusulanData, error) {
+	query := `
+		SELECT us."tingkat", us."sesiId", us."waktuDibuat", us."waktuBerakhir",
+		       u.id, u."jamMulai", u."jamSelesai", u.status, u.token, u."waktuPengerjaan",
+		       mp.pelajaran
+		FROM ujian_susulan us
+		JOIN ujian u ON us."ujianId" = u.id
+		JOIN mata_pelajaran mp ON u."mataPelajaranId" = mp.id
+		WHERE us."waktuBerakhir" >= $1
+		ORDER BY us."waktuDibuat"
+	`
+	rows, err := db.Query(query, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error querying ujian susulan: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.UjianSusulanData
+	for rows.Next() {
+		var susulan models.UjianSusulanData
+		var tingkat string
+		var sesiID, jamMulai, jamSelesai, token sql.NullString
+		var waktuPengerjaan sql.NullInt64
+
+		if err := rows.Scan(
+			&tingkat, &sesiID, &susulan.WaktuDibuat, &susulan.WaktuBerakhir,
+			&susulan.UjianData.ID, &jamMulai, &jamSelesai, &susulan.UjianData.Status, &token, &waktuPengerjaan,
+			&susulan.UjianData.MataPelajaran,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning ujian susulan: %w", err)
+		}
+
+		susulan.Tingkat = models.Tingkat(tingkat)
+		susulan.SesiId = sesiID.String
+		susulan.WaktuDibuat = susulan.WaktuDibuat.In(time.Local)
+		susulan.WaktuBerakhir = susulan.WaktuBerakhir.In(time.Local)
+
+		susulan.UjianData.JamMulai = jamMulai.String
+		susulan.UjianData.JamSelesai = jamSelesai.String
+		susulan.UjianData.Token = token.String
+		susulan.UjianData.WaktuPengerjaan = waktuPengerjaanDefault(waktuPengerjaan)
+		susulan.UjianData.IsUjianSusulan = true
+		susulan.UjianData.WaktuDibuat = susulan.WaktuDibuat
+		susulan.UjianData.WaktuBerakhir = susulan.WaktuBerakhir
+		susulan.UjianData.TiedToSesiID = susulan.SesiId
+
+		result = append(result, susulan)
+	}
+	return result, rows.Err()
+}
+
+// HapusUjianSusulan menghapus registrasi ujian susulan untuk ujian yang sudah dibersihkan tracker
+func HapusUjianSusulan(db *sql.DB, ujianIDs []string) error {
+	if len(ujianIDs) == 0 {
+		return nil
+	}
+	_, err := db.Exec(`DELETE FROM ujian_susulan WHERE "ujianId" = ANY($1)`, pq.Array(ujianIDs))
+	if err != nil {
+		return fmt.Errorf("error deleting ujian susulan: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"backend/models"
+	"hash/fnv"
+	"math/rand"
+)
+
+// AcakSoal mengacak urutan soal dan pilihan jawaban secara deterministik per siswa,
+// sehingga siswa yang sama selalu mendapat urutan yang sama saat memuat ulang halaman
+// sedangkan siswa di sebelahnya mendapat urutan berbeda. Kunci jawaban dibuang.
+func AcakSoal(soalList []models.SoalInput, ujianID, siswaDetailID string) []models.SoalUjian {
+	result := make([]models.SoalUjian, len(soalList))
+	for i, soal := range soalList {
+		// Soal isian singkat dan esai dijawab dengan teks, pilihannya (jawaban yang diterima) tidak dikirim
+		pilihan := []models.PilihanUjian{}
+		if soal.Tipe != models.TipeIsianSingkat && soal.Tipe != models.TipeEsai {
+			pilihan = make([]models.PilihanUjian, len(soal.Pilihan))
+			for j, p := range soal.Pilihan {
+				pilihan[j] = models.PilihanUjian{ID: p.ID, Text: p.Text}
+			}
+		}
+		// Urutan pilihan diacak per soal agar tidak bergeser ketika soal lain ditambah
+		acak(pilihan, ujianID, siswaDetailID, soal.ID)
+
+		result[i] = models.SoalUjian{
+			ID:      soal.ID,
+			Soal:    soal.Soal,
+			Gambar:  soal.Gambar,
+			Tipe:    soal.Tipe,
+			Format:  soal.Format,
+			Pilihan: pilihan,
+		}
+	}
+	acak(result, ujianID, siswaDetailID)
+	return result
+}
+
+func acak[T any](items []T, kunci ...string) {
+	h := fnv.New64a()
+	for _, k := range kunci {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+	}
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+	rng.Shuffle(len(items), func(a, b int) { items[a], items[b] = items[b], items[a] })
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"backend/models"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// PesertaAnalisis soal yang dikerjakan seorang siswa beserta jawabannya, masukan AnalisisButir
+type PesertaAnalisis struct {
+	SiswaDetailID string
+	Soal          []models.SoalInput
+	Jawaban       []models.JawabanSiswa
+}
+
+const (
+	// ProporsiKelompok bagian peserta dengan skor tertinggi/terendah yang menjadi kelompok atas/bawah
+	ProporsiKelompok = 0.27
+	// MinProporsiPengecoh proporsi pemilih minimal agar pengecoh dianggap berfungsi
+	MinProporsiPengecoh = 0.05
+)
+
+// skorAnalisis skor seorang peserta per soal. Esai yang belum dinilai tidak punya skor dan tidak ikut dihitung.
+type skorAnalisis struct {
+	siswaDetailID string
+	skor          map[string]float64  // soalId -> bagian bobot yang didapat (0-1)
+	dipilih       map[string][]string // soalId -> jawabanId yang dipilih
+	kosong        map[string]bool     // soal yang tidak dijawab
+	jumlahSoal    int                 // jumlah soal hasil undian peserta
+	total         float64             // rata-rata skor soal yang sudah dinilai, supaya peserta dengan undian berbeda tetap sebanding
+	lengkap       bool                // semua soal peserta sudah dinilai
+}
+
+// AnalisisButir menghitung tingkat kesukaran, daya beda, sebaran pilihan jawaban, dan reliabilitas KR-20
+// dari jawaban peserta yang sudah mengumpulkan. Setiap soal dihitung dari bagian skor yang didapat (0-1)
+// tanpa bobot dan penalti, sehingga soal pilihan ganda kompleks dengan nilai parsial dan esai ikut dianalisis.
+func AnalisisButir(ujian *models.Ujian, bank []models.SoalInput, peserta []PesertaAnalisis) models.AnalisisUjian {
+	kunci := NewKunciJawaban(bank, ujian)
+	result := models.AnalisisUjian{
+		UjianID:       ujian.ID,
+		MataPelajaran: ujian.MataPelajaran.Pelajaran,
+		Tingkat:       ujian.MataPelajaran.Tingkat,
+		JumlahPeserta: len(peserta),
+		Soal:          []models.AnalisisSoal{},
+	}
+
+	skor := make([]skorAnalisis, 0, len(peserta))
+	dinilai := 0
+	belumDinilai := make(map[string]int)
+	didapat := make(map[string]bool)
+	for _, p := range peserta {
+		s := skorAnalisis{
+			siswaDetailID: p.SiswaDetailID,
+			skor:          make(map[string]float64, len(p.Soal)),
+			dipilih:       make(map[string][]string),
+			kosong:        make(map[string]bool),
+			jumlahSoal:    len(p.Soal),
+			lengkap:       true,
+		}
+		jawabanSoal := make(map[string]models.JawabanSiswa, len(p.Jawaban))
+		for _, js := range p.Jawaban {
+			jawabanSoal[js.SoalID] = js
+		}
+		var jumlah float64
+		for _, soal := range p.Soal {
+			didapat[soal.ID] = true
+			status, kredit := StatusKosong, 0.0
+			js, ok := jawabanSoal[soal.ID]
+			if ok {
+				status, kredit = kunci.StatusJawaban(js)
+			}
+			if status == StatusBelumDinilai {
+				belumDinilai[soal.ID]++
+				s.lengkap = false
+				continue
+			}
+			s.skor[soal.ID] = kredit
+			jumlah += kredit
+			switch {
+			case status == StatusKosong:
+				s.kosong[soal.ID] = true
+			case !kunci.Teks(soal.ID):
+				s.dipilih[soal.ID] = js.Dipilih()
+			}
+		}
+		if len(s.skor) > 0 {
+			s.total = jumlah / float64(len(s.skor))
+			result.RataRata += s.total
+			dinilai++
+		}
+		skor = append(skor, s)
+	}
+	if dinilai > 0 {
+		result.RataRata = bulatkan(result.RataRata/float64(dinilai)*100, 2)
+	}
+
+	atas, bawah := kelompokAtasBawah(skor)
+	for _, soal := range bank {
+		if !didapat[soal.ID] {
+			continue
+		}
+		item := analisisSoal(soal, kunci, skor, atas, bawah)
+		item.Nomor = len(result.Soal) + 1
+		item.BelumDinilai = belumDinilai[soal.ID]
+		result.Soal = append(result.Soal, item)
+	}
+	result.KR20 = kr20(skor, len(result.Soal))
+	return result
+}
+
+// kelompokAtasBawah membagi peserta menurut skor total menjadi kelompok atas dan bawah masing-masing 27%,
+// paling sedikit satu peserta bila ada dua peserta atau lebih
+func kelompokAtasBawah(skor []skorAnalisis) (atas, bawah map[string]bool) {
+	atas, bawah = map[string]bool{}, map[string]bool{}
+	if len(skor) < 2 {
+		return atas, bawah
+	}
+	urut := append([]skorAnalisis(nil), skor...)
+	sort.SliceStable(urut, func(i, j int) bool {
+		if urut[i].total != urut[j].total {
+			return urut[i].total > urut[j].total
+		}
+		return urut[i].siswaDetailID < urut[j].siswaDetailID
+	})
+	n := max(int(math.Round(ProporsiKelompok*float64(len(urut)))), 1)
+	for i := 0; i < n; i++ {
+		atas[urut[i].siswaDetailID] = true
+		bawah[urut[len(urut)-1-i].siswaDetailID] = true
+	}
+	return atas, bawah
+}
+
+func analisisSoal(soal models.SoalInput, kunci KunciJawaban, skor []skorAnalisis, atas, bawah map[string]bool) models.AnalisisSoal {
+	item := models.AnalisisSoal{
+		SoalID: soal.ID,
+		Soal:   soal.Soal,
+		Tipe:   soal.Tipe,
+		Format: soal.Format,
+		Topik:  soal.Topik,
+		KD:     soal.KD,
+	}
+	if item.Tipe == "" {
+		item.Tipe = models.TipePilihanGanda
+	}
+
+	var x, y []float64
+	var jumlahAtas, jumlahBawah, skorAtas, skorBawah float64
+	dipilih := make(map[string]int)
+	dipilihAtas := make(map[string]int)
+	dipilihBawah := make(map[string]int)
+	for _, s := range skor {
+		kredit, ok := s.skor[soal.ID]
+		if !ok {
+			continue
+		}
+		item.JumlahPeserta++
+		x = append(x, kredit)
+		y = append(y, s.total)
+		if s.kosong[soal.ID] {
+			item.Kosong++
+		}
+		if atas[s.siswaDetailID] {
+			jumlahAtas++
+			skorAtas += kredit
+		}
+		if bawah[s.siswaDetailID] {
+			jumlahBawah++
+			skorBawah += kredit
+		}
+		for _, id := range s.dipilih[soal.ID] {
+			dipilih[id]++
+			if atas[s.siswaDetailID] {
+				dipilihAtas[id]++
+			}
+			if bawah[s.siswaDetailID] {
+				dipilihBawah[id]++
+			}
+		}
+	}
+	if item.JumlahPeserta == 0 {
+		return item
+	}
+
+	p := bulatkan(rataRata(x), 3)
+	item.TingkatKesukaran = &p
+	item.KategoriKesukaran = kategoriKesukaran(p)
+	if jumlahAtas > 0 && jumlahBawah > 0 {
+		d := bulatkan(skorAtas/jumlahAtas-skorBawah/jumlahBawah, 3)
+		item.DayaBeda = &d
+	}
+	if r, ok := korelasi(x, y); ok {
+		r = bulatkan(r, 3)
+		item.KorelasiBiserial = &r
+	}
+	switch {
+	case item.DayaBeda != nil:
+		item.KategoriDayaBeda = kategoriDayaBeda(*item.DayaBeda)
+	case item.KorelasiBiserial != nil:
+		item.KategoriDayaBeda = kategoriDayaBeda(*item.KorelasiBiserial)
+	}
+
+	if kunci.Teks(soal.ID) {
+		return item
+	}
+	for i, pilihan := range soal.Pilihan {
+		ap := models.AnalisisPilihan{
+			JawabanID:    pilihan.ID,
+			Teks:         pilihan.Text,
+			Benar:        pilihan.Benar,
+			Dipilih:      dipilih[pilihan.ID],
+			Proporsi:     bulatkan(float64(dipilih[pilihan.ID])/float64(item.JumlahPeserta), 3),
+			DipilihAtas:  dipilihAtas[pilihan.ID],
+			DipilihBawah: dipilihBawah[pilihan.ID],
+		}
+		if i < len(kolomPilihan) {
+			ap.Huruf = kolomPilihan[i]
+		}
+		ap.Berfungsi = !ap.Benar && ap.Proporsi >= MinProporsiPengecoh && ap.DipilihBawah >= ap.DipilihAtas
+		item.Pilihan = append(item.Pilihan, ap)
+	}
+	return item
+}
+
+// kategoriKesukaran klasifikasi p-value: di bawah 0,3 sukar, sampai 0,7 sedang, di atasnya mudah
+func kategoriKesukaran(p float64) string {
+	switch {
+	case p < 0.3:
+		return "sukar"
+	case p <= 0.7:
+		return "sedang"
+	default:
+		return "mudah"
+	}
+}
+
+// kategoriDayaBeda klasifikasi daya beda: negatif sebaiknya dibuang, di bawah 0,2 jelek, sampai 0,4 cukup,
+// sampai 0,7 baik, di atasnya baik sekali
+func kategoriDayaBeda(d float64) string {
+	switch {
+	case d < 0:
+		return "negatif"
+	case d < 0.2:
+		return "jelek"
+	case d < 0.4:
+		return "cukup"
+	case d < 0.7:
+		return "baik"
+	default:
+		return "baik sekali"
+	}
+}
+
+// kr20 reliabilitas Kuder-Richardson 20 dari skor mentah peserta yang semua soalnya sudah dinilai.
+// Untuk soal bernilai sebagian variansi soal menggantikan p*q sehingga hasilnya sama dengan alpha Cronbach.
+// Hanya dihitung bila semua peserta mengerjakan soal yang sama.
+func kr20(skor []skorAnalisis, jumlahSoal int) *float64 {
+	var lengkap []skorAnalisis
+	for _, s := range skor {
+		if s.jumlahSoal != jumlahSoal {
+			// Peserta dengan undian soal berbeda membuat skor total tidak sebanding
+			return nil
+		}
+		if s.lengkap {
+			lengkap = append(lengkap, s)
+		}
+	}
+	if len(lengkap) < 2 || jumlahSoal < 2 {
+		return nil
+	}
+
+	var soalIDs []string
+	for id := range lengkap[0].skor {
+		soalIDs = append(soalIDs, id)
+	}
+	total := make([]float64, len(lengkap))
+	var jumlahVariansi float64
+	for _, id := range soalIDs {
+		x := make([]float64, len(lengkap))
+		for i, s := range lengkap {
+			x[i] = s.skor[id]
+			total[i] += s.skor[id]
+		}
+		jumlahVariansi += variansi(x)
+	}
+	vt := variansi(total)
+	if vt == 0 {
+		return nil
+	}
+	k := float64(jumlahSoal)
+	r := bulatkan(k/(k-1)*(1-jumlahVariansi/vt), 3)
+	return &r
+}
+
+func rataRata(x []float64) float64 {
+	var jumlah float64
+	for _, v := range x {
+		jumlah += v
+	}
+	return jumlah / float64(len(x))
+}
+
+// variansi variansi populasi
+func variansi(x []float64) float64 {
+	m := rataRata(x)
+	var jumlah float64
+	for _, v := range x {
+		jumlah += (v - m) * (v - m)
+	}
+	return jumlah / float64(len(x))
+}
+
+// korelasi korelasi Pearson, sama dengan point-biserial bila x bernilai 0/1. ok false bila salah satu
+// variabel tidak bervariasi.
+func korelasi(x, y []float64) (float64, bool) {
+	if len(x) < 2 {
+		return 0, false
+	}
+	mx, my := rataRata(x), rataRata(y)
+	var sxy, sxx, syy float64
+	for i := range x {
+		sxy += (x[i] - mx) * (y[i] - my)
+		sxx += (x[i] - mx) * (x[i] - mx)
+		syy += (y[i] - my) * (y[i] - my)
+	}
+	if sxx == 0 || syy == 0 {
+		return 0, false
+	}
+	return sxy / math.Sqrt(sxx*syy), true
+}
+
+func bulatkan(v float64, desimal int) float64 {
+	pangkat := math.Pow(10, float64(desimal))
+	return math.Round(v*pangkat) / pangkat
+}
+
+// BarisAnalisis baris spreadsheet laporan analisis butir: satu baris per soal, lalu ringkasan ujian
+func BarisAnalisis(analisis models.AnalisisUjian) [][]string {
+	rows := [][]string{{
+		"No", "Soal", "Tipe", "Topik", "KD", "Peserta", "Kosong", "Belum Dinilai",
+		"Tingkat Kesukaran", "Kategori Kesukaran", "Daya Beda", "Point-Biserial", "Kategori Daya Beda",
+		"Sebaran Pilihan (* kunci, ! pengecoh tidak berfungsi)",
+	}}
+	for _, soal := range analisis.Soal {
+		rows = append(rows, []string{
+			strconv.Itoa(soal.Nomor), soal.Soal, soal.Tipe, soal.Topik, soal.KD,
+			strconv.Itoa(soal.JumlahPeserta), strconv.Itoa(soal.Kosong), strconv.Itoa(soal.BelumDinilai),
+			models.AngkaAnalisis(soal.TingkatKesukaran), soal.KategoriKesukaran,
+			models.AngkaAnalisis(soal.DayaBeda), models.AngkaAnalisis(soal.KorelasiBiserial), soal.KategoriDayaBeda,
+			soal.SebaranPilihan(),
+		})
+	}
+	rows = append(rows,
+		[]string{},
+		[]string{"Jumlah Peserta", strconv.Itoa(analisis.JumlahPeserta)},
+		[]string{"Rata-rata", strconv.FormatFloat(analisis.RataRata, 'f', 2, 64)},
+		[]string{"KR-20", models.AngkaAnalisis(analisis.KR20)},
+	)
+	return rows
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"backend/models"
+	"reflect"
+	"testing"
+)
+
+func TestAnalisisButir(t *testing.T) {
+	var bank []models.SoalInput
+	for _, id := range []string{"a", "b", "c", "d"} {
+		soal := soalPenilaian(id, 1)
+		soal.Pilihan = append(soal.Pilihan, models.Pilihan{ID: id + "-x"})
+		bank = append(bank, soal)
+	}
+	// Baris peserta, kolom soal a-d: 1 benar, 0 salah
+	matriks := map[string][]int{
+		"p1": {1, 1, 1, 1},
+		"p2": {1, 1, 1, 0},
+		"p3": {1, 1, 0, 0},
+		"p4": {1, 0, 0, 0},
+		"p5": {0, 0, 0, 1},
+	}
+	var peserta []PesertaAnalisis
+	for _, siswa := range []string{"p1", "p2", "p3", "p4", "p5"} {
+		p := PesertaAnalisis{SiswaDetailID: siswa, Soal: bank}
+		for i, benar := range matriks[siswa] {
+			pilihan := bank[i].ID + "-salah"
+			if benar == 1 {
+				pilihan = bank[i].ID + "-benar"
+			}
+			p.Jawaban = append(p.Jawaban, jawab(bank[i].ID, pilihan))
+		}
+		peserta = append(peserta, p)
+	}
+
+	analisis := AnalisisButir(&models.Ujian{ID: "ujian"}, bank, peserta)
+	if analisis.JumlahPeserta != 5 || analisis.RataRata != 55 || len(analisis.Soal) != 4 {
+		t.Fatalf("unexpected summary %+v", analisis)
+	}
+	// KR-20 = 4/3 * (1 - 0.88/1.36)
+	if analisis.KR20 == nil || *analisis.KR20 != 0.471 {
+		t.Errorf("KR20 = %v, want 0.471", analisis.KR20)
+	}
+
+	a := analisis.Soal[0]
+	if *a.TingkatKesukaran != 0.8 || a.KategoriKesukaran != "mudah" || *a.DayaBeda != 1 || *a.KorelasiBiserial != 0.514 {
+		t.Errorf("soal a: p %v, D %v, r %v (%s)", *a.TingkatKesukaran, *a.DayaBeda, *a.KorelasiBiserial, a.KategoriKesukaran)
+	}
+	wantPilihan := []models.AnalisisPilihan{
+		{JawabanID: "a-benar", Huruf: "A", Benar: true, Dipilih: 4, Proporsi: 0.8, DipilihAtas: 1},
+		{JawabanID: "a-salah", Huruf: "B", Dipilih: 1, Proporsi: 0.2, DipilihBawah: 1, Berfungsi: true},
+		{JawabanID: "a-x", Huruf: "C"},
+	}
+	if !reflect.DeepEqual(a.Pilihan, wantPilihan) {
+		t.Errorf("pilihan soal a = %+v, want %+v", a.Pilihan, wantPilihan)
+	}
+	if got := a.SebaranPilihan(); got != "A*: 4 (80%); B: 1 (20%); C!: 0 (0%)" {
+		t.Errorf("SebaranPilihan = %q", got)
+	}
+
+	// Kelompok atas p1 dan bawah p5 sama-sama menjawab benar
+	d := analisis.Soal[3]
+	if *d.TingkatKesukaran != 0.4 || *d.DayaBeda != 0 || d.KategoriDayaBeda != "jelek" {
+		t.Errorf("soal d: p %v, D %v (%s)", *d.TingkatKesukaran, *d.DayaBeda, d.KategoriDayaBeda)
+	}
+
+	rows := BarisAnalisis(analisis)
+	if len(rows) != 1+4+4 || rows[1][8] != "0.800" || rows[len(rows)-1][1] != "0.471" {
+		t.Errorf("unexpected spreadsheet rows %q", rows)
+	}
+}
+
+func TestAnalisisButirUndianDanEsai(t *testing.T) {
+	esai := models.SoalInput{ID: "esai", Tipe: models.TipeEsai, Bobot: 2}
+	bank := []models.SoalInput{soalPenilaian("a", 1), soalPenilaian("b", 1), esai}
+	skor := 1.0
+	peserta := []PesertaAnalisis{
+		{SiswaDetailID: "s1", Soal: []models.SoalInput{bank[0], bank[2]}, Jawaban: []models.JawabanSiswa{
+			jawab("a", "a-benar"), {SoalID: "esai", JawabanTeks: "Uraian", SkorManual: &skor},
+		}},
+		{SiswaDetailID: "s2", Soal: []models.SoalInput{bank[1], bank[2]}, Jawaban: []models.JawabanSiswa{
+			{SoalID: "esai", JawabanTeks: "Belum dinilai"},
+		}},
+	}
+
+	analisis := AnalisisButir(&models.Ujian{}, bank, peserta)
+	if analisis.KR20 != nil {
+		t.Errorf("KR20 must be nil when peserta got different soal, got %v", *analisis.KR20)
+	}
+	if len(analisis.Soal) != 3 {
+		t.Fatalf("expected 3 soal, got %+v", analisis.Soal)
+	}
+	b, e := analisis.Soal[1], analisis.Soal[2]
+	if b.JumlahPeserta != 1 || b.Kosong != 1 || *b.TingkatKesukaran != 0 {
+		t.Errorf("soal b: %+v", b)
+	}
+	if e.JumlahPeserta != 1 || e.BelumDinilai != 1 || *e.TingkatKesukaran != 0.5 || e.Pilihan != nil {
+		t.Errorf("esai: %+v", e)
+	}
+	if e.DayaBeda != nil {
+		t.Errorf("esai graded for only one peserta must have no daya beda, got %v", *e.DayaBeda)
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"backend/models"
+	"backend/utils"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// AmbangMirip skor kemiripan minimal (0-1) agar soal dianggap hampir sama dengan soal di bank soal
+const AmbangMirip = 0.85
+
+// Aksi untuk soal yang terdeteksi duplikat saat diunggah
+const (
+	AksiLewati = "lewati" // soal tidak disimpan
+	AksiTimpa  = "timpa"  // soal lama di bank soal diganti dengan soal baru
+	AksiSimpan = "simpan" // soal baru tetap disimpan, soal lama dibiarkan
+)
+
+// operatorSoal tanda yang mengubah makna soal sehingga tidak ikut dibuang bersama tanda baca lain.
+// : dipakai untuk pembagian dan - untuk pengurangan.
+const operatorSoal = "+-*/:=<>^%()"
+
+// NormalisasiSoal menyamakan teks soal sebelum dibandingkan: huruf kecil, tanda baca diabaikan,
+// dan spasi berurutan dianggap satu. Operator dan simbol matematika tetap dipakai (dipisah spasi agar
+// "2+3" sama dengan "2 + 3") dan rumus $...$ dibandingkan apa adanya, sehingga "2 + 3" dan "2 x 3"
+// tidak dianggap soal yang sama.
+func NormalisasiSoal(teks string) string {
+	segmen, err := utils.SegmenRumus(teks)
+	if err != nil {
+		segmen = []utils.Segmen{{Teks: teks}}
+	}
+	var b strings.Builder
+	for _, s := range segmen {
+		if s.Rumus {
+			b.WriteString(" $" + s.Teks + "$ ")
+			continue
+		}
+		for _, r := range s.Teks {
+			switch {
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				b.WriteRune(unicode.ToLower(r))
+			case unicode.IsSymbol(r) || strings.ContainsRune(operatorSoal, r):
+				b.WriteString(" " + string(r) + " ")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// KemiripanSoal skor 0-1 kemiripan dua soal. Teks soal dibandingkan dengan koefisien Dice bigram huruf
+// sehingga salah ketik kecil tetap terdeteksi, himpunan pilihan dengan Jaccard. Bila kedua soal punya
+// pilihan, teks berbobot 85% dan pilihan 15%. persis true bila teks dan himpunan pilihan sama setelah normalisasi.
+func KemiripanSoal(a, b models.SoalInput) (skor float64, persis bool) {
+	teksA, teksB := NormalisasiSoal(a.Soal), NormalisasiSoal(b.Soal)
+	pilihanA, pilihanB := himpunanPilihan(a), himpunanPilihan(b)
+
+	if teksA == teksB && strings.Join(pilihanA, "\x00") == strings.Join(pilihanB, "\x00") {
+		return 1, true
+	}
+	skor = diceBigram(teksA, teksB)
+	if len(pilihanA) > 0 && len(pilihanB) > 0 {
+		skor = 0.85*skor + 0.15*jaccard(pilihanA, pilihanB)
+	}
+	return skor, false
+}
+
+// CariDuplikat membandingkan setiap soal yang diunggah dengan bank soal dan mengembalikan soal bank
+// yang paling mirip untuk soal yang sama persis atau skornya minimal AmbangMirip
+func CariDuplikat(baru, bank []models.SoalInput) []models.DuplikatSoal {
+	var result []models.DuplikatSoal
+	for i, soal := range baru {
+		var terbaik *models.DuplikatSoal
+		for _, lama := range bank {
+			skor, persis := KemiripanSoal(soal, lama)
+			if !persis && skor < AmbangMirip {
+				continue
+			}
+			if terbaik == nil || skor > terbaik.Kemiripan {
+				terbaik = &models.DuplikatSoal{Indeks: i, SoalID: lama.ID, Soal: lama.Soal, Kemiripan: skor, Persis: persis}
+			}
+		}
+		if terbaik != nil {
+			terbaik.Kemiripan = float64(int(terbaik.Kemiripan*1000+0.5)) / 1000
+			result = append(result, *terbaik)
+		}
+	}
+	return result
+}
+
+func himpunanPilihan(soal models.SoalInput) []string {
+	seen := make(map[string]bool, len(soal.Pilihan))
+	var result []string
+	for _, p := range soal.Pilihan {
+		teks := NormalisasiSoal(p.Text)
+		if !seen[teks] {
+			seen[teks] = true
+			result = append(result, teks)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func diceBigram(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 2 || len(rb) < 2 {
+		return 0
+	}
+	bigram := make(map[string]int, len(ra))
+	for i := 0; i+1 < len(ra); i++ {
+		bigram[string(ra[i:i+2])]++
+	}
+	sama := 0
+	for i := 0; i+1 < len(rb); i++ {
+		key := string(rb[i : i+2])
+		if bigram[key] > 0 {
+			bigram[key]--
+			sama++
+		}
+	}
+	return 2 * float64(sama) / float64(len(ra)-1+len(rb)-1)
+}
+
+func jaccard(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, s := range a {
+		set[s] = true
+	}
+	irisan := 0
+	for _, s := range b {
+		if set[s] {
+			irisan++
+		}
+	}
+	return float64(irisan) / float64(len(a)+len(b)-irisan)
+}
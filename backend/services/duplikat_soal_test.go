@@ -0,0 +1,90 @@
+package services
+
+import (
+	"backend/models"
+	"testing"
+)
+
+func pilihanTeks(teks ...string) []models.Pilihan {
+	result := make([]models.Pilihan, len(teks))
+	for i, t := range teks {
+		result[i] = models.Pilihan{Text: t}
+	}
+	return result
+}
+
+func TestKemiripanSoal(t *testing.T) {
+	asli := models.SoalInput{
+		Soal:    "Siapakah presiden pertama Republik Indonesia?",
+		Pilihan: pilihanTeks("Soekarno", "Soeharto", "Habibie", "Megawati"),
+	}
+	tests := []struct {
+		name   string
+		soal   models.SoalInput
+		persis bool
+		mirip  bool
+	}{
+		{"beda huruf besar, spasi, tanda baca, dan urutan pilihan", models.SoalInput{
+			Soal:    "siapakah  PRESIDEN pertama republik indonesia",
+			Pilihan: pilihanTeks("Habibie", "soekarno", "Megawati", "Soeharto"),
+		}, true, true},
+		{"salah ketik", models.SoalInput{
+			Soal:    "Siapakah presiden pertama Repulik Indonesia?",
+			Pilihan: pilihanTeks("Soekarno", "Soeharto", "Habibie", "Megawati"),
+		}, false, true},
+		{"teks sama pilihan berbeda", models.SoalInput{
+			Soal:    "Siapakah presiden pertama Republik Indonesia?",
+			Pilihan: pilihanTeks("Jokowi", "SBY", "Gus Dur", "Soekarno"),
+		}, false, true},
+		{"soal lain", models.SoalInput{
+			Soal:    "Siapakah wakil presiden pertama Republik Indonesia?",
+			Pilihan: pilihanTeks("Hatta", "Adam Malik", "Try Sutrisno", "Jusuf Kalla"),
+		}, false, false},
+	}
+	for _, tt := range tests {
+		skor, persis := KemiripanSoal(asli, tt.soal)
+		if persis != tt.persis || (skor >= AmbangMirip) != tt.mirip {
+			t.Errorf("%s: skor %.3f persis %v, want persis %v mirip %v", tt.name, skor, persis, tt.persis, tt.mirip)
+		}
+	}
+}
+
+func TestNormalisasiSoal(t *testing.T) {
+	tests := []struct {
+		a, b string
+		sama bool
+	}{
+		{"Berapa hasil 2+3?", "berapa  hasil 2 + 3", true},
+		{"Hasil $\\frac{1}{2}$ adalah...", "hasil $\\frac{1}{2}$ adalah", true},
+		{"2 + 3 = ?", "2 x 3 = ?", false},
+		{"8 : 2 = ?", "8 - 2 = ?", false},
+		{"Nilai $x^2$ adalah", "Nilai $x_2$ adalah", false},
+		{"Nilai $a < b$", "Nilai $a > b$", false},
+	}
+	for _, tt := range tests {
+		if sama := NormalisasiSoal(tt.a) == NormalisasiSoal(tt.b); sama != tt.sama {
+			t.Errorf("%q vs %q: sama %v, want %v (%q, %q)", tt.a, tt.b, sama, tt.sama, NormalisasiSoal(tt.a), NormalisasiSoal(tt.b))
+		}
+	}
+
+	soal := models.SoalInput{Soal: "2 + 3 = ?", Pilihan: pilihanTeks("5", "6")}
+	kali := models.SoalInput{Soal: "2 x 3 = ?", Pilihan: pilihanTeks("5", "6")}
+	if _, persis := KemiripanSoal(soal, kali); persis {
+		t.Errorf("%q and %q must not be exact duplicates", soal.Soal, kali.Soal)
+	}
+}
+
+func TestCariDuplikat(t *testing.T) {
+	bank := []models.SoalInput{
+		{ID: "a", Soal: "Ibu kota Jawa Barat adalah", Pilihan: pilihanTeks("Bandung", "Bogor")},
+		{ID: "b", Soal: "Ibu kota Jawa Tengah adalah", Pilihan: pilihanTeks("Semarang", "Solo")},
+	}
+	baru := []models.SoalInput{
+		{Soal: "Hukum Newton pertama disebut hukum", Pilihan: pilihanTeks("Inersia", "Aksi reaksi")},
+		{Soal: "Ibu kota Jawa Tengah adalah...", Pilihan: pilihanTeks("Solo", "Semarang")},
+	}
+	duplikat := CariDuplikat(baru, bank)
+	if len(duplikat) != 1 || duplikat[0].Indeks != 1 || duplikat[0].SoalID != "b" || !duplikat[0].Persis || duplikat[0].Kemiripan != 1 {
+		t.Errorf("unexpected duplicates %+v", duplikat)
+	}
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"backend/models"
+	"strconv"
+)
+
+// KolomAngkaHasil kolom BarisHasil yang berisi angka, untuk TulisXLSX
+var KolomAngkaHasil = []int{0, 6, 7, 8, 9, 10, 11}
+
+// BarisHasil header dan satu baris per hasil ujian untuk ekspor CSV/XLSX, misalnya untuk diisikan ke e-Rapor.
+// Waktu pengerjaan ditulis dalam menit dan tanggal dengan format YYYY-MM-DD HH:MM.
+func BarisHasil(hasil []models.HasilUjianDetail) [][]string {
+	rows := [][]string{{
+		"No", "NIS", "Nama", "Kelas", "Tingkat", "Mata Pelajaran", "Nilai", "Benar", "Salah", "Kosong",
+		"Waktu Pengerjaan (menit)", "Kecurangan", "Tanggal", "ID Ujian",
+	}}
+	for i, h := range hasil {
+		menit := h.WaktuPengerjaan
+		if detik, err := strconv.Atoi(h.WaktuPengerjaan); err == nil {
+			menit = strconv.FormatFloat(float64(detik)/60, 'f', 1, 64)
+		}
+		tanggal := ""
+		if !h.CreatedAt.IsZero() {
+			tanggal = h.CreatedAt.Format("2006-01-02 15:04")
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1), h.NIS, h.SiswaNama, h.Kelas, h.Tingkat, h.MataPelajaran,
+			h.Nilai, h.Benar, h.Salah, h.Kosong, menit, strconv.Itoa(h.TotalKecurangan), tanggal, h.UjianID,
+		})
+	}
+	return rows
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"backend/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBarisHasil(t *testing.T) {
+	rows := BarisHasil([]models.HasilUjianDetail{{
+		UjianID: "ujian-mtk", SiswaNama: "Budi", Kelas: "X-RPL", Tingkat: "X", MataPelajaran: "MTK",
+		Nilai: "80", Benar: "8", Salah: "2", Kosong: "0", WaktuPengerjaan: "1350", NIS: "1001",
+		TotalKecurangan: 3, CreatedAt: time.Date(2025, 6, 1, 8, 0, 0, 0, time.Local),
+	}})
+	if len(rows) != 2 || len(rows[0]) != len(rows[1]) {
+		t.Fatalf("rows = %v", rows)
+	}
+	want := []string{"1", "1001", "Budi", "X-RPL", "X", "MTK", "80", "8", "2", "0", "22.5", "3", "2025-06-01 08:00", "ujian-mtk"}
+	if !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("baris = %v, want %v", rows[1], want)
+	}
+}
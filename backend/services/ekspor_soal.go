@@ -0,0 +1,100 @@
+package services
+
+import (
+	"backend/models"
+	"bytes"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// HurufKunci huruf pilihan yang benar dipisah koma, misalnya "A" atau "A, C"
+func HurufKunci(soal models.SoalInput) string {
+	var kunci []string
+	for i, p := range soal.Pilihan {
+		if p.Benar && i < len(kolomPilihan) {
+			kunci = append(kunci, kolomPilihan[i])
+		}
+	}
+	return strings.Join(kunci, ", ")
+}
+
+// BarisSpreadsheet kebalikan SoalDariSpreadsheet: header dan satu baris per soal dengan kolom yang sama
+// seperti file impor, sehingga hasil ekspor bisa langsung diimpor lagi. Kolom Gambar berisi nama file
+// gambar saja, ZIP gambar dengan nama yang sama dikirim terpisah saat impor.
+func BarisSpreadsheet(soalList []models.SoalInput) [][]string {
+	jumlahKolom := 2
+	for _, soal := range soalList {
+		jumlahKolom = max(jumlahKolom, min(len(soal.Pilihan), len(kolomPilihan)))
+	}
+
+	header := append([]string{"Soal"}, kolomPilihan[:jumlahKolom]...)
+	header = append(header, "Kunci", "Gambar", "Bobot", "Tipe", "Format", "Topik", "KD", "Kesulitan", "Jumlah Pilihan")
+	rows := [][]string{header}
+	for _, soal := range soalList {
+		row := []string{soal.Soal}
+		for i := 0; i < jumlahKolom; i++ {
+			teks := ""
+			if i < len(soal.Pilihan) {
+				teks = soal.Pilihan[i].Text
+			}
+			row = append(row, teks)
+		}
+		gambar := ""
+		if soal.Gambar != nil && *soal.Gambar != "" {
+			gambar = path.Base(*soal.Gambar)
+		}
+		tipe := soal.Tipe
+		if tipe == "" {
+			tipe = models.TipePilihanGanda
+		}
+		format := soal.Format
+		if format == "" {
+			format = models.FormatPolos
+		}
+		jumlahPilihan := ""
+		if soal.JumlahPilihan > 0 {
+			jumlahPilihan = strconv.Itoa(soal.JumlahPilihan)
+		}
+		row = append(row, HurufKunci(soal), gambar, strconv.Itoa(max(soal.Bobot, 1)), tipe, format, soal.Topik, soal.KD, soal.Kesulitan, jumlahPilihan)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// TulisXLSX menulis baris ke sheet pertama file XLSX baru. Sel pada kolomAngka (mulai dari 0) yang berisi
+// angka ditulis sebagai angka supaya bisa langsung dihitung di Excel, baris pertama tetap dianggap header.
+func TulisXLSX(rows [][]string, kolomAngka ...int) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	angka := make(map[int]bool, len(kolomAngka))
+	for _, j := range kolomAngka {
+		angka[j] = true
+	}
+	sheet := f.GetSheetName(0)
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(row))
+		for j, v := range row {
+			values[j] = v
+			if n, err := strconv.ParseFloat(v, 64); err == nil && i > 0 && angka[j] {
+				values[j] = n
+			}
+		}
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
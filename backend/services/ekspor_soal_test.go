@@ -0,0 +1,48 @@
+package services
+
+import (
+	"backend/models"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBarisSpreadsheetBolakBalik(t *testing.T) {
+	gambar := "/image-soal/3f2a.png"
+	soalList := []models.SoalInput{
+		{Soal: "Ibu kota Jawa Barat?", Gambar: &gambar, Tipe: models.TipePilihanGanda, Format: models.FormatPolos, Topik: "Geografi", KD: "3.2", Kesulitan: models.KesulitanMudah, Bobot: 1, JumlahPilihan: 3, Pilihan: []models.Pilihan{
+			{Text: "Bandung", Benar: true}, {Text: "Bogor"}, {Text: "Bekasi"},
+		}},
+		{Soal: "Bilangan prima $p < 6$?", Tipe: models.TipePilihanGandaKompleks, Format: models.FormatMarkdownLatex, Bobot: 2, Pilihan: []models.Pilihan{
+			{Text: "2", Benar: true}, {Text: "3", Benar: true}, {Text: "4"}, {Text: "5", Benar: true},
+		}},
+		{Soal: "Ibu kota **Indonesia**?", Tipe: models.TipeIsianSingkat, Format: models.FormatMarkdown, Bobot: 1, Pilihan: []models.Pilihan{
+			{Text: "Jakarta", Benar: true}, {Text: "DKI Jakarta", Benar: true},
+		}},
+		{Soal: "Jelaskan hukum Newton I", Tipe: models.TipeEsai, Format: models.FormatPolos, Bobot: 3},
+	}
+
+	rows := BarisSpreadsheet(soalList)
+	if want := []string{"Soal", "A", "B", "C", "D", "Kunci", "Gambar", "Bobot", "Tipe", "Format", "Topik", "KD", "Kesulitan", "Jumlah Pilihan"}; !reflect.DeepEqual(rows[0], want) {
+		t.Fatalf("header = %q, want %q", rows[0], want)
+	}
+
+	data, err := TulisXLSX(rows)
+	if err != nil {
+		t.Fatalf("TulisXLSX: %v", err)
+	}
+	dibaca, kesalahan, err := BacaFileSoal("soal.xlsx", "", bytes.NewReader(data))
+	if err != nil || len(kesalahan) > 0 {
+		t.Fatalf("re-import failed: %v %+v", err, kesalahan)
+	}
+	if len(dibaca) != len(soalList) || dibaca[0].Gambar != "3f2a.png" {
+		t.Fatalf("unexpected re-imported rows %+v", dibaca)
+	}
+	for i, baris := range dibaca {
+		asli := soalList[i]
+		asli.Gambar = nil
+		if !reflect.DeepEqual(baris.Soal, asli) {
+			t.Errorf("soal %d = %+v, want %+v", i+1, baris.Soal, asli)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"backend/models"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format bank soal Moodle yang bisa diimpor selain spreadsheet
+const (
+	FormatGIFT  = "gift"
+	FormatAiken = "aiken"
+)
+
+var (
+	aikenPilihan = regexp.MustCompile(`^([A-Z])[.)]\s+(.+)$`)
+	aikenKunci   = regexp.MustCompile(`(?i)^ANSWER:\s*([A-Z])\s*$`)
+	giftBobot    = regexp.MustCompile(`^%(-?\d+(?:\.\d+)?)%`)
+)
+
+// DeteksiFormatTeks menebak format file .txt dari Moodle: Aiken bila ada baris ANSWER:, selain itu GIFT
+func DeteksiFormatTeks(teks string) string {
+	for _, line := range strings.Split(teks, "\n") {
+		if aikenKunci.MatchString(strings.TrimSpace(line)) {
+			return FormatAiken
+		}
+	}
+	return FormatGIFT
+}
+
+// barisTeks memisahkan teks menjadi baris tanpa BOM dan carriage return
+func barisTeks(teks string) []string {
+	teks = strings.TrimPrefix(teks, "\ufeff")
+	teks = strings.ReplaceAll(teks, "\r\n", "\n")
+	return strings.Split(teks, "\n")
+}
+
+// SoalDariAiken membaca format Aiken: teks soal, pilihan "A." atau "A)" berurutan, lalu "ANSWER: X".
+// Aiken hanya mengenal pilihan ganda dengan satu jawaban benar.
+func SoalDariAiken(teks string) ([]BarisSoal, []models.KesalahanImpor) {
+	var result []BarisSoal
+	var kesalahan []models.KesalahanImpor
+
+	var soal models.SoalInput
+	mulai, rusak := 0, ""
+	reset := func() {
+		soal, mulai, rusak = models.SoalInput{}, 0, ""
+	}
+
+	for i, line := range barisTeks(teks) {
+		nomor := i + 1
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if mulai == 0 {
+			mulai = nomor
+		}
+
+		if m := aikenKunci.FindStringSubmatch(line); m != nil {
+			idx := int(strings.ToUpper(m[1])[0] - 'A')
+			switch {
+			case rusak != "":
+				kesalahan = append(kesalahan, models.KesalahanImpor{Baris: mulai, Pesan: rusak})
+			case soal.Soal == "" || len(soal.Pilihan) == 0:
+				kesalahan = append(kesalahan, models.KesalahanImpor{Baris: mulai, Pesan: "soal atau pilihan jawaban kosong sebelum ANSWER"})
+			case idx >= len(soal.Pilihan):
+				kesalahan = append(kesalahan, models.KesalahanImpor{Baris: nomor, Pesan: fmt.Sprintf("ANSWER %s tidak sesuai dengan pilihan yang ada", m[1])})
+			default:
+				soal.Pilihan[idx].Benar = true
+				soal.JumlahPilihan = len(soal.Pilihan)
+				result = append(result, BarisSoal{Baris: mulai, Soal: soal})
+			}
+			reset()
+			continue
+		}
+		if rusak != "" {
+			continue
+		}
+
+		if m := aikenPilihan.FindStringSubmatch(line); m != nil && soal.Soal != "" {
+			if want := string(rune('A' + len(soal.Pilihan))); m[1] != want {
+				rusak = fmt.Sprintf("pilihan %s seharusnya %s", m[1], want)
+				continue
+			}
+			soal.Pilihan = append(soal.Pilihan, models.Pilihan{Text: strings.TrimSpace(m[2])})
+			continue
+		}
+		if len(soal.Pilihan) > 0 {
+			rusak = fmt.Sprintf("baris %d bukan pilihan jawaban maupun ANSWER", nomor)
+			continue
+		}
+		// Teks soal boleh lebih dari satu baris
+		if soal.Soal != "" {
+			soal.Soal += "\n"
+		}
+		soal.Soal += line
+	}
+	if mulai != 0 {
+		kesalahan = append(kesalahan, models.KesalahanImpor{Baris: mulai, Pesan: "baris ANSWER tidak ditemukan"})
+	}
+	return result, kesalahan
+}
+
+// SoalDariGIFT membaca format GIFT Moodle. Yang didukung: pilihan ganda (=benar ~salah), pilihan ganda
+// dengan bobot persen (lebih dari satu jawaban berbobot positif menjadi pilihan ganda kompleks),
+// benar/salah ({T}/{F}), jawaban singkat (semua jawaban diawali =), dan esai ({}).
+// Penanda [markdown] menjadi format MARKDOWN, sedangkan judul ::...::, penanda format lain, komentar //,
+// $CATEGORY, dan umpan balik #... diabaikan.
+// Soal numerik dan menjodohkan tidak punya padanan di sini sehingga dilaporkan sebagai kesalahan.
+func SoalDariGIFT(teks string) ([]BarisSoal, []models.KesalahanImpor) {
+	var result []BarisSoal
+	var kesalahan []models.KesalahanImpor
+
+	var blok []string
+	mulai := 0
+	proses := func() {
+		if len(blok) == 0 {
+			return
+		}
+		soal, err := soalDariGIFT(strings.Join(blok, "\n"))
+		if err != nil {
+			kesalahan = append(kesalahan, models.KesalahanImpor{Baris: mulai, Pesan: err.Error()})
+		} else {
+			result = append(result, BarisSoal{Baris: mulai, Soal: soal})
+		}
+		blok = nil
+	}
+
+	for i, line := range barisTeks(teks) {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "$CATEGORY:") {
+			continue
+		}
+		if trimmed == "" {
+			proses()
+			continue
+		}
+		if len(blok) == 0 {
+			mulai = i + 1
+		}
+		blok = append(blok, trimmed)
+	}
+	proses()
+	return result, kesalahan
+}
+
+func soalDariGIFT(blok string) (models.SoalInput, error) {
+	if strings.HasPrefix(blok, "::") {
+		akhir := indeksTanpaEscape(blok[2:], "::")
+		if akhir < 0 {
+			return models.SoalInput{}, fmt.Errorf("judul soal tidak ditutup dengan ::")
+		}
+		blok = strings.TrimSpace(blok[akhir+4:])
+	}
+	format := ""
+	if strings.HasPrefix(blok, "[") {
+		if akhir := strings.Index(blok, "]"); akhir > 0 {
+			if strings.EqualFold(blok[1:akhir], "markdown") {
+				format = models.FormatMarkdown
+			}
+			blok = strings.TrimSpace(blok[akhir+1:])
+		}
+	}
+
+	buka := indeksTanpaEscape(blok, "{")
+	if buka < 0 {
+		return models.SoalInput{}, fmt.Errorf("blok jawaban {...} tidak ditemukan")
+	}
+	tutup := indeksTanpaEscape(blok[buka:], "}")
+	if tutup < 0 {
+		return models.SoalInput{}, fmt.Errorf("blok jawaban tidak ditutup dengan }")
+	}
+	tutup += buka
+
+	// Soal isian rumpang ("... {=jawaban} ...") ditulis dengan garis kosong di tempat jawaban
+	soal := models.SoalInput{Soal: unescapeGIFT(strings.TrimSpace(blok[:buka])), Format: format}
+	if sisa := strings.TrimSpace(blok[tutup+1:]); sisa != "" {
+		soal.Soal = strings.TrimSpace(soal.Soal + " _____ " + unescapeGIFT(sisa))
+	}
+	if soal.Soal == "" {
+		return models.SoalInput{}, fmt.Errorf("teks soal kosong")
+	}
+
+	isi := strings.TrimSpace(blok[buka+1 : tutup])
+	if isi == "" {
+		soal.Tipe = models.TipeEsai
+		return soal, nil
+	}
+	if strings.HasPrefix(isi, "#") {
+		return models.SoalInput{}, fmt.Errorf("soal numerik GIFT tidak didukung")
+	}
+	benarSalah := isi
+	if i := indeksTanpaEscape(isi, "#"); i >= 0 {
+		benarSalah = strings.TrimSpace(isi[:i])
+	}
+	switch strings.ToUpper(benarSalah) {
+	case "T", "TRUE", "F", "FALSE":
+		benar := strings.HasPrefix(strings.ToUpper(benarSalah), "T")
+		soal.Tipe = models.TipePilihanGanda
+		soal.JumlahPilihan = 2
+		soal.Pilihan = []models.Pilihan{{Text: "Benar", Benar: benar}, {Text: "Salah", Benar: !benar}}
+		return soal, nil
+	}
+
+	jawaban, err := jawabanGIFT(isi)
+	if err != nil {
+		return models.SoalInput{}, err
+	}
+	adaSalah, jumlahBenar := false, 0
+	for _, j := range jawaban {
+		if j.penanda == '~' {
+			adaSalah = true
+		}
+		if j.benar {
+			jumlahBenar++
+		}
+		soal.Pilihan = append(soal.Pilihan, models.Pilihan{Text: j.teks, Benar: j.benar})
+	}
+
+	switch {
+	case !adaSalah:
+		// Semua jawaban diawali =, berarti daftar jawaban singkat yang diterima
+		soal.Tipe = models.TipeIsianSingkat
+		for i := range soal.Pilihan {
+			soal.Pilihan[i].Benar = true
+		}
+	case jumlahBenar > 1:
+		soal.Tipe = models.TipePilihanGandaKompleks
+		soal.JumlahPilihan = len(soal.Pilihan)
+	default:
+		soal.Tipe = models.TipePilihanGanda
+		soal.JumlahPilihan = len(soal.Pilihan)
+	}
+	return soal, nil
+}
+
+type jawabanGIFTItem struct {
+	penanda byte
+	teks    string
+	benar   bool
+}
+
+// jawabanGIFT memecah isi blok {...} menjadi jawaban yang diawali = atau ~
+func jawabanGIFT(isi string) ([]jawabanGIFTItem, error) {
+	var bagian []string
+	var penanda []byte
+	for i := 0; i < len(isi); i++ {
+		switch isi[i] {
+		case '\\':
+			if len(bagian) > 0 {
+				bagian[len(bagian)-1] += isi[i:min(i+2, len(isi))]
+			}
+			i++
+			continue
+		case '=', '~':
+			penanda = append(penanda, isi[i])
+			bagian = append(bagian, "")
+			continue
+		}
+		if len(bagian) == 0 {
+			if isi[i] == ' ' || isi[i] == '\n' || isi[i] == '\t' {
+				continue
+			}
+			return nil, fmt.Errorf("jawaban harus diawali = atau ~")
+		}
+		bagian[len(bagian)-1] += string(isi[i])
+	}
+
+	result := make([]jawabanGIFTItem, 0, len(bagian))
+	for i, teks := range bagian {
+		if indeksTanpaEscape(teks, "->") >= 0 {
+			return nil, fmt.Errorf("soal menjodohkan GIFT tidak didukung")
+		}
+		if j := indeksTanpaEscape(teks, "#"); j >= 0 {
+			teks = teks[:j]
+		}
+		teks = strings.TrimSpace(teks)
+
+		item := jawabanGIFTItem{penanda: penanda[i], benar: penanda[i] == '='}
+		if m := giftBobot.FindStringSubmatch(teks); m != nil {
+			bobot, _ := strconv.ParseFloat(m[1], 64)
+			item.benar = bobot > 0
+			teks = strings.TrimSpace(teks[len(m[0]):])
+		}
+		item.teks = unescapeGIFT(teks)
+		if item.teks == "" {
+			return nil, fmt.Errorf("jawaban ke-%d kosong", i+1)
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// indeksTanpaEscape seperti strings.Index tetapi melewati karakter yang di-escape dengan backslash
+func indeksTanpaEscape(s, sub string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeGIFT(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
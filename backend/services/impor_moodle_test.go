@@ -0,0 +1,120 @@
+package services
+
+import (
+	"backend/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSoalDariGIFT(t *testing.T) {
+	gift := strings.Join([]string{
+		"// bank soal kelas X",
+		"$CATEGORY: $course$/Fisika",
+		"",
+		"::Gaya::[html]Satuan gaya adalah {=Newton ~Joule#bukan ~Watt}",
+		"",
+		"Matahari terbit dari timur.{T}",
+		"",
+		"Bilangan prima {",
+		"  ~%50%2",
+		"  ~%50%3",
+		"  ~%-100%4",
+		"}",
+		"",
+		"Ibu kota Indonesia adalah {=Jakarta =DKI Jakarta}.",
+		"",
+		"Jelaskan hukum Newton I. {}",
+		"",
+		"Rumus energi E \\= mc^2 benar? {=Ya ~Tidak}",
+		"",
+		"Berapa 2 + 2? {#4}",
+		"",
+		"Pasangkan {=a -> 1 =b -> 2}",
+		"",
+		"Tanpa jawaban sama sekali",
+	}, "\n")
+	soal, kesalahan := SoalDariGIFT(gift)
+
+	if len(soal) != 6 {
+		t.Fatalf("expected 6 soal, got %d: %+v", len(soal), soal)
+	}
+	pg := soal[0]
+	if pg.Baris != 4 || pg.Soal.Soal != "Satuan gaya adalah" || pg.Soal.Tipe != models.TipePilihanGanda || pg.Soal.JumlahPilihan != 3 ||
+		!reflect.DeepEqual(pg.Soal.Pilihan, []models.Pilihan{{Text: "Newton", Benar: true}, {Text: "Joule"}, {Text: "Watt"}}) {
+		t.Errorf("unexpected multiple choice %+v", pg)
+	}
+	bs := soal[1].Soal
+	if bs.JumlahPilihan != 2 || !bs.Pilihan[0].Benar || bs.Pilihan[1].Benar {
+		t.Errorf("unexpected true/false %+v", bs)
+	}
+	kompleks := soal[2].Soal
+	if kompleks.Tipe != models.TipePilihanGandaKompleks || !kompleks.Pilihan[0].Benar || !kompleks.Pilihan[1].Benar || kompleks.Pilihan[2].Benar {
+		t.Errorf("unexpected weighted choice %+v", kompleks)
+	}
+	isian := soal[3].Soal
+	if isian.Tipe != models.TipeIsianSingkat || isian.Soal != "Ibu kota Indonesia adalah _____ ." || len(isian.Pilihan) != 2 {
+		t.Errorf("unexpected short answer %+v", isian)
+	}
+	if soal[4].Soal.Tipe != models.TipeEsai || len(soal[4].Soal.Pilihan) != 0 {
+		t.Errorf("unexpected essay %+v", soal[4].Soal)
+	}
+	if soal[5].Soal.Soal != "Rumus energi E = mc^2 benar?" {
+		t.Errorf("escaped = must be unescaped, got %q", soal[5].Soal.Soal)
+	}
+
+	var baris []int
+	for _, k := range kesalahan {
+		baris = append(baris, k.Baris)
+	}
+	if !reflect.DeepEqual(baris, []int{20, 22, 24}) {
+		t.Errorf("errors on lines %v, want [20 22 24]: %+v", baris, kesalahan)
+	}
+}
+
+func TestSoalDariAiken(t *testing.T) {
+	aiken := strings.Join([]string{
+		"Ibu kota Jawa Barat adalah",
+		"A. Bandung",
+		"B) Bogor",
+		"C. Bekasi",
+		"ANSWER: A",
+		"Bilangan prima terkecil?",
+		"A. 1",
+		"B. 2",
+		"ANSWER: B",
+		"",
+		"Pilihan tidak urut",
+		"A. satu",
+		"C. tiga",
+		"ANSWER: A",
+		"Kunci di luar pilihan",
+		"A. satu",
+		"B. dua",
+		"ANSWER: D",
+		"Tanpa ANSWER",
+		"A. satu",
+	}, "\r\n")
+	if DeteksiFormatTeks(aiken) != FormatAiken || DeteksiFormatTeks("Soal {=a ~b}") != FormatGIFT {
+		t.Fatal("format detection failed")
+	}
+	soal, kesalahan := SoalDariAiken(aiken)
+
+	if len(soal) != 2 {
+		t.Fatalf("expected 2 soal, got %+v", soal)
+	}
+	if soal[0].Baris != 1 || soal[0].Soal.JumlahPilihan != 3 || !soal[0].Soal.Pilihan[0].Benar || soal[0].Soal.Pilihan[1].Text != "Bogor" {
+		t.Errorf("unexpected first soal %+v", soal[0])
+	}
+	if soal[1].Baris != 6 || !soal[1].Soal.Pilihan[1].Benar {
+		t.Errorf("unexpected second soal %+v", soal[1])
+	}
+
+	var baris []int
+	for _, k := range kesalahan {
+		baris = append(baris, k.Baris)
+	}
+	if !reflect.DeepEqual(baris, []int{11, 18, 19}) {
+		t.Errorf("errors on lines %v, want [11 18 19]: %+v", baris, kesalahan)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"archive/zip"
+	"backend/models"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// MaxBarisImpor batas jumlah soal dalam satu file impor
+const MaxBarisImpor = 1000
+
+// kolomPilihan huruf kolom pilihan jawaban, sesuai batas 2-6 pilihan
+var kolomPilihan = []string{"A", "B", "C", "D", "E", "F"}
+
+// BarisSoal satu soal hasil impor beserta nomor baris dan nama file gambarnya di ZIP
+type BarisSoal struct {
+	Baris  int
+	Soal   models.SoalInput
+	Gambar string
+}
+
+// BacaSpreadsheet membaca semua baris sheet pertama file XLSX atau file CSV.
+// CSV boleh dipisah koma atau titik koma (format Excel berbahasa Indonesia).
+func BacaSpreadsheet(namaFile string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(namaFile)) {
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("file XLSX tidak dapat dibaca: %w", err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("file XLSX tidak memiliki sheet")
+		}
+		return f.GetRows(sheets[0])
+	case ".csv":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		baris, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(baris, []byte(";")) > bytes.Count(baris, []byte(",")) {
+			reader.Comma = ';'
+		}
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("file CSV tidak valid: %w", err)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("format file harus .xlsx atau .csv")
+	}
+}
+
+// BacaFileSoal membaca file bank soal sesuai ekstensinya: .xlsx/.csv sebagai spreadsheet, .gift sebagai GIFT,
+// dan .txt sebagai GIFT atau Aiken. format ("gift"/"aiken") boleh diisi untuk memaksa format file .txt,
+// bila kosong formatnya ditebak dari isi file. Kesalahan per baris/soal dikembalikan terpisah dari
+// kesalahan membaca file.
+func BacaFileSoal(namaFile, format string, r io.Reader) ([]BarisSoal, []models.KesalahanImpor, error) {
+	ext := strings.ToLower(filepath.Ext(namaFile))
+	if ext == ".xlsx" || ext == ".csv" {
+		rows, err := BacaSpreadsheet(namaFile, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		soal, kesalahan := SoalDariSpreadsheet(rows)
+		return soal, kesalahan, nil
+	}
+	if ext != ".gift" && ext != ".txt" {
+		return nil, nil, fmt.Errorf("format file harus .xlsx, .csv, .gift, atau .txt")
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	teks := string(data)
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = FormatGIFT
+		if ext == ".txt" {
+			format = DeteksiFormatTeks(teks)
+		}
+	}
+
+	var soal []BarisSoal
+	var kesalahan []models.KesalahanImpor
+	switch format {
+	case FormatGIFT:
+		soal, kesalahan = SoalDariGIFT(teks)
+	case FormatAiken:
+		soal, kesalahan = SoalDariAiken(teks)
+	default:
+		return nil, nil, fmt.Errorf("format %q tidak dikenal, gunakan gift atau aiken", format)
+	}
+	if len(soal)+len(kesalahan) > MaxBarisImpor {
+		return nil, nil, fmt.Errorf("maksimal %d soal per file", MaxBarisImpor)
+	}
+	return soal, kesalahan, nil
+}
+
+// SoalDariSpreadsheet mengubah baris spreadsheet menjadi soal. Baris pertama adalah header dengan kolom
+// Soal, A-F (pilihan), Kunci, serta kolom opsional Gambar, Bobot, Tipe, Format, Topik, KD, Kesulitan, dan
+// Jumlah Pilihan (kosong berarti mengikuti mata pelajaran); urutan kolom bebas.
+// Kunci berisi huruf pilihan benar, dipisah koma untuk soal pilihan ganda kompleks. Semua kesalahan
+// dikumpulkan per baris supaya guru bisa memperbaiki file sekaligus.
+func SoalDariSpreadsheet(rows [][]string) ([]BarisSoal, []models.KesalahanImpor) {
+	if len(rows) == 0 {
+		return nil, []models.KesalahanImpor{{Baris: 1, Pesan: "file kosong"}}
+	}
+
+	kolom := make(map[string]int)
+	for i, nama := range rows[0] {
+		kolom[strings.ToUpper(strings.TrimSpace(nama))] = i
+	}
+	for _, wajib := range []string{"SOAL", "KUNCI"} {
+		if _, ok := kolom[wajib]; !ok {
+			return nil, []models.KesalahanImpor{{Baris: 1, Pesan: fmt.Sprintf("header wajib memiliki kolom %s", wajib)}}
+		}
+	}
+	if len(rows)-1 > MaxBarisImpor {
+		return nil, []models.KesalahanImpor{{Baris: 1, Pesan: fmt.Sprintf("maksimal %d soal per file", MaxBarisImpor)}}
+	}
+
+	var result []BarisSoal
+	var kesalahan []models.KesalahanImpor
+	for i, row := range rows[1:] {
+		nomor := i + 2 // nomor baris seperti yang terlihat di Excel
+		sel := func(nama string) string {
+			if j, ok := kolom[nama]; ok && j < len(row) {
+				return strings.TrimSpace(row[j])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		baris, err := soalDariBaris(sel)
+		if err != nil {
+			kesalahan = append(kesalahan, models.KesalahanImpor{Baris: nomor, Pesan: err.Error()})
+			continue
+		}
+		baris.Baris = nomor
+		result = append(result, baris)
+	}
+	return result, kesalahan
+}
+
+func soalDariBaris(sel func(kolom string) string) (BarisSoal, error) {
+	soal := models.SoalInput{Soal: sel("SOAL"), Tipe: strings.ToUpper(sel("TIPE")), Format: strings.ToUpper(sel("FORMAT")),
+		Topik: sel("TOPIK"), KD: sel("KD"), Kesulitan: strings.ToUpper(sel("KESULITAN"))}
+	if bobot := sel("BOBOT"); bobot != "" {
+		n, err := strconv.Atoi(bobot)
+		if err != nil {
+			return BarisSoal{}, fmt.Errorf("bobot %q bukan angka", bobot)
+		}
+		soal.Bobot = n
+	}
+	if jumlah := sel("JUMLAH PILIHAN"); jumlah != "" {
+		n, err := strconv.Atoi(jumlah)
+		if err != nil {
+			return BarisSoal{}, fmt.Errorf("jumlah pilihan %q bukan angka", jumlah)
+		}
+		soal.JumlahPilihan = n
+	}
+
+	// Pilihan diisi berurutan dari A, kolom kosong di tengah dianggap kesalahan
+	kosong := ""
+	for _, huruf := range kolomPilihan {
+		teks := sel(huruf)
+		if teks == "" {
+			if kosong == "" {
+				kosong = huruf
+			}
+			continue
+		}
+		if kosong != "" {
+			return BarisSoal{}, fmt.Errorf("pilihan %s kosong sedangkan pilihan %s terisi", kosong, huruf)
+		}
+		soal.Pilihan = append(soal.Pilihan, models.Pilihan{Text: teks})
+	}
+
+	kunci := strings.ToUpper(sel("KUNCI"))
+	if kunci == "" && soal.Tipe != models.TipeEsai && soal.Tipe != models.TipeIsianSingkat {
+		return BarisSoal{}, fmt.Errorf("kunci jawaban kosong")
+	}
+	for _, huruf := range strings.FieldsFunc(kunci, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+		i := strings.Index("ABCDEF", huruf)
+		if len(huruf) != 1 || i < 0 || i >= len(soal.Pilihan) {
+			return BarisSoal{}, fmt.Errorf("kunci %q tidak sesuai dengan pilihan yang terisi", huruf)
+		}
+		soal.Pilihan[i].Benar = true
+	}
+
+	return BarisSoal{Soal: soal, Gambar: sel("GAMBAR")}, nil
+}
+
+// GambarZip membuka file ZIP berisi gambar soal dan mengindeks isinya berdasarkan nama file
+// tanpa folder, tidak peka huruf besar kecil
+func GambarZip(data []byte) (map[string]*zip.File, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("file ZIP gambar tidak valid: %w", err)
+	}
+	result := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		result[strings.ToLower(path.Base(f.Name))] = f
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"backend/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBacaSpreadsheetCSV(t *testing.T) {
+	// Excel berbahasa Indonesia menyimpan CSV dengan titik koma dan BOM
+	csv := "\xef\xbb\xbfSoal;A;B;Kunci\n\"1 + 1; berapa?\";2;3;A\n"
+	rows, err := BacaSpreadsheet("soal.csv", strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("BacaSpreadsheet: %v", err)
+	}
+	want := [][]string{{"Soal", "A", "B", "Kunci"}, {"1 + 1; berapa?", "2", "3", "A"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+
+	if _, err := BacaSpreadsheet("soal.xls", strings.NewReader("")); err == nil {
+		t.Error("expected error for unsupported extension")
+	}
+}
+
+func TestSoalDariSpreadsheet(t *testing.T) {
+	rows := [][]string{
+		{"Soal", "A", "B", "C", "D", "Kunci", "Gambar", "Bobot", "Tipe"},
+		{"Ibu kota Jawa Barat?", "Bandung", "Bogor", "Bekasi", "Depok", "a", "peta.png"},
+		{"Bilangan prima?", "2", "3", "4", "", "A, B", "", "2", "pilihan_ganda_kompleks"},
+		{"", "", "", "", ""},
+		{"Pilihan bolong", "1", "", "3", "", "A"},
+		{"Kunci di luar pilihan", "1", "2", "", "", "C"},
+		{"Bobot bukan angka", "1", "2", "", "", "A", "", "dua"},
+	}
+	soal, kesalahan := SoalDariSpreadsheet(rows)
+
+	if len(soal) != 2 {
+		t.Fatalf("expected 2 valid rows, got %+v", soal)
+	}
+	pertama := soal[0]
+	if pertama.Baris != 2 || pertama.Gambar != "peta.png" || len(pertama.Soal.Pilihan) != 4 || !pertama.Soal.Pilihan[0].Benar {
+		t.Errorf("unexpected first row %+v", pertama)
+	}
+	kedua := soal[1].Soal
+	if kedua.Tipe != models.TipePilihanGandaKompleks || kedua.Bobot != 2 || len(kedua.Pilihan) != 3 ||
+		!kedua.Pilihan[0].Benar || !kedua.Pilihan[1].Benar || kedua.Pilihan[2].Benar {
+		t.Errorf("unexpected second row %+v", kedua)
+	}
+
+	// Baris kosong dilewati, nomor baris mengikuti Excel (header di baris 1)
+	var baris []int
+	for _, k := range kesalahan {
+		baris = append(baris, k.Baris)
+	}
+	if !reflect.DeepEqual(baris, []int{5, 6, 7}) {
+		t.Errorf("errors on rows %v, want [5 6 7]: %+v", baris, kesalahan)
+	}
+
+	if _, kesalahan := SoalDariSpreadsheet([][]string{{"Pertanyaan", "A"}}); len(kesalahan) != 1 || kesalahan[0].Baris != 1 {
+		t.Errorf("missing header columns must be reported on row 1, got %+v", kesalahan)
+	}
+}
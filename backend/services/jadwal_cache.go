@@ -0,0 +1,136 @@
+package services
+
+import (
+	"backend/models"
+	"time"
+)
+
+// batasUmurCacheJadwal membatasi umur cache jadwal, supaya perubahan jadwal dari
+// panel admin yang tidak memberi sinyal tetap terbaca tanpa menunggu transisi berikutnya
+const batasUmurCacheJadwal = 5 * time.Minute
+
+// NotifyJadwalChanged menandai cache jadwal kadaluarsa dan membangunkan tracker
+func (ut *UjianTracker) NotifyJadwalChanged() {
+	ut.jadwalKadaluarsa.Store(true)
+
+	select {
+	case ut.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// getJadwal mengembalikan salinan jadwal dari cache, dan hanya query ulang ke database
+// ketika cache kadaluarsa, tanggal berganti, atau waktu transisi berikutnya sudah lewat
+func (ut *UjianTracker) getJadwal(now time.Time) (map[models.Tingkat][]models.TingkatData, error) {
+	ut.jadwalMutex.Lock()
+	defer ut.jadwalMutex.Unlock()
+
+	today := now.Format("2006-01-02")
+	if ut.jadwal == nil || ut.jadwalKadaluarsa.Load() || ut.jadwalTanggal != today || !now.Before(ut.reloadBerikutnya) {
+		// Reset flag sebelum query, sinyal yang datang selama query tetap memicu reload berikutnya
+		ut.jadwalKadaluarsa.Store(false)
+		jadwalData, err := ut.jadwalRepo.GetJadwalUjian(now)
+		if err != nil {
+			ut.jadwalKadaluarsa.Store(true)
+			return nil, err
+		}
+
+		ut.jadwal = jadwalData
+		ut.jadwalTanggal = today
+		ut.reloadBerikutnya = ut.nextTransition(jadwalData, now)
+	}
+
+	return cloneJadwal(ut.jadwal), nil
+}
+
+// nextTransition menghitung waktu terdekat setelah now di mana tampilan sesi bisa berubah:
+// 5 menit sebelum sesi, mulai/selesai sesi dan ujian, reset 120 menit setelah sesi terakhir,
+// berakhirnya ujian susulan, dan pergantian hari
+func (ut *UjianTracker) nextTransition(jadwalData map[models.Tingkat][]models.TingkatData, now time.Time) time.Time {
+	next := now.Add(batasUmurCacheJadwal)
+	besok := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	if besok.Before(next) {
+		next = besok
+	}
+
+	consider := func(t time.Time) {
+		if t.After(now) && t.Before(next) {
+			next = t
+		}
+	}
+	considerJam := func(jam string, offset time.Duration) {
+		if jam == "" {
+			return
+		}
+		if t, err := parseTime(now, jam); err == nil {
+			consider(t.Add(offset))
+		}
+	}
+
+	today := now.Format("2006-01-02")
+	for _, tingkatDataList := range jadwalData {
+		for _, tingkatData := range tingkatDataList {
+			if tingkatData.Tanggal != today {
+				continue
+			}
+			for _, sesi := range tingkatData.SesiUjian {
+				considerJam(sesi.JamMulai, -5*time.Minute)
+				considerJam(sesi.JamMulai, 0)
+				considerJam(sesi.JamSelesai, 0)
+				considerJam(sesi.JamSelesai, 120*time.Minute)
+				for _, ujian := range sesi.Ujian {
+					considerJam(ujian.JamMulai, 0)
+					considerJam(ujian.JamSelesai, 0)
+				}
+			}
+		}
+	}
+
+	ut.mutex.RLock()
+	for _, ujianList := range ut.UjianSusulan {
+		for _, ujian := range ujianList {
+			consider(ujian.WaktuBerakhir)
+		}
+	}
+	ut.mutex.RUnlock()
+
+	return next
+}
+
+// catatStatusUjian menyamakan status dan token ujian di cache setelah ditulis ke database,
+// supaya tick berikutnya tidak menganggap status berubah lagi
+func (ut *UjianTracker) catatStatusUjian(ujianID, status, token string) {
+	ut.jadwalMutex.Lock()
+	defer ut.jadwalMutex.Unlock()
+
+	for _, tingkatDataList := range ut.jadwal {
+		for i := range tingkatDataList {
+			for j := range tingkatDataList[i].SesiUjian {
+				sesi := &tingkatDataList[i].SesiUjian[j]
+				for k := range sesi.Ujian {
+					if sesi.Ujian[k].ID == ujianID {
+						sesi.Ujian[k].Status = status
+						sesi.Ujian[k].Token = token
+					}
+				}
+			}
+		}
+	}
+}
+
+func cloneJadwal(jadwalData map[models.Tingkat][]models.TingkatData) map[models.Tingkat][]models.TingkatData {
+	result := make(map[models.Tingkat][]models.TingkatData, len(jadwalData))
+	for tingkat, tingkatDataList := range jadwalData {
+		salinan := make([]models.TingkatData, len(tingkatDataList))
+		for i, tingkatData := range tingkatDataList {
+			salinan[i] = tingkatData
+			salinan[i].SesiUjian = make([]models.SesiData, len(tingkatData.SesiUjian))
+			for j, sesi := range tingkatData.SesiUjian {
+				salinan[i].SesiUjian[j] = sesi
+				salinan[i].SesiUjian[j].Ujian = append([]models.UjianData(nil), sesi.Ujian...)
+			}
+		}
+		result[tingkat] = salinan
+	}
+	return result
+}
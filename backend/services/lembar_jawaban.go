@@ -0,0 +1,73 @@
+package services
+
+import (
+	"backend/models"
+	"math"
+)
+
+// LembarJawaban menyusun jawaban siswa per soal dengan urutan soal dan pilihan yang sama seperti saat ujian
+// (AcakSoal), beserta status dan skor setiap soal. soalList harus soal yang tercatat untuk siswa, bukan bank
+// soal saat ini, supaya soal yang ditambah atau diarsipkan setelah ujian tidak menggeser nomor soal. Kunci jawaban hanya disertakan bila tampilkanKunci true,
+// tanpa kunci siswa tetap melihat soal mana yang benar atau salah.
+func (k KunciJawaban) LembarJawaban(soalList []models.SoalInput, jawaban []models.JawabanSiswa, ujianID, siswaDetailID string, tampilkanKunci bool) []models.SoalLembarJawaban {
+	soalByID := make(map[string]models.SoalInput, len(soalList))
+	for _, soal := range soalList {
+		soalByID[soal.ID] = soal
+	}
+	jawabanSoal := make(map[string]models.JawabanSiswa, len(jawaban))
+	for _, js := range jawaban {
+		jawabanSoal[js.SoalID] = js
+	}
+
+	urutan := AcakSoal(soalList, ujianID, siswaDetailID)
+	result := make([]models.SoalLembarJawaban, 0, len(urutan))
+	for i, su := range urutan {
+		soal := soalByID[su.ID]
+		lembar := models.SoalLembarJawaban{
+			Nomor:   i + 1,
+			SoalID:  su.ID,
+			Soal:    su.Soal,
+			Gambar:  su.Gambar,
+			Tipe:    su.Tipe,
+			Format:  su.Format,
+			Bobot:   k.Bobot(su.ID),
+			Pilihan: make([]models.PilihanLembarJawaban, 0, len(su.Pilihan)),
+			Status:  StatusKosong,
+		}
+		if lembar.Tipe == "" {
+			lembar.Tipe = models.TipePilihanGanda
+		}
+
+		js, dijawab := jawabanSoal[su.ID]
+		if dijawab {
+			var kredit float64
+			lembar.Status, kredit = k.StatusJawaban(js)
+			lembar.Skor = math.Round(kredit*float64(lembar.Bobot)*100) / 100
+			lembar.JawabanTeks = js.JawabanTeks
+		}
+		dipilih := make(map[string]bool)
+		for _, id := range js.Dipilih() {
+			dipilih[id] = true
+		}
+
+		benar := make(map[string]bool, len(soal.Pilihan))
+		for _, p := range soal.Pilihan {
+			benar[p.ID] = p.Benar
+		}
+		for _, p := range su.Pilihan {
+			pilihan := models.PilihanLembarJawaban{ID: p.ID, Text: p.Text, Dipilih: dipilih[p.ID]}
+			if tampilkanKunci {
+				b := benar[p.ID]
+				pilihan.Benar = &b
+			}
+			lembar.Pilihan = append(lembar.Pilihan, pilihan)
+		}
+		if tampilkanKunci && lembar.Tipe == models.TipeIsianSingkat {
+			for _, p := range soal.Pilihan {
+				lembar.KunciTeks = append(lembar.KunciTeks, p.Text)
+			}
+		}
+		result = append(result, lembar)
+	}
+	return result
+}
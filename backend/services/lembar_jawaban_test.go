@@ -0,0 +1,50 @@
+package services
+
+import (
+	"backend/models"
+	"reflect"
+	"testing"
+)
+
+func TestLembarJawaban(t *testing.T) {
+	soal := []models.SoalInput{
+		soalPenilaian("a", 2),
+		{ID: "isian", Tipe: models.TipeIsianSingkat, Bobot: 1, Pilihan: []models.Pilihan{{Text: "Jakarta"}, {Text: "DKI Jakarta"}}},
+		{ID: "esai", Tipe: models.TipeEsai, Bobot: 4},
+	}
+	kunci := NewKunciJawaban(soal, &models.Ujian{})
+	skor := 3.0
+	jawaban := []models.JawabanSiswa{
+		jawab("a", "a-salah"),
+		{SoalID: "isian", JawabanTeks: " jakarta "},
+		{SoalID: "esai", JawabanTeks: "Uraian", SkorManual: &skor},
+	}
+
+	lembar := kunci.LembarJawaban(soal, jawaban, "ujian", "siswa", true)
+	urutan := AcakSoal(soal, "ujian", "siswa")
+	got := map[string]models.SoalLembarJawaban{}
+	for i, l := range lembar {
+		if l.SoalID != urutan[i].ID || l.Nomor != i+1 {
+			t.Fatalf("answer sheet order must follow AcakSoal, got %s at %d", l.SoalID, i+1)
+		}
+		got[l.SoalID] = l
+	}
+
+	if a := got["a"]; a.Status != StatusSalah || a.Skor != 0 || len(a.Pilihan) != 2 {
+		t.Errorf("soal a: %+v", a)
+	}
+	isian := got["isian"]
+	if isian.Status != StatusBenar || isian.Skor != 1 || len(isian.Pilihan) != 0 ||
+		!reflect.DeepEqual(isian.KunciTeks, []string{"Jakarta", "DKI Jakarta"}) {
+		t.Errorf("isian: %+v", isian)
+	}
+	if esai := got["esai"]; esai.Status != StatusSebagian || esai.Skor != 3 || esai.JawabanTeks != "Uraian" {
+		t.Errorf("esai: %+v", esai)
+	}
+
+	for _, l := range kunci.LembarJawaban(soal, nil, "ujian", "siswa", false) {
+		if l.Status != StatusKosong || l.KunciTeks != nil {
+			t.Errorf("unanswered soal without kunci: %+v", l)
+		}
+	}
+}
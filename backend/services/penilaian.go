@@ -0,0 +1,293 @@
+package services
+
+import (
+	"backend/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// IDBaru membuat ID yang kompatibel dengan cuid Prisma
+func IDBaru() string {
+	return fmt.Sprintf("cm%s", uuid.New().String()[:20])
+}
+
+// KunciJawaban kunci jawaban dan aturan penilaian satu ujian
+type KunciJawaban struct {
+	// PenaltiSalah bagian bobot soal yang dikurangkan untuk setiap jawaban salah, 0 berarti tanpa pengurangan
+	PenaltiSalah float64
+	// NilaiParsial memberi nilai sebagian untuk soal pilihan ganda kompleks yang benar sebagian,
+	// bila false soal tersebut hanya bernilai penuh jika semua pilihan yang dipilih tepat
+	NilaiParsial bool
+	soal         map[string]kunciSoal
+}
+
+type kunciSoal struct {
+	tipe        string
+	bobot       int
+	pilihan     map[string]bool // jawabanId -> benar
+	jumlahBenar int
+	diterima    map[string]bool // jawaban isian singkat yang diterima, sudah dinormalisasi
+}
+
+// MaxPanjangJawabanTeks batas panjang jawaban isian singkat dan esai dalam karakter
+const MaxPanjangJawabanTeks = 10000
+
+// NewKunciJawaban membuat kunci jawaban dari bank soal dan aturan penilaian ujian.
+// Soal tanpa bobot dianggap berbobot 1.
+func NewKunciJawaban(soalList []models.SoalInput, ujian *models.Ujian) KunciJawaban {
+	kunci := KunciJawaban{
+		PenaltiSalah: math.Max(ujian.PenaltiSalah, 0),
+		NilaiParsial: ujian.NilaiParsial,
+		soal:         make(map[string]kunciSoal, len(soalList)),
+	}
+	for _, soal := range soalList {
+		ks := kunciSoal{tipe: soal.Tipe, bobot: soal.Bobot, pilihan: make(map[string]bool, len(soal.Pilihan))}
+		if ks.tipe == "" {
+			ks.tipe = models.TipePilihanGanda
+		}
+		if ks.bobot <= 0 {
+			ks.bobot = 1
+		}
+		if ks.tipe == models.TipeIsianSingkat {
+			// Semua pilihan soal isian singkat adalah jawaban yang diterima
+			ks.diterima = make(map[string]bool, len(soal.Pilihan))
+			for _, pilihan := range soal.Pilihan {
+				ks.diterima[NormalisasiJawaban(pilihan.Text)] = true
+			}
+			kunci.soal[soal.ID] = ks
+			continue
+		}
+		for _, pilihan := range soal.Pilihan {
+			ks.pilihan[pilihan.ID] = pilihan.Benar
+			if pilihan.Benar {
+				ks.jumlahBenar++
+			}
+		}
+		kunci.soal[soal.ID] = ks
+	}
+	return kunci
+}
+
+// Kompleks true bila soal adalah pilihan ganda kompleks (jawaban berupa daftar jawabanId)
+func (k KunciJawaban) Kompleks(soalID string) bool {
+	return k.soal[soalID].tipe == models.TipePilihanGandaKompleks
+}
+
+// Teks true bila soal dijawab dengan teks (isian singkat atau esai), bukan jawabanId
+func (k KunciJawaban) Teks(soalID string) bool {
+	tipe := k.soal[soalID].tipe
+	return tipe == models.TipeIsianSingkat || tipe == models.TipeEsai
+}
+
+// Esai true bila soal adalah esai yang dinilai manual oleh guru
+func (k KunciJawaban) Esai(soalID string) bool {
+	return k.soal[soalID].tipe == models.TipeEsai
+}
+
+// Bobot bobot soal, 0 bila soal tidak ada di ujian
+func (k KunciJawaban) Bobot(soalID string) int {
+	return k.soal[soalID].bobot
+}
+
+// ValidTeks memeriksa bahwa soal dijawab dengan teks, jawabannya tidak kosong dan tidak melebihi batas panjang
+func (k KunciJawaban) ValidTeks(soalID, teks string) bool {
+	if !k.Teks(soalID) {
+		return false
+	}
+	teks = strings.TrimSpace(teks)
+	return teks != "" && utf8.RuneCountInString(teks) <= MaxPanjangJawabanTeks
+}
+
+// NormalisasiJawaban menyamakan jawaban isian singkat sebelum dibandingkan:
+// huruf kecil dan spasi berurutan/di tepi diabaikan
+func NormalisasiJawaban(teks string) string {
+	return strings.ToLower(strings.Join(strings.Fields(teks), " "))
+}
+
+// Valid memeriksa bahwa soal ada, setiap jawabanId adalah pilihan dari soal tersebut tanpa duplikat,
+// dan soal pilihan ganda biasa dijawab dengan tepat satu pilihan
+func (k KunciJawaban) Valid(soalID string, dipilih []string) bool {
+	soal, ok := k.soal[soalID]
+	if !ok || len(dipilih) == 0 || k.Teks(soalID) {
+		return false
+	}
+	if soal.tipe != models.TipePilihanGandaKompleks && len(dipilih) != 1 {
+		return false
+	}
+	sudah := make(map[string]bool, len(dipilih))
+	for _, id := range dipilih {
+		if _, ok := soal.pilihan[id]; !ok || sudah[id] {
+			return false
+		}
+		sudah[id] = true
+	}
+	return true
+}
+
+// kredit bagian bobot soal yang didapat siswa, antara 0 dan 1. Pada nilai parsial setiap pilihan salah
+// yang ikut dipilih membatalkan satu pilihan benar, sehingga memilih semua pilihan tidak menguntungkan.
+func (k KunciJawaban) kredit(soalID string, dipilih []string) float64 {
+	soal := k.soal[soalID]
+	var benar, salah int
+	for _, id := range dipilih {
+		if soal.pilihan[id] {
+			benar++
+		} else {
+			salah++
+		}
+	}
+	if soal.jumlahBenar == 0 {
+		return 0
+	}
+	if salah == 0 && benar == soal.jumlahBenar {
+		return 1
+	}
+	if soal.tipe != models.TipePilihanGandaKompleks || !k.NilaiParsial {
+		return 0
+	}
+	return math.Max(float64(benar-salah)/float64(soal.jumlahBenar), 0)
+}
+
+// Status jawaban satu soal hasil StatusJawaban
+const (
+	StatusBenar        = "benar"
+	StatusSebagian     = "sebagian" // pilihan ganda kompleks benar sebagian atau esai dengan skor di bawah bobot
+	StatusSalah        = "salah"
+	StatusBelumDinilai = "belum_dinilai" // esai yang belum diberi skor oleh guru
+	StatusKosong       = "kosong"        // tidak dijawab atau jawabannya tidak valid
+)
+
+// StatusJawaban menilai satu jawaban siswa dan mengembalikan statusnya beserta bagian bobot soal
+// yang didapat (0-1). Esai memakai skor dari guru, soal lain memakai kunci jawaban.
+func (k KunciJawaban) StatusJawaban(js models.JawabanSiswa) (string, float64) {
+	bobot := float64(k.soal[js.SoalID].bobot)
+	if k.Teks(js.SoalID) {
+		if !k.ValidTeks(js.SoalID, js.JawabanTeks) {
+			return StatusKosong, 0
+		}
+		if k.Esai(js.SoalID) {
+			switch {
+			case js.SkorManual == nil:
+				return StatusBelumDinilai, 0
+			case *js.SkorManual >= bobot:
+				return StatusBenar, 1
+			case *js.SkorManual > 0:
+				return StatusSebagian, *js.SkorManual / bobot
+			default:
+				return StatusSalah, 0
+			}
+		}
+		if k.soal[js.SoalID].diterima[NormalisasiJawaban(js.JawabanTeks)] {
+			return StatusBenar, 1
+		}
+		return StatusSalah, 0
+	}
+
+	dipilih := js.Dipilih()
+	if !k.Valid(js.SoalID, dipilih) {
+		return StatusKosong, 0
+	}
+	switch kredit := k.kredit(js.SoalID, dipilih); {
+	case kredit >= 1:
+		return StatusBenar, 1
+	case kredit > 0:
+		return StatusSebagian, kredit
+	default:
+		return StatusSalah, 0
+	}
+}
+
+// NilaiHasil menilai jawaban tersimpan siswa dan menyusun hasil ujiannya. Nilai dihitung terhadap
+// total bobot seluruh soal ujian sehingga soal yang tidak dijawab tercatat sebagai kosong, dan jawaban
+// salah mengurangi skor sebesar PenaltiSalah dikali bobot soalnya. Soal yang benar sebagian mendapat
+// nilai sebagian tanpa pengurangan dan ikut dihitung sebagai salah pada jumlah benar/salah.
+// Esai mendapat skor dari guru tanpa pengurangan, esai yang belum dinilai tidak dihitung benar, salah, maupun kosong.
+// Jawaban yang tidak valid, misalnya untuk soal yang sudah dihapus, tidak ikut dinilai.
+func (k KunciJawaban) NilaiHasil(ujianID, siswaDetailID string, jawaban []models.JawabanSiswa, waktuPengerjaan int) models.HasilDetail {
+	rincian := models.RincianNilai{PenaltiSalah: k.PenaltiSalah, NilaiParsial: k.NilaiParsial}
+	for _, soal := range k.soal {
+		rincian.TotalBobot += soal.bobot
+	}
+
+	var benar, salah int
+	for _, js := range jawaban {
+		bobot := k.soal[js.SoalID].bobot
+		status, kredit := k.StatusJawaban(js)
+		if k.Esai(js.SoalID) && status != StatusKosong {
+			rincian.BobotEsai += bobot
+			rincian.SkorEsai += kredit * float64(bobot)
+			switch status {
+			case StatusBelumDinilai:
+				rincian.EsaiBelumDinilai++
+			case StatusBenar:
+				benar++
+			default:
+				salah++
+			}
+			continue
+		}
+		switch status {
+		case StatusBenar:
+			benar++
+			rincian.BobotBenar += bobot
+		case StatusSebagian:
+			salah++
+			rincian.BobotSebagian += bobot
+			rincian.SkorSebagian += kredit * float64(bobot)
+		case StatusSalah:
+			salah++
+			rincian.BobotSalah += bobot
+		}
+	}
+	rincian.BobotKosong = rincian.TotalBobot - rincian.BobotBenar - rincian.BobotSebagian - rincian.BobotSalah - rincian.BobotEsai
+	rincian.Pengurangan = k.PenaltiSalah * float64(rincian.BobotSalah)
+	rincian.Skor = math.Max(float64(rincian.BobotBenar)+rincian.SkorSebagian+rincian.SkorEsai-rincian.Pengurangan, 0)
+
+	// Score calculation: (weighted score / total weight) * 100, dibulatkan ke bawah
+	nilai := 0
+	if rincian.TotalBobot > 0 {
+		nilai = int(math.Floor(rincian.Skor/float64(rincian.TotalBobot)*100 + 1e-9))
+	}
+
+	return models.HasilDetail{
+		ID:              IDBaru(),
+		SiswaDetailID:   siswaDetailID,
+		UjianID:         ujianID,
+		WaktuPengerjaan: waktuPengerjaan,
+		Nilai:           nilai,
+		Benar:           benar,
+		Salah:           salah,
+		Kosong:          len(k.soal) - benar - salah - rincian.EsaiBelumDinilai,
+		Rincian:         &rincian,
+		JawabanHash:     HashJawaban(jawaban),
+	}
+}
+
+// HashJawaban sidik himpunan jawaban (soal -> pilihan) yang tidak bergantung urutan,
+// dipakai untuk membedakan pengumpulan ulang dengan jawaban yang berbeda
+func HashJawaban(jawaban []models.JawabanSiswa) string {
+	pasangan := make([]string, len(jawaban))
+	for i, js := range jawaban {
+		dipilih := append([]string(nil), js.Dipilih()...)
+		sort.Strings(dipilih)
+		pasangan[i] = js.SoalID + "=" + strings.Join(dipilih, ",")
+		if js.JawabanTeks != "" {
+			pasangan[i] += "\x00" + js.JawabanTeks
+		}
+	}
+	sort.Strings(pasangan)
+
+	h := sha256.New()
+	for _, p := range pasangan {
+		h.Write([]byte(p))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"backend/models"
+	"testing"
+)
+
+func soalPenilaian(id string, bobot int) models.SoalInput {
+	return models.SoalInput{ID: id, Bobot: bobot, Pilihan: []models.Pilihan{
+		{ID: id + "-benar", Benar: true},
+		{ID: id + "-salah"},
+	}}
+}
+
+func jawab(soalID, jawabanID string) models.JawabanSiswa {
+	return models.JawabanSiswa{SoalID: soalID, JawabanID: jawabanID}
+}
+
+func TestNilaiHasil(t *testing.T) {
+	bobotSama := []models.SoalInput{soalPenilaian("a", 0), soalPenilaian("b", 0), soalPenilaian("c", 0), soalPenilaian("d", 0)}
+	berbobot := []models.SoalInput{soalPenilaian("a", 3), soalPenilaian("b", 1), soalPenilaian("c", 1)}
+
+	tests := []struct {
+		name    string
+		soal    []models.SoalInput
+		penalti float64
+		jawaban []models.JawabanSiswa
+		nilai   int
+		rincian models.RincianNilai
+		kosong  int
+	}{
+		{
+			name:    "bobot sama, satu benar dari empat soal",
+			soal:    bobotSama,
+			jawaban: []models.JawabanSiswa{jawab("a", "a-benar")},
+			nilai:   25,
+			rincian: models.RincianNilai{TotalBobot: 4, BobotBenar: 1, BobotKosong: 3, Skor: 1},
+			kosong:  3,
+		},
+		{
+			name:    "soal berbobot",
+			soal:    berbobot,
+			jawaban: []models.JawabanSiswa{jawab("a", "a-benar"), jawab("b", "b-salah")},
+			nilai:   60,
+			rincian: models.RincianNilai{TotalBobot: 5, BobotBenar: 3, BobotSalah: 1, BobotKosong: 1, Skor: 3},
+			kosong:  1,
+		},
+		{
+			name:    "pengurangan untuk jawaban salah",
+			soal:    bobotSama,
+			penalti: 0.25,
+			jawaban: []models.JawabanSiswa{jawab("a", "a-benar"), jawab("b", "b-benar"), jawab("c", "c-salah"), jawab("d", "d-salah")},
+			nilai:   37,
+			rincian: models.RincianNilai{TotalBobot: 4, BobotBenar: 2, BobotSalah: 2, PenaltiSalah: 0.25, Pengurangan: 0.5, Skor: 1.5},
+		},
+		{
+			name:    "skor tidak pernah negatif",
+			soal:    berbobot,
+			penalti: 1,
+			jawaban: []models.JawabanSiswa{jawab("a", "a-salah"), jawab("b", "b-benar")},
+			nilai:   0,
+			rincian: models.RincianNilai{TotalBobot: 5, BobotBenar: 1, BobotSalah: 3, BobotKosong: 1, PenaltiSalah: 1, Pengurangan: 3},
+			kosong:  1,
+		},
+		{
+			name:    "jawaban untuk soal yang sudah dihapus diabaikan",
+			soal:    berbobot,
+			jawaban: []models.JawabanSiswa{jawab("x", "x-benar"), jawab("c", "c-benar")},
+			nilai:   20,
+			rincian: models.RincianNilai{TotalBobot: 5, BobotBenar: 1, BobotKosong: 4, Skor: 1},
+			kosong:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasil := NewKunciJawaban(tt.soal, &models.Ujian{PenaltiSalah: tt.penalti}).NilaiHasil("ujian", "siswa", tt.jawaban, 60)
+			if hasil.Nilai != tt.nilai || hasil.Kosong != tt.kosong {
+				t.Errorf("nilai = %d, kosong = %d, want %d and %d", hasil.Nilai, hasil.Kosong, tt.nilai, tt.kosong)
+			}
+			if hasil.Rincian == nil || *hasil.Rincian != tt.rincian {
+				t.Errorf("rincian = %+v, want %+v", hasil.Rincian, tt.rincian)
+			}
+		})
+	}
+}
+
+// soalKompleks soal pilihan ganda kompleks dengan dua pilihan benar dan dua pilihan salah
+func soalKompleks(id string, bobot int) models.SoalInput {
+	return models.SoalInput{ID: id, Bobot: bobot, Tipe: models.TipePilihanGandaKompleks, Pilihan: []models.Pilihan{
+		{ID: id + "-benar1", Benar: true},
+		{ID: id + "-benar2", Benar: true},
+		{ID: id + "-salah1"},
+		{ID: id + "-salah2"},
+	}}
+}
+
+func jawabBanyak(soalID string, jawabanIDs ...string) models.JawabanSiswa {
+	return models.JawabanSiswa{SoalID: soalID, JawabanIDs: jawabanIDs}
+}
+
+func TestNilaiHasilPilihanGandaKompleks(t *testing.T) {
+	soal := []models.SoalInput{soalKompleks("k", 2), soalPenilaian("a", 2)}
+
+	tests := []struct {
+		name    string
+		ujian   models.Ujian
+		jawaban []models.JawabanSiswa
+		nilai   int
+		benar   int
+		salah   int
+		rincian models.RincianNilai
+	}{
+		{
+			name:    "semua pilihan tepat",
+			jawaban: []models.JawabanSiswa{jawabBanyak("k", "k-benar2", "k-benar1")},
+			nilai:   50,
+			benar:   1,
+			rincian: models.RincianNilai{TotalBobot: 4, BobotBenar: 2, BobotKosong: 2, Skor: 2},
+		},
+		{
+			name:    "benar sebagian tanpa nilai parsial",
+			jawaban: []models.JawabanSiswa{jawabBanyak("k", "k-benar1")},
+			nilai:   0,
+			salah:   1,
+			rincian: models.RincianNilai{TotalBobot: 4, BobotSalah: 2, BobotKosong: 2},
+		},
+		{
+			name:    "benar sebagian dengan nilai parsial",
+			ujian:   models.Ujian{NilaiParsial: true},
+			jawaban: []models.JawabanSiswa{jawabBanyak("k", "k-benar1"), jawab("a", "a-benar")},
+			nilai:   75,
+			benar:   1,
+			salah:   1,
+			rincian: models.RincianNilai{TotalBobot: 4, BobotBenar: 2, BobotSebagian: 2, NilaiParsial: true, SkorSebagian: 1, Skor: 3},
+		},
+		{
+			name:    "pilihan salah membatalkan pilihan benar",
+			ujian:   models.Ujian{NilaiParsial: true},
+			jawaban: []models.JawabanSiswa{jawabBanyak("k", "k-benar1", "k-salah1")},
+			nilai:   0,
+			salah:   1,
+			rincian: models.RincianNilai{TotalBobot: 4, BobotSalah: 2, BobotKosong: 2, NilaiParsial: true},
+		},
+		{
+			name:    "nilai sebagian tidak dikenai pengurangan",
+			ujian:   models.Ujian{NilaiParsial: true, PenaltiSalah: 0.25},
+			jawaban: []models.JawabanSiswa{jawabBanyak("k", "k-benar1"), jawab("a", "a-salah")},
+			nilai:   12,
+			salah:   2,
+			rincian: models.RincianNilai{TotalBobot: 4, BobotSebagian: 2, BobotSalah: 2, PenaltiSalah: 0.25, NilaiParsial: true, SkorSebagian: 1, Pengurangan: 0.5, Skor: 0.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasil := NewKunciJawaban(soal, &tt.ujian).NilaiHasil("ujian", "siswa", tt.jawaban, 60)
+			if hasil.Nilai != tt.nilai || hasil.Benar != tt.benar || hasil.Salah != tt.salah {
+				t.Errorf("nilai/benar/salah = %d/%d/%d, want %d/%d/%d", hasil.Nilai, hasil.Benar, hasil.Salah, tt.nilai, tt.benar, tt.salah)
+			}
+			if hasil.Rincian == nil || *hasil.Rincian != tt.rincian {
+				t.Errorf("rincian = %+v, want %+v", hasil.Rincian, tt.rincian)
+			}
+		})
+	}
+}
+
+func TestKunciJawabanValid(t *testing.T) {
+	kunci := NewKunciJawaban([]models.SoalInput{soalKompleks("k", 1), soalPenilaian("a", 1)}, &models.Ujian{})
+	tests := []struct {
+		soalID  string
+		dipilih []string
+		valid   bool
+	}{
+		{"a", []string{"a-benar"}, true},
+		{"a", []string{"a-benar", "a-salah"}, false},
+		{"k", []string{"k-benar1", "k-salah2"}, true},
+		{"k", []string{"k-benar1", "k-benar1"}, false},
+		{"k", []string{"a-benar"}, false},
+		{"k", nil, false},
+	}
+	for _, tt := range tests {
+		if got := kunci.Valid(tt.soalID, tt.dipilih); got != tt.valid {
+			t.Errorf("Valid(%s, %v) = %v, want %v", tt.soalID, tt.dipilih, got, tt.valid)
+		}
+	}
+}
+
+func TestHashJawabanTidakBergantungUrutan(t *testing.T) {
+	a := []models.JawabanSiswa{jawab("a", "a-benar"), jawab("b", "b-salah")}
+	b := []models.JawabanSiswa{jawab("b", "b-salah"), jawab("a", "a-benar")}
+	if HashJawaban(a) != HashJawaban(b) {
+		t.Error("hash must not depend on answer order")
+	}
+	if HashJawaban(a) == HashJawaban([]models.JawabanSiswa{jawab("a", "a-benar"), jawab("b", "b-benar")}) {
+		t.Error("different answers must hash differently")
+	}
+}
+
+func TestNilaiHasilIsianDanEsai(t *testing.T) {
+	soal := []models.SoalInput{
+		{ID: "isian", Tipe: models.TipeIsianSingkat, Bobot: 1, Pilihan: []models.Pilihan{{Text: "Jakarta"}, {Text: "DKI Jakarta"}}},
+		{ID: "esai", Tipe: models.TipeEsai, Bobot: 4},
+	}
+	kunci := NewKunciJawaban(soal, &models.Ujian{PenaltiSalah: 0.5})
+	skor := func(s float64) *float64 { return &s }
+
+	tests := []struct {
+		name    string
+		jawaban []models.JawabanSiswa
+		nilai   int
+		benar   int
+		salah   int
+		kosong  int
+		rincian models.RincianNilai
+	}{
+		{
+			name: "isian tidak peka huruf besar dan spasi, esai belum dinilai",
+			jawaban: []models.JawabanSiswa{
+				{SoalID: "isian", JawabanTeks: "  dki   JAKARTA "},
+				{SoalID: "esai", JawabanTeks: "Karena ..."},
+			},
+			nilai:   20,
+			benar:   1,
+			rincian: models.RincianNilai{TotalBobot: 5, BobotBenar: 1, BobotEsai: 4, PenaltiSalah: 0.5, EsaiBelumDinilai: 1, Skor: 1},
+		},
+		{
+			name: "esai dinilai sebagian tanpa pengurangan",
+			jawaban: []models.JawabanSiswa{
+				{SoalID: "isian", JawabanTeks: "Bandung"},
+				{SoalID: "esai", JawabanTeks: "Karena ...", SkorManual: skor(3)},
+			},
+			nilai:   50,
+			salah:   2,
+			rincian: models.RincianNilai{TotalBobot: 5, BobotSalah: 1, BobotEsai: 4, PenaltiSalah: 0.5, SkorEsai: 3, Pengurangan: 0.5, Skor: 2.5},
+		},
+		{
+			name:    "esai kosong tidak dinilai",
+			jawaban: []models.JawabanSiswa{{SoalID: "esai", JawabanTeks: "   "}},
+			nilai:   0,
+			kosong:  2,
+			rincian: models.RincianNilai{TotalBobot: 5, BobotKosong: 5, PenaltiSalah: 0.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasil := kunci.NilaiHasil("ujian", "siswa", tt.jawaban, 60)
+			if hasil.Nilai != tt.nilai || hasil.Benar != tt.benar || hasil.Salah != tt.salah || hasil.Kosong != tt.kosong {
+				t.Errorf("nilai/benar/salah/kosong = %d/%d/%d/%d, want %d/%d/%d/%d",
+					hasil.Nilai, hasil.Benar, hasil.Salah, hasil.Kosong, tt.nilai, tt.benar, tt.salah, tt.kosong)
+			}
+			if hasil.Rincian == nil || *hasil.Rincian != tt.rincian {
+				t.Errorf("rincian = %+v, want %+v", hasil.Rincian, tt.rincian)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"backend/clock"
+	"backend/models"
+	"backend/repositories"
+	"log"
+	"time"
+)
+
+// PenutupUjian menutup pengerjaan siswa yang waktunya sudah habis tetapi tidak pernah dikumpulkan
+// (misalnya browser tertutup), dinilai dari jawaban yang sempat tersimpan lewat autosave
+type PenutupUjian struct {
+	Clock   clock.Clock
+	peserta repositories.PesertaRepository
+	ujian   repositories.UjianRepository
+	soal    repositories.SoalRepository
+	hasil   repositories.HasilRepository
+	jawaban repositories.JawabanRepository
+}
+
+func NewPenutupUjian(repos repositories.Repositories, clk clock.Clock) *PenutupUjian {
+	return &PenutupUjian{
+		Clock:   clk,
+		peserta: repos.Peserta,
+		ujian:   repos.Ujian,
+		soal:    repos.Soal,
+		hasil:   repos.Hasil,
+		jawaban: repos.Jawaban,
+	}
+}
+
+// Start menjalankan TutupKedaluwarsa setiap interval
+func (p *PenutupUjian) Start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.TutupKedaluwarsa()
+	}
+}
+
+// TutupKedaluwarsa menyimpan hasil untuk setiap peserta yang sudah melewati batas waktu ditambah
+// ToleransiPengumpulan, lalu mengembalikan jumlah pengerjaan yang ditutup. Hasil ini ditandai DitutupOtomatis
+// sehingga submit yang datang belakangan menerima hasil tersebut, bukan 409.
+func (p *PenutupUjian) TutupKedaluwarsa() int {
+	pesertaList, err := p.peserta.ListPesertaBelumSelesai()
+	if err != nil {
+		log.Printf("Error fetching peserta belum selesai: %v", err)
+		return 0
+	}
+
+	now := p.Clock.Now()
+	ujianCache := make(map[string]*models.Ujian)
+	bankCache := make(map[string][]models.SoalInput)
+	ditutup := 0
+
+	for _, peserta := range pesertaList {
+		ujian, ok := ujianCache[peserta.UjianID]
+		if !ok {
+			ujian, err = p.ujian.GetUjian(peserta.UjianID)
+			if err != nil {
+				log.Printf("Error fetching ujian %s: %v", peserta.UjianID, err)
+			}
+			ujianCache[peserta.UjianID] = ujian
+		}
+		if ujian == nil {
+			continue
+		}
+
+		waktuPengerjaan, terlambat := DurasiPengerjaan(peserta.WaktuMulai, now, ujian.WaktuPengerjaan)
+		if !terlambat {
+			continue
+		}
+
+		// Bank soal bersama hanya dipakai peserta tanpa catatan soal, soal tercatat bisa sudah diarsipkan
+		bank, ok := bankCache[peserta.UjianID]
+		if len(peserta.SoalIDs) > 0 || !ok {
+			bank, err = p.soal.GetSoalUjianTercatat(peserta.UjianID, peserta.SoalIDs)
+			if err != nil {
+				log.Printf("Error fetching soal ujian %s: %v", peserta.UjianID, err)
+				continue
+			}
+			if len(peserta.SoalIDs) == 0 {
+				bankCache[peserta.UjianID] = bank
+			}
+		}
+		// Siswa dinilai hanya dari soal yang tercatat atau diundikan untuknya
+		soalList, err := SoalPeserta(bank, ujian, &peserta)
+		if err != nil {
+			log.Printf("Error drawing soal ujian %s for siswa %s: %v", peserta.UjianID, peserta.SiswaDetailID, err)
+			continue
+		}
+		kunci := NewKunciJawaban(soalList, ujian)
+
+		jawaban, err := p.jawaban.GetJawabanSiswa(peserta.UjianID, peserta.SiswaDetailID)
+		if err != nil {
+			log.Printf("Error fetching jawaban siswa %s: %v", peserta.SiswaDetailID, err)
+			continue
+		}
+
+		hasil := kunci.NilaiHasil(peserta.UjianID, peserta.SiswaDetailID, jawaban, waktuPengerjaan)
+		hasil.DitutupOtomatis = true
+		if err := p.hasil.SimpanHasil(hasil, nil); err != nil {
+			// Siswa bisa saja mengumpulkan bersamaan, unique constraint hasil mencegah hasil ganda
+			log.Printf("Error closing ujian %s for siswa %s: %v", peserta.UjianID, peserta.SiswaDetailID, err)
+			continue
+		}
+		log.Printf("Ujian %s siswa %s ditutup otomatis, %d jawaban dinilai", peserta.UjianID, peserta.SiswaDetailID, len(jawaban))
+		ditutup++
+	}
+	return ditutup
+}
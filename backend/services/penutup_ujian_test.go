@@ -0,0 +1,61 @@
+package services
+
+import (
+	"backend/clock"
+	"backend/models"
+	"backend/repositories"
+	"testing"
+)
+
+func TestPenutupUjianTutupKedaluwarsa(t *testing.T) {
+	store := storeDuaSesi()
+	store.Soal = []models.Soal{
+		{ID: "soal-1", Soal: "1 + 1 = ?", MataPelajaranID: "mp-mtk"},
+		{ID: "soal-2", Soal: "2 x 3 = ?", MataPelajaranID: "mp-mtk"},
+	}
+	store.Jawaban = []models.Jawaban{
+		{ID: "s1-a", SoalID: "soal-1", Jawaban: "1"},
+		{ID: "s1-b", SoalID: "soal-1", Jawaban: "2", Benar: true},
+		{ID: "s2-a", SoalID: "soal-2", Jawaban: "5"},
+		{ID: "s2-b", SoalID: "soal-2", Jawaban: "6", Benar: true},
+	}
+	store.Peserta = []models.UjianPeserta{
+		{ID: "p-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", WaktuMulai: pukul(7, 30)},
+		{ID: "p-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2", WaktuMulai: pukul(7, 30)},
+		{ID: "p-3", UjianID: "ujian-mtk", SiswaDetailID: "siswa-3", WaktuMulai: pukul(8, 0)},
+	}
+	store.JawabanSiswa = []models.JawabanSiswa{
+		{ID: "js-1", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", SoalID: "soal-1", JawabanID: "s1-b"},
+		{ID: "js-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-1", SoalID: "soal-2", JawabanID: "s2-a"},
+	}
+	// siswa-2 sudah mengumpulkan sendiri
+	store.Hasil = []models.HasilDetail{{ID: "hasil-2", UjianID: "ujian-mtk", SiswaDetailID: "siswa-2"}}
+
+	clk := clock.NewFake(pukul(9, 31))
+	penutup := NewPenutupUjian(repositories.NewMemoryRepositories(store), clk)
+
+	// 09:31 masih dalam toleransi pengumpulan siswa-1
+	if ditutup := penutup.TutupKedaluwarsa(); ditutup != 0 {
+		t.Fatalf("closed %d attempts inside the grace period", ditutup)
+	}
+
+	clk.Set(pukul(9, 33))
+	if ditutup := penutup.TutupKedaluwarsa(); ditutup != 1 {
+		t.Fatalf("closed %d attempts, want 1", ditutup)
+	}
+	hasil, err := store.GetHasilSiswa("ujian-mtk", "siswa-1")
+	if err != nil {
+		t.Fatalf("expected hasil for siswa-1: %v", err)
+	}
+	if hasil.Nilai != 50 || hasil.Benar != 1 || hasil.Salah != 1 || hasil.WaktuPengerjaan != 7200 || !hasil.DitutupOtomatis {
+		t.Errorf("unexpected hasil %+v", hasil)
+	}
+	if _, err := store.GetHasilSiswa("ujian-mtk", "siswa-3"); err != repositories.ErrNotFound {
+		t.Errorf("siswa-3 still has time left, got err %v", err)
+	}
+
+	// Putaran berikutnya tidak menutup ulang
+	if ditutup := penutup.TutupKedaluwarsa(); ditutup != 0 {
+		t.Errorf("closed %d attempts again", ditutup)
+	}
+}
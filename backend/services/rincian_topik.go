@@ -0,0 +1,89 @@
+package services
+
+import (
+	"backend/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RincianTopik mengelompokkan soal yang dikerjakan siswa menurut KD dan topiknya lalu menghitung jumlah
+// benar, salah, dan kosong per kelompok, diurutkan menurut KD lalu topik. Soal tanpa KD maupun topik
+// dikumpulkan dalam satu kelompok kosong. Bila tidak ada soal yang diberi KD atau topik hasilnya nil.
+// soalList harus soal yang tercatat untuk siswa supaya soal yang ditambah setelah ujian tidak dihitung kosong.
+func (k KunciJawaban) RincianTopik(soalList []models.SoalInput, jawaban []models.JawabanSiswa) []models.RincianTopik {
+	jawabanSoal := make(map[string]models.JawabanSiswa, len(jawaban))
+	for _, js := range jawaban {
+		jawabanSoal[js.SoalID] = js
+	}
+
+	type kunciTopik struct{ kd, topik string }
+	index := make(map[kunciTopik]int)
+	var result []models.RincianTopik
+	adaTopik := false
+	for _, soal := range soalList {
+		kt := kunciTopik{soal.KD, soal.Topik}
+		adaTopik = adaTopik || kt != kunciTopik{}
+		i, ok := index[kt]
+		if !ok {
+			result = append(result, models.RincianTopik{KD: kt.kd, Topik: kt.topik})
+			i = len(result) - 1
+			index[kt] = i
+		}
+
+		r := &result[i]
+		r.Total++
+		status := StatusKosong
+		if js, ok := jawabanSoal[soal.ID]; ok {
+			status, _ = k.StatusJawaban(js)
+		}
+		switch status {
+		case StatusBenar:
+			r.Benar++
+		case StatusSebagian, StatusSalah:
+			r.Salah++
+		case StatusBelumDinilai:
+			r.BelumDinilai++
+		default:
+			r.Kosong++
+		}
+	}
+	if !adaTopik {
+		return nil
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		// Kelompok tanpa KD ditaruh setelah kelompok yang punya KD
+		if (a.KD == "") != (b.KD == "") {
+			return b.KD == ""
+		}
+		if c := bandingkanKD(a.KD, b.KD); c != 0 {
+			return c < 0
+		}
+		if (a.Topik == "") != (b.Topik == "") {
+			return b.Topik == ""
+		}
+		return a.Topik < b.Topik
+	})
+	return result
+}
+
+// bandingkanKD membandingkan kode KD per bagian angka sehingga 3.2 sebelum 3.10
+func bandingkanKD(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA != nil || errB != nil {
+			if c := strings.Compare(pa[i], pb[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if na != nb {
+			return na - nb
+		}
+	}
+	return len(pa) - len(pb)
+}
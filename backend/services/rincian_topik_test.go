@@ -0,0 +1,39 @@
+package services
+
+import (
+	"backend/models"
+	"reflect"
+	"testing"
+)
+
+func TestRincianTopik(t *testing.T) {
+	soal := []models.SoalInput{
+		soalPenilaian("a", 1), soalPenilaian("b", 1), soalPenilaian("c", 1), soalPenilaian("d", 1), soalPenilaian("e", 1),
+		{ID: "esai", Tipe: models.TipeEsai, Bobot: 1},
+	}
+	soal[0].KD, soal[0].Topik = "3.10", "Statistika"
+	soal[1].KD, soal[1].Topik = "3.2", "Aljabar"
+	soal[2].KD, soal[2].Topik = "3.2", "Aljabar"
+	soal[3].Topik = "Geometri"
+	soal[5].KD, soal[5].Topik = "3.2", "Aljabar"
+	kunci := NewKunciJawaban(soal, &models.Ujian{})
+
+	jawaban := []models.JawabanSiswa{
+		jawab("a", "a-benar"), jawab("b", "b-benar"), jawab("c", "c-salah"), jawab("e", "e-benar"),
+		{SoalID: "esai", JawabanTeks: "Uraian"},
+	}
+	want := []models.RincianTopik{
+		{KD: "3.2", Topik: "Aljabar", Benar: 1, Salah: 1, BelumDinilai: 1, Total: 3},
+		{KD: "3.10", Topik: "Statistika", Benar: 1, Total: 1},
+		{Topik: "Geometri", Kosong: 1, Total: 1},
+		{Benar: 1, Total: 1},
+	}
+	if got := kunci.RincianTopik(soal, jawaban); !reflect.DeepEqual(got, want) {
+		t.Errorf("RincianTopik = %+v, want %+v", got, want)
+	}
+
+	tanpaTopik := []models.SoalInput{soalPenilaian("a", 1)}
+	if got := NewKunciJawaban(tanpaTopik, &models.Ujian{}).RincianTopik(tanpaTopik, nil); got != nil {
+		t.Errorf("expected nil without any topik/KD, got %+v", got)
+	}
+}
@@ -1,43 +1,60 @@
 package services
 
 import (
+	"backend/clock"
 	"backend/models"
 	"backend/repositories"
 	"crypto/rand"
-	"database/sql"
 	"fmt"
 	"log"
 	"math"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type UjianTracker struct {
-    DB                   *sql.DB
+    Clock                clock.Clock
+    jadwalRepo           repositories.JadwalRepository
+    ujianRepo            repositories.UjianRepository
     Broadcast            chan models.ResponseDataUjian
     mutex                sync.RWMutex
     UjianSusulan         map[models.Tingkat][]models.UjianSusulanData
+
+    // Cache jadwal hari ini, dimuat ulang hanya pada batas transisi sesi
+    jadwalMutex          sync.Mutex
+    jadwal               map[models.Tingkat][]models.TingkatData
+    jadwalTanggal        string
+    jadwalKadaluarsa     atomic.Bool
+    reloadBerikutnya     time.Time
+    refresh              chan struct{}
+    stop                 chan struct{}
+    stopOnce             sync.Once
 }
 
-func NewUjianTracker(db *sql.DB, broadcast chan models.ResponseDataUjian) *UjianTracker {
+func NewUjianTracker(jadwalRepo repositories.JadwalRepository, ujianRepo repositories.UjianRepository, clk clock.Clock, broadcast chan models.ResponseDataUjian) *UjianTracker {
 	return &UjianTracker{
-		DB:       db,
-		Broadcast: broadcast,
+		Clock:      clk,
+		jadwalRepo: jadwalRepo,
+		ujianRepo:  ujianRepo,
+		Broadcast:  broadcast,
+		refresh:    make(chan struct{}, 1),
+		stop:       make(chan struct{}),
 	}
 }
 
 
 func (ut *UjianTracker) UpdateTrackingData() {
     ut.cleanExpiredUjianSusulan()
-    jadwalData, err := repositories.GetJadwalUjian(ut.DB)
+    now := ut.Clock.Now()
+    jadwalData, err := ut.getJadwal(now)
     if err != nil {
         log.Printf("Error getting jadwal data: %v", err)
         return
     }
 
-    now := time.Now().In(time.Local) 
     today := now.Format("2006-01-02")
     result := models.ResponseDataUjian{
         X:   []models.TingkatData{},
@@ -110,8 +127,8 @@ func (ut *UjianTracker) UpdateTrackingData() {
                     ut.checkAndCleanExpiredSessionsBeforeIntegration(tingkatData, tingkat, now)
 
                     sort.Slice(tingkatData.SesiUjian, func(i, j int) bool {
-                        jamI, _ := parseTime(tingkatData.SesiUjian[i].JamMulai)
-                        jamJ, _ := parseTime(tingkatData.SesiUjian[j].JamMulai)
+                        jamI, _ := parseTime(now, tingkatData.SesiUjian[i].JamMulai)
+                        jamJ, _ := parseTime(now, tingkatData.SesiUjian[j].JamMulai)
                         return jamI.Before(jamJ)
                     })
                     
@@ -121,8 +138,8 @@ func (ut *UjianTracker) UpdateTrackingData() {
                         sesi := &tingkatData.SesiUjian[j]
                         
                         if sesi.JamMulai != "" && sesi.JamSelesai != "" {
-                            sesiMulai, errMulai := parseTime(sesi.JamMulai)
-                            sesiSelesai, errSelesai := parseTime(sesi.JamSelesai)
+                            sesiMulai, errMulai := parseTime(now, sesi.JamMulai)
+                            sesiSelesai, errSelesai := parseTime(now, sesi.JamSelesai)
                             
                             if errMulai != nil || errSelesai != nil {
                                 continue
@@ -158,7 +175,7 @@ func (ut *UjianTracker) UpdateTrackingData() {
                                         ujian := &sesi.Ujian[k]
                                         oldStatus := ujian.Status
                                         
-                                        ujianMulai, err := parseTime(ujian.JamMulai)
+                                        ujianMulai, err := parseTime(now, ujian.JamMulai)
                                         if err != nil {
                                             continue
                                         }
@@ -174,7 +191,7 @@ func (ut *UjianTracker) UpdateTrackingData() {
                                         }
                                         
                                         if oldStatus != newStatus {
-                                            err := updateUjianStatus(ut.DB, ujian, newStatus)
+                                            err := ut.updateUjianStatus(ujian, newStatus)
                                             if err != nil {
                                                 log.Printf("Error updating ujian status: %v", err)
                                             }
@@ -200,7 +217,7 @@ func (ut *UjianTracker) UpdateTrackingData() {
                                 for nextIdx := j + 1; nextIdx < len(tingkatData.SesiUjian); nextIdx++ {
                                     nextSesi := &tingkatData.SesiUjian[nextIdx]
                                     if nextSesi.JamMulai != "" {
-                                        waktuNext, err := parseTime(nextSesi.JamMulai)
+                                        waktuNext, err := parseTime(now, nextSesi.JamMulai)
                                         if err == nil {
                                             sesiBerikutnya = nextSesi
                                             waktuSesiBerikutnya = waktuNext
@@ -338,7 +355,7 @@ func (ut *UjianTracker) integratedUjianSusulan(tingkatData *models.TingkatData,
         return
     }
     
-    now := time.Now().In(time.Local)
+    now := ut.Clock.Now()
     
     var validUjian []models.UjianSusulanData
     for _, ujian := range ujianList {
@@ -411,8 +428,8 @@ func (ut *UjianTracker) canAddUjianSusulanToSesi(sesiID string, tingkatData *mod
     for _, sesi := range tingkatData.SesiUjian {
         if sesi.ID == sesiID {
             if sesi.JamMulai != "" && sesi.JamSelesai != "" {
-                sesiMulai, errMulai := parseTime(sesi.JamMulai)
-                sesiSelesai, errSelesai := parseTime(sesi.JamSelesai)
+                sesiMulai, errMulai := parseTime(now, sesi.JamMulai)
+                sesiSelesai, errSelesai := parseTime(now, sesi.JamSelesai)
                 
                 if errMulai == nil && errSelesai == nil {
                     // Bisa tambah jika:
@@ -436,8 +453,8 @@ func (ut *UjianTracker) isSesiCurrentlyActive(sesiList []models.SesiData, now ti
     for i := range sesiList {
         sesi := &sesiList[i]
         if sesi.JamMulai != "" && sesi.JamSelesai != "" {
-            sesiMulai, errMulai := parseTime(sesi.JamMulai)
-            sesiSelesai, errSelesai := parseTime(sesi.JamSelesai)
+            sesiMulai, errMulai := parseTime(now, sesi.JamMulai)
+            sesiSelesai, errSelesai := parseTime(now, sesi.JamSelesai)
             
             if errMulai == nil && errSelesai == nil {
                 // Sesi sedang berlangsung
@@ -472,7 +489,7 @@ func (ut *UjianTracker) shouldDisplaySesi(sesi *models.SesiData, sesiMulai, sesi
         for nextIdx := currentIndex + 1; nextIdx < len(allSesi); nextIdx++ {
             nextSesi := &allSesi[nextIdx]
             if nextSesi.JamMulai != "" && !strings.Contains(nextSesi.ID, "susulan") {
-                waktuNext, err := parseTime(nextSesi.JamMulai)
+                waktuNext, err := parseTime(now, nextSesi.JamMulai)
                 if err == nil {
                     sesiBerikutnya = nextSesi
                     waktuSesiBerikutnya = waktuNext
@@ -541,7 +558,7 @@ func (ut *UjianTracker) checkAndCleanExpiredSessionsBeforeIntegration(tingkatDat
     
     for _, sesi := range tingkatData.SesiUjian {
         if sesi.JamMulai != "" {
-            sesiMulai, err := parseTime(sesi.JamMulai)
+            sesiMulai, err := parseTime(now, sesi.JamMulai)
             if err == nil && now.Before(sesiMulai) {
                 if nextSesiTime == nil || sesiMulai.Before(*nextSesiTime) {
                     nextSesiTime = &sesiMulai
@@ -554,7 +571,7 @@ func (ut *UjianTracker) checkAndCleanExpiredSessionsBeforeIntegration(tingkatDat
     var lastFinishedSesiTime *time.Time
     for _, sesi := range tingkatData.SesiUjian {
         if sesi.JamSelesai != "" {
-            sesiSelesai, err := parseTime(sesi.JamSelesai)
+            sesiSelesai, err := parseTime(now, sesi.JamSelesai)
             if err == nil && now.After(sesiSelesai) {
                 if lastFinishedSesiTime == nil || sesiSelesai.After(*lastFinishedSesiTime) {
                     lastFinishedSesiTime = &sesiSelesai
@@ -608,9 +625,14 @@ func (ut *UjianTracker) cleanAllUjianSusulanByTingkat(tingkat models.Tingkat, re
     log.Printf("DEBUG: Cleaned ALL %d ujian susulan for tingkat %s - Reason: %s",
         cleanedCount, tingkat, reason)
     
+    if err := ut.jadwalRepo.HapusUjianSusulan(ujianIDsToReactivate); err != nil {
+        log.Printf("ERROR: Gagal menghapus ujian susulan tingkat %s: %v", tingkat, err)
+    }
+    
     // Update status ujian ke 'active' setelah dibersihkan
     if len(ujianIDsToReactivate) > 0 {
-        if err := updateUjianStatusList(ut.DB, ujianIDsToReactivate); err != nil {
+        ut.jadwalKadaluarsa.Store(true)
+        if err := ut.ujianRepo.SelesaikanUjian(ujianIDsToReactivate); err != nil {
             log.Printf("ERROR: Gagal update status ujian setelah clean by tingkat %s: %v", tingkat, err)
         } else {
             log.Printf("DEBUG: Successfully reactivated %d ujian after cleaning tingkat %s", 
@@ -621,7 +643,7 @@ func (ut *UjianTracker) cleanAllUjianSusulanByTingkat(tingkat models.Tingkat, re
 func (ut *UjianTracker) cleanExpiredUjianSusulan() {
     ut.mutex.Lock()
     defer ut.mutex.Unlock()
-    now := time.Now()
+    now := ut.Clock.Now()
     cleanedCount := 0
     var ujianIDsToReactivate []string
 
@@ -647,56 +669,26 @@ func (ut *UjianTracker) cleanExpiredUjianSusulan() {
     }
     if cleanedCount > 0 {
         log.Printf("DEBUG: Cleaned %d expired ujian susulan", cleanedCount)
-        if err := updateUjianStatusList(ut.DB, ujianIDsToReactivate); err != nil {
-            log.Printf("ERROR: Gagal update status ujian: %v", err)
+        if err := ut.jadwalRepo.HapusUjianSusulan(ujianIDsToReactivate); err != nil {
+            log.Printf("ERROR: Gagal menghapus ujian susulan: %v", err)
         }
-    }
-}
-
-func updateUjianStatusList(db *sql.DB, ujianIDs []string) error {
-    tx, err := db.Begin()
-    if err != nil {
-        return fmt.Errorf("gagal mulai transaksi: %w", err)
-    }
-
-    for _, id := range ujianIDs {
-        if err := updateUjianStatusInTx(tx, id); err != nil {
-            tx.Rollback()
-            return fmt.Errorf("gagal update status ujian ID %s: %w", id, err)
+        ut.jadwalKadaluarsa.Store(true)
+        if err := ut.ujianRepo.SelesaikanUjian(ujianIDsToReactivate); err != nil {
+            log.Printf("ERROR: Gagal update status ujian: %v", err)
         }
     }
-
-    return tx.Commit()
 }
 
-func updateUjianStatusInTx(tx *sql.Tx, ujianId string) error {
-    query := "UPDATE ujian SET status = 'selesai' WHERE id = $1"
-    result, err := tx.Exec(query, ujianId)
-    if err != nil {
-        return fmt.Errorf("gagal memperbarui status ujian: %w", err)
-    }
-    
-    rowsAffected, _ := result.RowsAffected()
-    log.Printf("DEBUG: Updated %d rows for ujian ID: %s in transaction", rowsAffected, ujianId)
+func (ut *UjianTracker) AddUjianSusulan(tingkat models.Tingkat, ujianData models.UjianData, durasiMenit int, sesiID string) error {
+    now := ut.Clock.Now()
     
-    if rowsAffected == 0 {
-        return fmt.Errorf("no rows affected - ujian ID might not exist: %s", ujianId)
-    }
+    // Jika ini adalah penambahan untuk sesi yang sedang aktif, reset cleaning terlebih dahulu
+    // Jadwal diambil sebelum lock karena reload cache ikut membaca UjianSusulan
+    jadwalData, err := ut.getJadwal(now)
     
-    return nil
-}
-
-
-
-
-func (ut *UjianTracker) AddUjianSusulan(tingkat models.Tingkat, ujianData models.UjianData, durasiMenit int, sesiID string) error {
     ut.mutex.Lock()
     defer ut.mutex.Unlock()
     
-    now := time.Now().In(time.Local)
-    
-    // Jika ini adalah penambahan untuk sesi yang sedang aktif, reset cleaning terlebih dahulu
-    jadwalData, err := repositories.GetJadwalUjian(ut.DB)
     if err == nil {
         if tingkatDataList, exists := jadwalData[tingkat]; exists {
             today := now.Format("2006-01-02")
@@ -715,18 +707,29 @@ func (ut *UjianTracker) AddUjianSusulan(tingkat models.Tingkat, ujianData models
     
     waktuBerakhir := now.Add(time.Duration(durasiMenit) * time.Minute)
     
+    ujianData.WaktuDibuat = now
+    ujianData.WaktuBerakhir = waktuBerakhir
+    ujianData.TiedToSesiID = sesiID
     ujianSusulan := models.UjianSusulanData{
+        Tingkat:       tingkat,
         UjianData:     ujianData,
         WaktuDibuat:   now,
         WaktuBerakhir: waktuBerakhir,
         SesiId:        sesiID,
     }
     
+    // Simpan ke database dulu supaya ujian susulan tetap ada setelah backend restart
+    if err := ut.jadwalRepo.SimpanUjianSusulan(ujianSusulan); err != nil {
+        return err
+    }
+    
     if ut.UjianSusulan == nil {
         ut.UjianSusulan = make(map[models.Tingkat][]models.UjianSusulanData)
     }
     
     ut.UjianSusulan[tingkat] = append(ut.UjianSusulan[tingkat], ujianSusulan)
+    // Waktu berakhir ujian susulan adalah batas transisi baru
+    ut.jadwalKadaluarsa.Store(true)
     
     log.Printf("DEBUG: Added ujian susulan %s to tingkat %s, sesi %s, berlaku sampai %s", 
         ujianData.ID, tingkat, sesiID, waktuBerakhir.Format("15:04:05"))
@@ -756,8 +759,8 @@ func (ut *UjianTracker) updateUjianStatusInSesi(sesi *models.SesiData, now time.
             ujian.JamSelesai = ujianSelesai.Format("15:04")
             
         } else {
-            ujianMulai, err := parseTime(ujian.JamMulai)
-            ujianSelesai, err2 := parseTime(ujian.JamSelesai)
+            ujianMulai, err := parseTime(now, ujian.JamMulai)
+            ujianSelesai, err2 := parseTime(now, ujian.JamSelesai)
             
             if err != nil || err2 != nil {
                 continue
@@ -786,7 +789,7 @@ func (ut *UjianTracker) updateUjianStatusInSesi(sesi *models.SesiData, now time.
         }
 
         if oldStatus != ujian.Status {
-            err := updateUjianStatus(ut.DB, ujian, ujian.Status)
+            err := ut.updateUjianStatus(ujian, ujian.Status)
             if err != nil {
                 log.Printf("Error updating ujian status: %v", err)
             }
@@ -827,7 +830,7 @@ func (ut *UjianTracker) updateFinishedSesiUjianStatus(sesi *models.SesiData, now
         ujian.SisaWaktuMulai = 0
         
         if oldStatus != newStatus {
-            err := updateUjianStatus(ut.DB, ujian, newStatus)
+            err := ut.updateUjianStatus(ujian, newStatus)
             if err != nil {
                 log.Printf("Error updating ujian status: %v", err)
             }
@@ -852,7 +855,7 @@ func generateRandomToken(length int) (string, error) {
 	return string(b), nil
 }
 
-func updateUjianStatus(db *sql.DB, ujian *models.UjianData, newStatus string) error {
+func (ut *UjianTracker) updateUjianStatus(ujian *models.UjianData, newStatus string) error {
 	start := time.Now() 
 
 	var token string
@@ -867,10 +870,11 @@ func updateUjianStatus(db *sql.DB, ujian *models.UjianData, newStatus string) er
 		token = ujian.Token
 	}
 
-	err := repositories.UpdateUjianStatus(db, ujian.ID, newStatus, token)
+	err := ut.ujianRepo.UpdateUjianStatus(ujian.ID, newStatus, token)
 	if err != nil {
 		return fmt.Errorf("error updating ujian status: %w", err)
 	}
+	ut.catatStatusUjian(ujian.ID, newStatus, token)
 
 	ujian.Status = newStatus
 	ujian.Token = token
@@ -880,30 +884,59 @@ func updateUjianStatus(db *sql.DB, ujian *models.UjianData, newStatus string) er
 	return nil
 }
 
-func parseTime(timeStr string) (time.Time, error) {
-	now := time.Now()
+// parseTime mengubah jam "15:04" menjadi waktu pada tanggal dan zona yang sama dengan now
+func parseTime(now time.Time, timeStr string) (time.Time, error) {
 	timeFormat := "15:04"
 	t, err := time.Parse(timeFormat, timeStr)
 	if err != nil {
 		return time.Time{}, err
 	}
-	location := time.Local 
-	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, location), nil
+	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), nil
 }
 
 
+// loadUjianSusulan memuat ulang ujian susulan yang masih berjalan dari database
+func (ut *UjianTracker) loadUjianSusulan() {
+	ujianList, err := ut.jadwalRepo.GetUjianSusulanAktif(ut.Clock.Now())
+	if err != nil {
+		log.Printf("Error loading ujian susulan: %v", err)
+		return
+	}
+
+	ut.mutex.Lock()
+	defer ut.mutex.Unlock()
+
+	ut.UjianSusulan = make(map[models.Tingkat][]models.UjianSusulanData)
+	for _, ujian := range ujianList {
+		ut.UjianSusulan[ujian.Tingkat] = append(ut.UjianSusulan[ujian.Tingkat], ujian)
+	}
+	log.Printf("Memuat %d ujian susulan aktif dari database", len(ujianList))
+}
+
+// StartTracking memuat data awal lalu menjalankan pembaruan tiap detik di goroutine sampai Stop dipanggil
 func (ut *UjianTracker) StartTracking() {
 	
+	ut.loadUjianSusulan()
 	ut.UpdateTrackingData()
 	
-	// Setup ticker untuk update setiap detik
+	// Ticker tiap detik hanya menghitung ulang hitung mundur dari cache jadwal,
+	// query ke database terjadi di getJadwal saat batas transisi atau sinyal perubahan jadwal
 	ticker := time.NewTicker(1 * time.Second)
 	go func() {
 		for {
 			select {
 			case <-ticker.C:
-				ut.UpdateTrackingData()
+			case <-ut.refresh:
+			case <-ut.stop:
+				ticker.Stop()
+				return
 			}
+			ut.UpdateTrackingData()
 		}
 	}()
+}
+
+// Stop menghentikan goroutine yang dijalankan StartTracking, aman dipanggil lebih dari sekali
+func (ut *UjianTracker) Stop() {
+	ut.stopOnce.Do(func() { close(ut.stop) })
 }
\ No newline at end of file
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"backend/clock"
+	"backend/models"
+	"backend/repositories"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Tanggal jadwal diparse sebagai tengah malam UTC, samakan zona lokal supaya hasil test tidak bergantung mesin
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
+
+// hitungJadwalRepository menghitung query jadwal untuk memastikan cache tracker bekerja
+type hitungJadwalRepository struct {
+	repositories.JadwalRepository
+	jadwalQueries int
+}
+
+func (r *hitungJadwalRepository) GetJadwalUjian(now time.Time) (map[models.Tingkat][]models.TingkatData, error) {
+	r.jadwalQueries++
+	return r.JadwalRepository.GetJadwalUjian(now)
+}
+
+func statusUjian(store *repositories.MemoryStore, ujianID string) (string, string) {
+	store.Lock()
+	defer store.Unlock()
+	for _, u := range store.Ujian {
+		if u.ID == ujianID {
+			return u.Status, u.Token
+		}
+	}
+	return "", ""
+}
+
+func jumlahUjianSusulan(store *repositories.MemoryStore) int {
+	store.Lock()
+	defer store.Unlock()
+	return len(store.UjianSusulan)
+}
+
+func newTestTracker(store *repositories.MemoryStore, clk clock.Clock) (*UjianTracker, chan models.ResponseDataUjian) {
+	broadcast := make(chan models.ResponseDataUjian, 1)
+	return NewUjianTracker(store, store, clk, broadcast), broadcast
+}
+
+var hariUjian = time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+func pukul(jam, menit int) time.Time {
+	return hariUjian.Add(time.Duration(jam)*time.Hour + time.Duration(menit)*time.Minute)
+}
+
+// storeDuaSesi: sesi 1 07:30-09:30 (MTK), sesi 2 10:00-12:00 (BIndo) untuk tingkat X
+func storeDuaSesi() *repositories.MemoryStore {
+	store := repositories.NewMemoryStore()
+	store.MataPelajaran = []models.MataPelajaran{
+		{ID: "mp-mtk", Tingkat: "X", Pelajaran: "MTK"},
+		{ID: "mp-bindo", Tingkat: "X", Pelajaran: "BIndo"},
+	}
+	store.Jadwal = []repositories.MemoryJadwal{{ID: "jadwal-1", Tanggal: hariUjian, Tingkat: "X"}}
+	store.Sesi = []repositories.MemorySesi{
+		{ID: "sesi-1", JadwalID: "jadwal-1", Sesi: 1, JamMulai: "07:30", JamSelesai: "09:30"},
+		{ID: "sesi-2", JadwalID: "jadwal-1", Sesi: 2, JamMulai: "10:00", JamSelesai: "12:00"},
+	}
+	store.Ujian = []repositories.MemoryUjian{
+		{ID: "ujian-mtk", MataPelajaranID: "mp-mtk", SesiID: "sesi-1", JamMulai: "07:30", JamSelesai: "09:30", Status: "pending", WaktuPengerjaan: 120},
+		{ID: "ujian-bindo", MataPelajaranID: "mp-bindo", SesiID: "sesi-2", JamMulai: "10:00", JamSelesai: "12:00", Status: "pending", WaktuPengerjaan: 120},
+	}
+	return store
+}
+
+func sesiTampil(result models.ResponseDataUjian) []models.SesiData {
+	if len(result.X) == 0 {
+		return nil
+	}
+	return result.X[0].SesiUjian
+}
+
+func TestUjianTrackerSepanjangHariUjian(t *testing.T) {
+	clk := clock.NewFake(pukul(7, 22))
+	store := storeDuaSesi()
+	ut, broadcast := newTestTracker(store, clk)
+
+	steps := []struct {
+		name   string
+		at     time.Time
+		before func(t *testing.T)
+		check  func(t *testing.T, sesi []models.SesiData)
+	}{
+		{
+			name: "sebelum jendela 5 menit",
+			at:   pukul(7, 22),
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 0 {
+					t.Fatalf("expected no sesi displayed, got %d", len(sesi))
+				}
+				if !ut.reloadBerikutnya.Equal(pukul(7, 25)) {
+					t.Errorf("expected next transition 07:25, got %s", ut.reloadBerikutnya.Format("15:04"))
+				}
+			},
+		},
+		{
+			name: "jendela 5 menit sebelum sesi 1",
+			at:   pukul(7, 26),
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 1 || sesi[0].ID != "sesi-1" {
+					t.Fatalf("expected sesi-1 displayed, got %+v", sesi)
+				}
+				if !sesi[0].HitungMundurSesiAktif || sesi[0].SisaWaktuSesi != 4 {
+					t.Errorf("expected sesi countdown of 4 minutes, got %+v", sesi[0])
+				}
+				ujian := sesi[0].Ujian[0]
+				if ujian.Status != "pending" || !ujian.HitungMundurAktif || ujian.SisaWaktuMulai != 4 {
+					t.Errorf("unexpected ujian state %+v", ujian)
+				}
+			},
+		},
+		{
+			name: "sesi 1 berjalan",
+			at:   pukul(8, 0),
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 1 || sesi[0].ID != "sesi-1" {
+					t.Fatalf("expected sesi-1 displayed, got %+v", sesi)
+				}
+				ujian := sesi[0].Ujian[0]
+				if ujian.Status != "active" || len(ujian.Token) != 5 {
+					t.Errorf("expected active ujian with token, got %+v", ujian)
+				}
+				if status, token := statusUjian(store, "ujian-mtk"); status != "active" || token != ujian.Token {
+					t.Errorf("expected status and token written to store, got %s/%s", status, token)
+				}
+			},
+		},
+		{
+			name: "jeda antar sesi",
+			at:   pukul(9, 40),
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 1 || sesi[0].ID != "sesi-1" {
+					t.Fatalf("expected finished sesi-1 still displayed, got %+v", sesi)
+				}
+				if sesi[0].SisaWaktuResetUjian != 15 || sesi[0].IsNextSesi != 2 || sesi[0].SisaWaktuSesi != 20 {
+					t.Errorf("unexpected sesi state %+v", sesi[0])
+				}
+				if status, _ := statusUjian(store, "ujian-mtk"); status != "selesai" {
+					t.Errorf("expected ujian-mtk selesai, got %s", status)
+				}
+			},
+		},
+		{
+			name: "jendela 5 menit sebelum sesi 2",
+			at:   pukul(9, 56),
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 1 || sesi[0].ID != "sesi-2" {
+					t.Fatalf("expected sesi-2 displayed, got %+v", sesi)
+				}
+				if sesi[0].Ujian[0].Status != "pending" {
+					t.Errorf("expected ujian-bindo pending, got %s", sesi[0].Ujian[0].Status)
+				}
+			},
+		},
+		{
+			name: "sesi 2 berjalan",
+			at:   pukul(10, 30),
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 1 || sesi[0].Ujian[0].Status != "active" {
+					t.Fatalf("expected active ujian-bindo, got %+v", sesi)
+				}
+			},
+		},
+		{
+			name: "setelah sesi terakhir",
+			at:   pukul(12, 30),
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 1 || sesi[0].ID != "sesi-2" {
+					t.Fatalf("expected sesi-2 displayed, got %+v", sesi)
+				}
+				if sesi[0].SisaWaktuResetUjian != 90 {
+					t.Errorf("expected 90 minutes to reset, got %d", sesi[0].SisaWaktuResetUjian)
+				}
+				if status, _ := statusUjian(store, "ujian-bindo"); status != "selesai" {
+					t.Errorf("expected ujian-bindo selesai, got %s", status)
+				}
+			},
+		},
+		{
+			name: "ujian susulan",
+			at:   pukul(12, 31),
+			before: func(t *testing.T) {
+				susulan := models.UjianData{ID: "ujian-mtk", MataPelajaran: "MTK", WaktuPengerjaan: 60, IsUjianSusulan: true}
+				if err := ut.AddUjianSusulan(models.TingkatX, susulan, 60, "sesi-1"); err != nil {
+					t.Fatalf("AddUjianSusulan: %v", err)
+				}
+			},
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 2 {
+					t.Fatalf("expected sesi-2 plus virtual susulan sesi, got %+v", sesi)
+				}
+				ujian := sesi[1].Ujian[0]
+				if ujian.ID != "ujian-mtk" || ujian.Status != "active" || ujian.SisaWaktuMulai != 60 {
+					t.Errorf("unexpected susulan ujian %+v", ujian)
+				}
+				if n := jumlahUjianSusulan(store); n != 1 {
+					t.Errorf("expected susulan persisted, got %d", n)
+				}
+			},
+		},
+		{
+			name: "ujian susulan berakhir",
+			at:   pukul(13, 32),
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 1 || sesi[0].ID != "sesi-2" {
+					t.Fatalf("expected only sesi-2, got %+v", sesi)
+				}
+				if n := jumlahUjianSusulan(store); n != 0 {
+					t.Errorf("expected expired susulan removed from store, got %d", n)
+				}
+			},
+		},
+		{
+			name: "reset 120 menit setelah sesi terakhir",
+			at:   pukul(14, 1),
+			check: func(t *testing.T, sesi []models.SesiData) {
+				if len(sesi) != 0 {
+					t.Fatalf("expected no sesi after reset, got %+v", sesi)
+				}
+			},
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			clk.Set(step.at)
+			if step.before != nil {
+				step.before(t)
+			}
+			ut.UpdateTrackingData()
+			step.check(t, sesiTampil(<-broadcast))
+		})
+	}
+}
+
+func TestUjianTrackerReloadHanyaPadaTransisi(t *testing.T) {
+	clk := clock.NewFake(pukul(8, 0))
+	store := storeDuaSesi()
+	jadwal := &hitungJadwalRepository{JadwalRepository: store}
+	broadcast := make(chan models.ResponseDataUjian, 1)
+	ut := NewUjianTracker(jadwal, store, clk, broadcast)
+
+	for detik := 0; detik < int(batasUmurCacheJadwal/time.Second); detik++ {
+		ut.UpdateTrackingData()
+		<-broadcast
+		clk.Advance(time.Second)
+	}
+	if jadwal.jadwalQueries != 1 {
+		t.Fatalf("expected 1 jadwal query before the next boundary, got %d", jadwal.jadwalQueries)
+	}
+
+	ut.UpdateTrackingData()
+	<-broadcast
+	if jadwal.jadwalQueries != 2 {
+		t.Fatalf("expected reload at cache age limit, got %d queries", jadwal.jadwalQueries)
+	}
+
+	ut.NotifyJadwalChanged()
+	ut.UpdateTrackingData()
+	<-broadcast
+	if jadwal.jadwalQueries != 3 {
+		t.Fatalf("expected reload after NotifyJadwalChanged, got %d queries", jadwal.jadwalQueries)
+	}
+}
+
+func TestUjianTrackerMemuatUlangUjianSusulan(t *testing.T) {
+	clk := clock.NewFake(pukul(12, 30))
+	store := storeDuaSesi()
+	store.UjianSusulan = []repositories.MemoryUjianSusulan{{
+		UjianID:       "ujian-mtk",
+		Tingkat:       models.TingkatX,
+		SesiID:        "sesi-1",
+		WaktuDibuat:   pukul(12, 0),
+		WaktuBerakhir: pukul(13, 0),
+	}}
+	ut, broadcast := newTestTracker(store, clk)
+
+	ut.loadUjianSusulan()
+	ut.UpdateTrackingData()
+	sesi := sesiTampil(<-broadcast)
+	if len(sesi) != 2 || sesi[1].Ujian[0].ID != "ujian-mtk" {
+		t.Fatalf("expected reloaded susulan in virtual sesi, got %+v", sesi)
+	}
+}
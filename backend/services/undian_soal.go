@@ -0,0 +1,136 @@
+package services
+
+import (
+	"backend/models"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+)
+
+// MemakaiUndian true bila ujian tidak memakai semua soal mata pelajaran
+func MemakaiUndian(ujian *models.Ujian) bool {
+	return ujian.JumlahSoal > 0 || len(ujian.KuotaSoal) > 0
+}
+
+// SeedSoal seed undian soal untuk satu siswa pada satu ujian. Seed dicatat bersama hasil undian
+// sehingga UndiSoal dengan bank soal yang sama bisa mengulang undian yang persis sama.
+func SeedSoal(ujianID, siswaDetailID string) int64 {
+	h := fnv.New64a()
+	for _, k := range []string{"undian", ujianID, siswaDetailID} {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+	}
+	return int64(h.Sum64())
+}
+
+// UndiSoal memilih soal untuk satu siswa dari bank soal (diurutkan menurut ID seperti GetSoalUjian).
+// Kuota dipenuhi lebih dulu sesuai urutannya, sisanya sampai jumlah soal diambil dari soal mana saja
+// yang belum terpilih. Bila jumlah 0, jumlah soal sama dengan total kuota. Hasilnya ID soal terpilih
+// menurut urutan bank soal; urutan tampil tetap diacak AcakSoal.
+func UndiSoal(bank []models.SoalInput, jumlah int, kuota []models.KuotaSoal, seed int64) ([]string, error) {
+	totalKuota := 0
+	for _, k := range kuota {
+		totalKuota += k.Jumlah
+	}
+	if jumlah == 0 {
+		jumlah = totalKuota
+	}
+	if totalKuota > jumlah {
+		return nil, fmt.Errorf("total kuota %d melebihi jumlah soal %d", totalKuota, jumlah)
+	}
+	if jumlah > len(bank) {
+		return nil, fmt.Errorf("bank soal hanya berisi %d soal, tidak cukup untuk %d soal", len(bank), jumlah)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	terpilih := make(map[string]bool, jumlah)
+	ambil := func(cocok func(models.SoalInput) bool, n int) int {
+		var kandidat []string
+		for _, soal := range bank {
+			if !terpilih[soal.ID] && cocok(soal) {
+				kandidat = append(kandidat, soal.ID)
+			}
+		}
+		rng.Shuffle(len(kandidat), func(a, b int) { kandidat[a], kandidat[b] = kandidat[b], kandidat[a] })
+		n = min(n, len(kandidat))
+		for _, id := range kandidat[:n] {
+			terpilih[id] = true
+		}
+		return n
+	}
+
+	for i, k := range kuota {
+		if didapat := ambil(func(soal models.SoalInput) bool { return CocokKuota(soal, k) }, k.Jumlah); didapat < k.Jumlah {
+			return nil, fmt.Errorf("kuota %d (%s) membutuhkan %d soal, hanya tersedia %d", i+1, namaKuota(k), k.Jumlah, didapat)
+		}
+	}
+	ambil(func(models.SoalInput) bool { return true }, jumlah-totalKuota)
+
+	result := make([]string, 0, jumlah)
+	for _, soal := range bank {
+		if terpilih[soal.ID] {
+			result = append(result, soal.ID)
+		}
+	}
+	return result, nil
+}
+
+// CocokKuota true bila soal termasuk kuota, topik dibandingkan tanpa membedakan huruf besar kecil
+func CocokKuota(soal models.SoalInput, kuota models.KuotaSoal) bool {
+	if kuota.Topik != "" && !strings.EqualFold(strings.TrimSpace(soal.Topik), strings.TrimSpace(kuota.Topik)) {
+		return false
+	}
+	return kuota.Kesulitan == "" || soal.Kesulitan == kuota.Kesulitan
+}
+
+func namaKuota(k models.KuotaSoal) string {
+	var bagian []string
+	if k.Topik != "" {
+		bagian = append(bagian, "topik "+k.Topik)
+	}
+	if k.Kesulitan != "" {
+		bagian = append(bagian, "kesulitan "+k.Kesulitan)
+	}
+	if len(bagian) == 0 {
+		return "semua soal"
+	}
+	return strings.Join(bagian, ", ")
+}
+
+// SoalTerpilih menyaring bank soal menjadi soal yang tercatat untuk siswa. soalIDs kosong berarti soal siswa
+// belum tercatat dan semua soal dikembalikan. Soal tercatat yang tidak ada di bank soal ikut hilang, sehingga
+// soal yang sudah diarsipkan harus dimuat dengan GetSoalUjianTercatat.
+func SoalTerpilih(bank []models.SoalInput, soalIDs []string) []models.SoalInput {
+	if len(soalIDs) == 0 {
+		return bank
+	}
+	dipilih := make(map[string]bool, len(soalIDs))
+	for _, id := range soalIDs {
+		dipilih[id] = true
+	}
+	result := make([]models.SoalInput, 0, len(soalIDs))
+	for _, soal := range bank {
+		if dipilih[soal.ID] {
+			result = append(result, soal)
+		}
+	}
+	return result
+}
+
+// SoalPeserta soal yang dikerjakan dan dinilai untuk satu siswa. Soal yang sudah tercatat selalu dipakai
+// walaupun pengaturan ujian berubah setelahnya. Bila ujian memakai undian tetapi undian siswa belum tercatat,
+// undian diulang dengan seed siswa sehingga hasilnya sama dengan yang akan dicatat saat siswa mulai.
+func SoalPeserta(bank []models.SoalInput, ujian *models.Ujian, peserta *models.UjianPeserta) ([]models.SoalInput, error) {
+	if peserta == nil {
+		return bank, nil
+	}
+	if len(peserta.SoalIDs) > 0 || !MemakaiUndian(ujian) {
+		return SoalTerpilih(bank, peserta.SoalIDs), nil
+	}
+	soalIDs, err := UndiSoal(bank, ujian.JumlahSoal, ujian.KuotaSoal, SeedSoal(ujian.ID, peserta.SiswaDetailID))
+	if err != nil {
+		return nil, err
+	}
+	return SoalTerpilih(bank, soalIDs), nil
+}
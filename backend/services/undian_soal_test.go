@@ -0,0 +1,104 @@
+package services
+
+import (
+	"backend/models"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func bankUndian() []models.SoalInput {
+	var bank []models.SoalInput
+	for i := 0; i < 20; i++ {
+		soal := models.SoalInput{ID: fmt.Sprintf("soal-%02d", i), Topik: "Aljabar", Kesulitan: models.KesulitanMudah}
+		if i%2 == 1 {
+			soal.Topik = "Geometri"
+		}
+		if i%5 == 0 {
+			soal.Kesulitan = models.KesulitanSulit
+		}
+		bank = append(bank, soal)
+	}
+	return bank
+}
+
+func TestUndiSoalKuota(t *testing.T) {
+	bank := bankUndian()
+	kuota := []models.KuotaSoal{{Kesulitan: models.KesulitanSulit, Jumlah: 3}, {Topik: "geometri", Jumlah: 4}}
+
+	ids, err := UndiSoal(bank, 10, kuota, SeedSoal("ujian-1", "siswa-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 10 {
+		t.Fatalf("expected 10 soal, got %v", ids)
+	}
+	terpilih := SoalTerpilih(bank, ids)
+	sulit, geometri := 0, 0
+	for _, soal := range terpilih {
+		if soal.Kesulitan == models.KesulitanSulit {
+			sulit++
+		}
+		if soal.Topik == "Geometri" {
+			geometri++
+		}
+	}
+	if sulit < 3 || geometri < 4 {
+		t.Errorf("kuota not met: %d sulit, %d geometri in %v", sulit, geometri, ids)
+	}
+
+	ulang, _ := UndiSoal(bank, 10, kuota, SeedSoal("ujian-1", "siswa-1"))
+	if !reflect.DeepEqual(ids, ulang) {
+		t.Errorf("same seed drew %v then %v", ids, ulang)
+	}
+	berbeda := false
+	for _, siswa := range []string{"siswa-2", "siswa-3", "siswa-4"} {
+		lain, _ := UndiSoal(bank, 10, kuota, SeedSoal("ujian-1", siswa))
+		berbeda = berbeda || !reflect.DeepEqual(ids, lain)
+	}
+	if !berbeda {
+		t.Errorf("every student drew the same soal %v", ids)
+	}
+}
+
+func TestUndiSoalTidakCukup(t *testing.T) {
+	bank := bankUndian()
+	tests := []struct {
+		name   string
+		jumlah int
+		kuota  []models.KuotaSoal
+	}{
+		{"bank terlalu kecil", 21, nil},
+		{"kuota kesulitan", 0, []models.KuotaSoal{{Kesulitan: models.KesulitanSulit, Jumlah: 5}}},
+		{"kuota melebihi jumlah", 2, []models.KuotaSoal{{Topik: "Aljabar", Jumlah: 3}}},
+	}
+	for _, tt := range tests {
+		if ids, err := UndiSoal(bank, tt.jumlah, tt.kuota, 1); err == nil {
+			t.Errorf("%s: expected an error, drew %v", tt.name, ids)
+		}
+	}
+}
+
+func TestSoalPeserta(t *testing.T) {
+	bank := bankUndian()
+	ujian := &models.Ujian{ID: "ujian-1"}
+	peserta := &models.UjianPeserta{UjianID: "ujian-1", SiswaDetailID: "siswa-1"}
+
+	if soal, _ := SoalPeserta(bank, ujian, peserta); len(soal) != len(bank) {
+		t.Errorf("ujian without undian must use the whole bank, got %d soal", len(soal))
+	}
+
+	ujian.JumlahSoal = 5
+	soal, err := SoalPeserta(bank, ujian, peserta)
+	if err != nil || len(soal) != 5 {
+		t.Fatalf("expected 5 soal, got %d (%v)", len(soal), err)
+	}
+
+	// Undian yang sudah tercatat tetap dipakai walaupun pengaturan ujian berubah
+	peserta.SoalIDs = []string{"soal-03", "soal-07"}
+	ujian.JumlahSoal = 0
+	soal, _ = SoalPeserta(bank, ujian, peserta)
+	if len(soal) != 2 || soal[0].ID != "soal-03" || soal[1].ID != "soal-07" {
+		t.Errorf("recorded draw ignored, got %+v", soal)
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import "time"
+
+// ToleransiPengumpulan jeda setelah batas waktu yang masih diterima untuk menutup
+// keterlambatan jaringan saat jawaban dikirim tepat ketika waktu habis
+const ToleransiPengumpulan = 2 * time.Minute
+
+// BatasWaktu waktu berakhirnya pengerjaan siswa, dihitung dari waktu mulai (token dimasukkan)
+func BatasWaktu(waktuMulai time.Time, waktuPengerjaanMenit int) time.Time {
+	return waktuMulai.Add(time.Duration(waktuPengerjaanMenit) * time.Minute)
+}
+
+// DurasiPengerjaan lama pengerjaan dalam detik menurut jam server, dibatasi waktu pengerjaan ujian.
+// terlambat bernilai true bila now sudah melewati batas waktu ditambah ToleransiPengumpulan.
+func DurasiPengerjaan(waktuMulai, now time.Time, waktuPengerjaanMenit int) (detik int, terlambat bool) {
+	batas := BatasWaktu(waktuMulai, waktuPengerjaanMenit)
+	if now.After(batas.Add(ToleransiPengumpulan)) {
+		terlambat = true
+	}
+	if now.After(batas) {
+		now = batas
+	}
+	if now.Before(waktuMulai) {
+		return 0, terlambat
+	}
+	return int(now.Sub(waktuMulai) / time.Second), terlambat
+}
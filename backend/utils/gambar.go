@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// imageSoalDir folder gambar soal di aplikasi web, sama dengan yang dipakai SaveImage
+const imageSoalDir = "../web-ulangan/public/image-soal"
+
+// SaveImageBytes menyimpan gambar soal yang tidak datang dari form, misalnya dari ZIP impor,
+// dan mengembalikan path web-nya seperti SaveImage
+func SaveImageBytes(filename string, data []byte) (string, error) {
+	if err := os.MkdirAll(imageSoalDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating directory: %v", err)
+	}
+
+	newFileName := uuid.New().String() + strings.ToLower(filepath.Ext(filename))
+	if err := os.WriteFile(filepath.Join(imageSoalDir, newFileName), data, 0644); err != nil {
+		return "", fmt.Errorf("error writing image: %v", err)
+	}
+	return "/image-soal/" + newFileName, nil
+}
+
+// DeleteImage menghapus gambar soal berdasarkan path web hasil SaveImage/SaveImageBytes
+func DeleteImage(webPath string) error {
+	name := filepath.Base(webPath)
+	if !strings.HasPrefix(webPath, "/image-soal/") || name == "." || name == "/" {
+		return fmt.Errorf("path gambar tidak valid: %s", webPath)
+	}
+	err := os.Remove(filepath.Join(imageSoalDir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
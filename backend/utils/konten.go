@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"backend/models"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Segmen potongan teks soal, Rumus true untuk LaTeX di antara $...$ (Blok untuk $$...$$)
+type Segmen struct {
+	Teks  string
+	Rumus bool
+	Blok  bool
+}
+
+// SegmenRumus memisahkan teks markdown dan rumus LaTeX. \$ dianggap tanda dolar biasa,
+// rumus yang tidak ditutup dikembalikan sebagai error.
+func SegmenRumus(teks string) ([]Segmen, error) {
+	var result []Segmen
+	var biasa strings.Builder
+	for i := 0; i < len(teks); i++ {
+		switch {
+		case teks[i] == '\\' && i+1 < len(teks) && teks[i+1] == '$':
+			biasa.WriteString(`\$`)
+			i++
+		case teks[i] == '$':
+			penutup, blok := "$", false
+			if strings.HasPrefix(teks[i:], "$$") {
+				penutup, blok = "$$", true
+			}
+			mulai := i + len(penutup)
+			akhir := indeksDolar(teks[mulai:], penutup)
+			if akhir < 0 {
+				return nil, fmt.Errorf("rumus tidak ditutup dengan %s", penutup)
+			}
+			if biasa.Len() > 0 {
+				result = append(result, Segmen{Teks: biasa.String()})
+				biasa.Reset()
+			}
+			result = append(result, Segmen{Teks: teks[mulai : mulai+akhir], Rumus: true, Blok: blok})
+			i = mulai + akhir + len(penutup) - 1
+		default:
+			biasa.WriteByte(teks[i])
+		}
+	}
+	if biasa.Len() > 0 {
+		result = append(result, Segmen{Teks: biasa.String()})
+	}
+	return result, nil
+}
+
+func indeksDolar(s, penutup string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], penutup) {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	mdGambar     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdTautan     = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	mdTebal      = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	mdMiring     = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:[^*_]*?\S)?)[*_]([^\w*]|$)`)
+	mdKode       = regexp.MustCompile("`([^`]*)`")
+	mdJudul      = regexp.MustCompile(`^\s{0,3}#{1,6}\s+`)
+	mdKutipan    = regexp.MustCompile(`^\s{0,3}>\s?`)
+	mdDaftar     = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+	mdEscape     = regexp.MustCompile(`\\([\\*_{}\[\]()#+\-.!$` + "`" + `])`)
+	latexPecah   = regexp.MustCompile(`\\[dt]?frac\s*\{([^{}]*)\}\s*\{([^{}]*)\}`)
+	latexAkar    = regexp.MustCompile(`\\sqrt\s*\{([^{}]*)\}`)
+	latexAkarN   = regexp.MustCompile(`\\sqrt\s*\[([^\]]*)\]\s*\{([^{}]*)\}`)
+	latexPangkat = regexp.MustCompile(`\^\s*(\{[^{}]*\}|[0-9a-zA-Z])`)
+	latexIndeks  = regexp.MustCompile(`_\s*(\{[^{}]*\}|[0-9a-zA-Z])`)
+	latexTeks    = regexp.MustCompile(`\\(?:text|mathrm|mathbf|mathit|operatorname)\s*\{([^{}]*)\}`)
+	latexNama    = regexp.MustCompile(`\\([a-zA-Z]+)`)
+)
+
+// simbolLatex pengganti perintah LaTeX untuk PDF. Font PDF memakai cp1252, jadi simbol di luar
+// cp1252 ditulis dengan ASCII.
+var simbolLatex = map[string]string{
+	"times": "×", "cdot": "·", "div": "÷", "pm": "±", "mp": "-/+",
+	"lt": "<", "gt": ">", "le": "<=", "leq": "<=", "ge": ">=", "geq": ">=", "ne": "!=", "neq": "!=",
+	"approx": "~", "equiv": "==", "infty": "tak hingga", "degree": "°", "circ": "°",
+	"rightarrow": "->", "to": "->", "leftarrow": "<-", "Rightarrow": "=>", "Leftrightarrow": "<=>",
+	"left": "", "right": "", "quad": " ", "qquad": "  ", "ldots": "...", "cdots": "...",
+	"sin": "sin", "cos": "cos", "tan": "tan", "log": "log", "ln": "ln", "lim": "lim",
+	"sum": "sum", "int": "integral", "mu": "µ",
+}
+
+var superskrip = map[string]string{"1": "¹", "2": "²", "3": "³"}
+
+// TeksPolos mengubah teks soal/pilihan menjadi teks biasa untuk PDF sesuai formatnya:
+// markdown dibuang penandanya, rumus LaTeX ditulis ulang sebagai teks (misalnya \frac{a}{b} menjadi (a)/(b)).
+func TeksPolos(teks, format string) string {
+	switch format {
+	case models.FormatMarkdown:
+		return markdownPolos(teks)
+	case models.FormatMarkdownLatex:
+		segmen, err := SegmenRumus(teks)
+		if err != nil {
+			return markdownPolos(teks)
+		}
+		var b strings.Builder
+		for _, s := range segmen {
+			switch {
+			case s.Blok:
+				b.WriteString("\n" + rumusPolos(s.Teks) + "\n")
+			case s.Rumus:
+				b.WriteString(rumusPolos(s.Teks))
+			default:
+				b.WriteString(markdownPolos(s.Teks))
+			}
+		}
+		return strings.TrimSpace(b.String())
+	default:
+		return teks
+	}
+}
+
+func markdownPolos(teks string) string {
+	var baris []string
+	for _, line := range strings.Split(teks, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		line = mdJudul.ReplaceAllString(line, "")
+		line = mdKutipan.ReplaceAllString(line, "")
+		line = mdDaftar.ReplaceAllString(line, "${1}• ")
+		line = mdGambar.ReplaceAllString(line, "[gambar: $1]")
+		line = mdTautan.ReplaceAllString(line, "$1 ($2)")
+		line = mdKode.ReplaceAllString(line, "$1")
+		line = mdTebal.ReplaceAllString(line, "$2")
+		line = mdMiring.ReplaceAllString(line, "$1$2$3")
+		line = mdEscape.ReplaceAllString(line, "$1")
+		baris = append(baris, line)
+	}
+	return strings.Join(baris, "\n")
+}
+
+func rumusPolos(rumus string) string {
+	rumus = strings.NewReplacer(`^\circ`, "°", `^{\circ}`, "°").Replace(rumus)
+	rumus = latexTeks.ReplaceAllString(rumus, "$1")
+	// Pecahan dan akar bisa bersarang, ulangi sampai tidak ada yang tersisa
+	for i := 0; i < 5; i++ {
+		sebelum := rumus
+		rumus = latexPecah.ReplaceAllString(rumus, "($1)/($2)")
+		rumus = latexAkarN.ReplaceAllString(rumus, "akar$1($2)")
+		rumus = latexAkar.ReplaceAllString(rumus, "akar($1)")
+		if rumus == sebelum {
+			break
+		}
+	}
+	rumus = latexPangkat.ReplaceAllStringFunc(rumus, func(m string) string {
+		isi := strings.TrimSpace(strings.TrimPrefix(m, "^"))
+		isi = strings.TrimSuffix(strings.TrimPrefix(isi, "{"), "}")
+		if s, ok := superskrip[strings.TrimSpace(isi)]; ok {
+			return s
+		}
+		return "^(" + isi + ")"
+	})
+	rumus = latexIndeks.ReplaceAllStringFunc(rumus, func(m string) string {
+		isi := strings.TrimSpace(strings.TrimPrefix(m, "_"))
+		isi = strings.TrimSuffix(strings.TrimPrefix(isi, "{"), "}")
+		if len(isi) > 1 {
+			return "_(" + isi + ")"
+		}
+		return isi
+	})
+	rumus = strings.NewReplacer(`\,`, " ", `\;`, " ", `\:`, " ", `\!`, "", `\%`, "%", `\{`, "{", `\}`, "}", `\\`, " ").Replace(rumus)
+	rumus = latexNama.ReplaceAllStringFunc(rumus, func(m string) string {
+		if s, ok := simbolLatex[m[1:]]; ok {
+			return s
+		}
+		// Huruf Yunani dan perintah lain ditulis dengan namanya, misalnya \pi menjadi pi
+		return m[1:]
+	})
+	rumus = strings.NewReplacer("{", "", "}", "").Replace(rumus)
+	return strings.Join(strings.Fields(rumus), " ")
+}
@@ -2,9 +2,15 @@ package utils
 
 import (
 	"backend/models"
+	"bytes"
 	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -93,3 +99,202 @@ func convertToMinutes(waktuStr string) string {
 
 	return fmt.Sprintf("%d:%02d", menit, detik)
 }
+
+// GenerateSoalPDF membuat naskah soal siap cetak untuk satu mata pelajaran, tanpa kunci jawaban.
+// Soal markdown/LaTeX dicetak sebagai teks biasa lewat TeksPolos.
+func GenerateSoalPDF(tingkat, mataPelajaran string, soalList []models.SoalInput) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(190, 10, tr(fmt.Sprintf("Soal Ujian %s - Tingkat %s", mataPelajaran, tingkat)), "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 11)
+	pdf.CellFormat(95, 8, "Nama  : ..............................", "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 8, "Kelas : ..............................", "", 1, "L", false, 0, "")
+	pdf.Ln(5)
+
+	for i, soal := range soalList {
+		pdf.SetFont("Arial", "B", 11)
+		pdf.CellFormat(10, 6, fmt.Sprintf("%d.", i+1), "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 11)
+		teks := TeksPolos(soal.Soal, soal.Format)
+		if soal.Tipe == models.TipePilihanGandaKompleks {
+			teks += " (pilih semua jawaban yang benar)"
+		}
+		pdf.MultiCell(180, 6, tr(teks), "", "L", false)
+		tambahGambarSoal(pdf, soal.Gambar)
+
+		switch soal.Tipe {
+		case models.TipeIsianSingkat:
+			pdf.SetX(20)
+			pdf.CellFormat(170, 8, "Jawab: ..................................................", "", 1, "L", false, 0, "")
+		case models.TipeEsai:
+			for j := 0; j < 5; j++ {
+				pdf.SetX(20)
+				pdf.CellFormat(170, 8, "", "B", 1, "L", false, 0, "")
+			}
+		default:
+			for j, p := range soal.Pilihan {
+				pdf.SetX(20)
+				pdf.CellFormat(8, 6, fmt.Sprintf("%c.", 'A'+j), "", 0, "L", false, 0, "")
+				pdf.MultiCell(162, 6, tr(TeksPolos(p.Text, soal.Format)), "", "L", false)
+			}
+		}
+		pdf.Ln(4)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateKunciJawabanPDF membuat lembar kunci jawaban terpisah dari naskah soal, nomor soal sama dengan GenerateSoalPDF
+func GenerateKunciJawabanPDF(tingkat, mataPelajaran string, soalList []models.SoalInput) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(190, 10, tr(fmt.Sprintf("Kunci Jawaban %s - Tingkat %s", mataPelajaran, tingkat)), "", 1, "C", false, 0, "")
+	pdf.Ln(5)
+
+	colWidths := []float64{12, 48, 110, 20}
+	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFillColor(240, 240, 240)
+	for i, header := range []string{"No", "Tipe", "Kunci", "Bobot"} {
+		pdf.CellFormat(colWidths[i], 8, header, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 10)
+	for i, soal := range soalList {
+		pdf.CellFormat(colWidths[0], 8, fmt.Sprintf("%d", i+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(colWidths[1], 8, namaTipeSoal(soal.Tipe), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(colWidths[2], 8, tr(kunciSoal(soal)), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(colWidths[3], 8, fmt.Sprintf("%d", soal.Bobot), "1", 0, "C", false, 0, "")
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateAnalisisPDF membuat laporan analisis butir soal satu ujian dalam tabel A4 mendatar
+func GenerateAnalisisPDF(analisis models.AnalisisUjian) ([]byte, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(277, 10, tr(fmt.Sprintf("Analisis Butir Soal %s - Tingkat %s", analisis.MataPelajaran, analisis.Tingkat)), "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(277, 6, tr(fmt.Sprintf("Peserta: %d    Rata-rata: %.2f    KR-20: %s",
+		analisis.JumlahPeserta, analisis.RataRata, models.AngkaAnalisis(analisis.KR20))), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	colWidths := []float64{10, 78, 30, 14, 14, 18, 14, 16, 22, 61}
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(240, 240, 240)
+	for i, header := range []string{"No", "Soal", "Topik / KD", "Peserta", "p", "Kesukaran", "D", "r pbis", "Daya Beda", "Sebaran Pilihan (* kunci, ! tidak berfungsi)"} {
+		pdf.CellFormat(colWidths[i], 8, header, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, soal := range analisis.Soal {
+		topik := strings.TrimSpace(strings.Join([]string{soal.KD, soal.Topik}, " "))
+		pdf.CellFormat(colWidths[0], 7, fmt.Sprintf("%d", soal.Nomor), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(colWidths[1], 7, tr(potongTeks(TeksPolos(soal.Soal, soal.Format), 60)), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(colWidths[2], 7, tr(potongTeks(topik, 22)), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(colWidths[3], 7, fmt.Sprintf("%d", soal.JumlahPeserta), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(colWidths[4], 7, models.AngkaAnalisis(soal.TingkatKesukaran), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(colWidths[5], 7, soal.KategoriKesukaran, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(colWidths[6], 7, models.AngkaAnalisis(soal.DayaBeda), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(colWidths[7], 7, models.AngkaAnalisis(soal.KorelasiBiserial), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(colWidths[8], 7, soal.KategoriDayaBeda, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(colWidths[9], 7, tr(potongTeks(soal.SebaranPilihan(), 48)), "1", 0, "L", false, 0, "")
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// potongTeks baris pertama teks, dipotong sampai n karakter supaya muat di satu sel tabel
+func potongTeks(teks string, n int) string {
+	teks = strings.TrimSpace(strings.SplitN(teks, "\n", 2)[0])
+	if r := []rune(teks); len(r) > n {
+		return string(r[:n-3]) + "..."
+	}
+	return teks
+}
+
+func namaTipeSoal(tipe string) string {
+	switch tipe {
+	case models.TipePilihanGandaKompleks:
+		return "Pilihan ganda kompleks"
+	case models.TipeIsianSingkat:
+		return "Isian singkat"
+	case models.TipeEsai:
+		return "Esai"
+	default:
+		return "Pilihan ganda"
+	}
+}
+
+// kunciSoal huruf pilihan benar, atau daftar jawaban yang diterima untuk isian singkat
+func kunciSoal(soal models.SoalInput) string {
+	var kunci []string
+	for i, p := range soal.Pilihan {
+		switch {
+		case soal.Tipe == models.TipeIsianSingkat:
+			kunci = append(kunci, TeksPolos(p.Text, soal.Format))
+		case p.Benar:
+			kunci = append(kunci, fmt.Sprintf("%c", 'A'+i))
+		}
+	}
+	if soal.Tipe == models.TipeEsai {
+		return "Dinilai guru"
+	}
+	if soal.Tipe == models.TipeIsianSingkat {
+		return strings.Join(kunci, " / ")
+	}
+	return strings.Join(kunci, ", ")
+}
+
+// tambahGambarSoal menyisipkan gambar soal bila filenya ada dan formatnya didukung gofpdf,
+// gambar yang tidak bisa dibaca dilewati supaya naskah tetap bisa dicetak
+func tambahGambarSoal(pdf *gofpdf.Fpdf, gambar *string) {
+	if gambar == nil || *gambar == "" {
+		return
+	}
+	filePath := filepath.Join(imageSoalDir, filepath.Base(*gambar))
+	tipe := strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+	if tipe != "jpg" && tipe != "jpeg" && tipe != "png" && tipe != "gif" {
+		return
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("Gambar soal %s tidak ditemukan: %v", *gambar, err)
+		return
+	}
+	defer f.Close()
+	if _, _, err := image.DecodeConfig(f); err != nil {
+		log.Printf("Gambar soal %s tidak dapat dibaca: %v", *gambar, err)
+		return
+	}
+
+	pdf.ImageOptions(filePath, 20, pdf.GetY()+2, 80, 0, true, gofpdf.ImageOptions{ImageType: tipe, ReadDpi: true}, 0, "")
+	pdf.Ln(2)
+}
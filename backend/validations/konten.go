@@ -0,0 +1,197 @@
+package validators
+
+import (
+	"backend/models"
+	"backend/utils"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	htmlKomentar           = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTag                = regexp.MustCompile(`(?i)</?[a-z][a-z0-9-]*(\s[^<>]*)?/?>`)
+	tautanInline           = regexp.MustCompile(`(\]\(\s*)(<[^<>\n]*>|[^\s)]+)`)
+	tautanReferensi        = regexp.MustCompile(`(?m)^( {0,3}\[[^\]]+\]:[ \t]*)(<[^<>\n]*>|\S+)`)
+	tautanOtomatis         = regexp.MustCompile(`<[a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\s]*>`)
+	perintahLatexTerlarang = regexp.MustCompile(`\\(href|url|includegraphics|input|include|write|immediate|openout|def|let|newcommand|renewcommand|html[a-zA-Z]*)\b`)
+)
+
+// SanitizeSoal menyeragamkan format dan kesulitan soal (huruf besar, alias plain/markdown/latex diterima) lalu membersihkan
+// teks soal dan pilihan markdown dari HTML mentah dan tautan selain http, https, mailto, atau relatif. < dan > di dalam
+// rumus LaTeX ditulis ulang menjadi \lt dan \gt. Dipanggil sebelum ValidateSoal/ValidateSoalInput.
+func SanitizeSoal(soal *models.SoalInput) {
+	switch strings.ToUpper(strings.TrimSpace(soal.Format)) {
+	case "":
+		soal.Format = ""
+	case "PLAIN", "POLOS":
+		soal.Format = models.FormatPolos
+	case "MARKDOWN", "MD":
+		soal.Format = models.FormatMarkdown
+	case "MARKDOWN_LATEX", "LATEX":
+		soal.Format = models.FormatMarkdownLatex
+	}
+	soal.Topik = strings.TrimSpace(soal.Topik)
+	soal.KD = strings.TrimSpace(soal.KD)
+	soal.Kesulitan = strings.ToUpper(strings.TrimSpace(soal.Kesulitan))
+	if soal.Format != models.FormatMarkdown && soal.Format != models.FormatMarkdownLatex {
+		return
+	}
+
+	soal.Soal = sanitasiMarkdown(soal.Soal, soal.Format == models.FormatMarkdownLatex)
+	for i := range soal.Pilihan {
+		soal.Pilihan[i].Text = sanitasiMarkdown(soal.Pilihan[i].Text, soal.Format == models.FormatMarkdownLatex)
+	}
+}
+
+func sanitasiMarkdown(teks string, latex bool) string {
+	if !latex {
+		return strings.TrimSpace(bersihkanMarkdown(teks))
+	}
+	segmen, err := utils.SegmenRumus(teks)
+	if err != nil {
+		// Rumus rusak ditolak validateFormat, cukup bersihkan seluruh teks
+		return strings.TrimSpace(bersihkanMarkdown(teks))
+	}
+	var b strings.Builder
+	for _, s := range segmen {
+		switch {
+		case s.Blok:
+			b.WriteString("$$" + rumusTanpaTag(s.Teks) + "$$")
+		case s.Rumus:
+			b.WriteString("$" + rumusTanpaTag(s.Teks) + "$")
+		default:
+			b.WriteString(bersihkanMarkdown(s.Teks))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// bersihkanMarkdown membuang komentar dan tag HTML, mengganti tujuan tautan yang tidak aman dengan #, lalu
+// meloloskan setiap < yang masih bisa membuka tag (tag tanpa penutup, autolink berbahaya, atau tag yang
+// terbentuk dari sisa pembuangan) kecuali autolink yang aman.
+func bersihkanMarkdown(s string) string {
+	s = htmlKomentar.ReplaceAllString(s, "")
+	s = htmlTag.ReplaceAllString(s, "")
+	gantiTujuan := func(pola *regexp.Regexp) {
+		s = pola.ReplaceAllStringFunc(s, func(m string) string {
+			bagian := pola.FindStringSubmatch(m)
+			if tautanAman(bagian[2]) {
+				return m
+			}
+			return bagian[1] + "#"
+		})
+	}
+	gantiTujuan(tautanInline)
+	gantiTujuan(tautanReferensi)
+
+	aman := map[int]bool{}
+	for _, idx := range tautanOtomatis.FindAllStringIndex(s, -1) {
+		if tautanAman(s[idx[0]:idx[1]]) {
+			aman[idx[0]] = true
+		}
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '<' && !aman[i] && i+1 < len(s) && pembukaTag(s[i+1]) {
+			b.WriteString("&lt;")
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func pembukaTag(c byte) bool {
+	return c == '/' || c == '!' || c == '?' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// tautanAman menerima tujuan tautan relatif atau berskema http, https, dan mailto. Entitas HTML, garis
+// miring terbalik, dan spasi dibuang dulu karena peramban dan pengurai markdown juga mengabaikannya.
+func tautanAman(tujuan string) bool {
+	t := html.UnescapeString(strings.Trim(tujuan, "<>"))
+	t = strings.Map(func(r rune) rune {
+		if r == '\\' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, t)
+	i := strings.IndexAny(t, ":/?#")
+	if i < 0 || t[i] != ':' {
+		return true
+	}
+	switch t[:i] {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// rumusTanpaTag menulis < dan > di rumus sebagai \lt dan \gt agar tidak pernah dibaca sebagai HTML
+// oleh pengurai markdown yang tidak mengenali rumus.
+func rumusTanpaTag(rumus string) string {
+	return strings.NewReplacer("<", `\lt `, ">", `\gt `).Replace(rumus)
+}
+
+// validateFormat memeriksa format soal dan, untuk MARKDOWN_LATEX, rumus di soal dan setiap pilihan
+func validateFormat(soal models.SoalInput, index int) error {
+	switch soal.Format {
+	case "", models.FormatPolos, models.FormatMarkdown:
+		return nil
+	case models.FormatMarkdownLatex:
+	default:
+		return fmt.Errorf("format soal %d harus %s, %s, atau %s", index+1,
+			models.FormatPolos, models.FormatMarkdown, models.FormatMarkdownLatex)
+	}
+
+	if err := validateRumus(soal.Soal); err != nil {
+		return fmt.Errorf("rumus pada soal %d: %v", index+1, err)
+	}
+	for j, pilihan := range soal.Pilihan {
+		if err := validateRumus(pilihan.Text); err != nil {
+			return fmt.Errorf("rumus pada pilihan %d soal %d: %v", j+1, index+1, err)
+		}
+	}
+	return nil
+}
+
+func validateRumus(teks string) error {
+	segmen, err := utils.SegmenRumus(teks)
+	if err != nil {
+		return err
+	}
+	for _, s := range segmen {
+		if !s.Rumus {
+			continue
+		}
+		if strings.TrimSpace(s.Teks) == "" {
+			return fmt.Errorf("rumus kosong")
+		}
+		if m := perintahLatexTerlarang.FindStringSubmatch(s.Teks); m != nil {
+			return fmt.Errorf("perintah \\%s tidak diizinkan", m[1])
+		}
+		if strings.ContainsAny(s.Teks, "<>") {
+			return fmt.Errorf("gunakan \\lt dan \\gt untuk < dan > di $%s$", s.Teks)
+		}
+		kurung := 0
+		for i := 0; i < len(s.Teks); i++ {
+			switch s.Teks[i] {
+			case '\\':
+				i++
+			case '{':
+				kurung++
+			case '}':
+				kurung--
+			}
+			if kurung < 0 {
+				break
+			}
+		}
+		if kurung != 0 {
+			return fmt.Errorf("kurung kurawal tidak seimbang di $%s$", s.Teks)
+		}
+	}
+	return nil
+}
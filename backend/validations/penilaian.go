@@ -0,0 +1,51 @@
+package validators
+
+import (
+	"backend/models"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxPenaltiSalah penalti tertinggi, 1 berarti setiap jawaban salah mengurangi seluruh bobot soalnya
+const MaxPenaltiSalah = 1
+
+func validatePenaltiSalah(penalti float64) error {
+	if !(penalti >= 0 && penalti <= MaxPenaltiSalah) {
+		return fmt.Errorf("penaltiSalah harus antara 0 dan %d", MaxPenaltiSalah)
+	}
+	return nil
+}
+
+// ValidatePengaturanPenilaian memeriksa pengaturan penilaian satu ujian. Field nil berarti ujian
+// mengikuti pengaturan mata pelajarannya sehingga tidak perlu diperiksa, nilaiParsial cukup true atau false.
+func ValidatePengaturanPenilaian(pengaturan models.PengaturanPenilaianRequest) error {
+	if pengaturan.PenaltiSalah != nil {
+		return validatePenaltiSalah(*pengaturan.PenaltiSalah)
+	}
+	return nil
+}
+
+// ValidatePengaturanMataPelajaran memeriksa perubahan pengaturan mata pelajaran, pelajaran dirapikan di tempat.
+// Minimal satu pengaturan harus dikirim.
+func ValidatePengaturanMataPelajaran(pengaturan *models.PengaturanMataPelajaranRequest) error {
+	pengaturan.Pelajaran = strings.TrimSpace(pengaturan.Pelajaran)
+	if !isValidTingkat(pengaturan.Tingkat) {
+		return errors.New("tingkat harus X, XI, atau XII")
+	}
+	if pengaturan.Pelajaran == "" {
+		return errors.New("pelajaran tidak boleh kosong")
+	}
+	if pengaturan.PenaltiSalah == nil && pengaturan.NilaiParsial == nil && pengaturan.JumlahPilihan == nil {
+		return errors.New("minimal satu pengaturan harus diisi: penaltiSalah, nilaiParsial, atau jumlahPilihan")
+	}
+	if pengaturan.PenaltiSalah != nil {
+		if err := validatePenaltiSalah(*pengaturan.PenaltiSalah); err != nil {
+			return err
+		}
+	}
+	if j := pengaturan.JumlahPilihan; j != nil && (*j < MinJumlahPilihan || *j > MaxJumlahPilihan) {
+		return fmt.Errorf("jumlahPilihan harus antara %d dan %d", MinJumlahPilihan, MaxJumlahPilihan)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package validators
+
+import (
+	"backend/models"
+	"fmt"
+	"strings"
+)
+
+// MaxJumlahSoalUjian batas jumlah soal yang diundi per siswa
+const MaxJumlahSoalUjian = 500
+
+func isValidKesulitan(kesulitan string) bool {
+	switch kesulitan {
+	case models.KesulitanMudah, models.KesulitanSedang, models.KesulitanSulit:
+		return true
+	}
+	return false
+}
+
+// ValidatePengaturanSoal memeriksa jumlah soal dan kuota undian ujian. Topik dirapikan dan kesulitan
+// diubah ke huruf besar di tempat. Kecukupan bank soal diperiksa terpisah dengan services.UndiSoal.
+func ValidatePengaturanSoal(pengaturan *models.PengaturanSoalRequest) error {
+	if pengaturan.JumlahSoal < 0 || pengaturan.JumlahSoal > MaxJumlahSoalUjian {
+		return fmt.Errorf("jumlahSoal harus antara 0 (semua soal) dan %d", MaxJumlahSoalUjian)
+	}
+
+	total := 0
+	for i := range pengaturan.KuotaSoal {
+		k := &pengaturan.KuotaSoal[i]
+		k.Topik = strings.TrimSpace(k.Topik)
+		k.Kesulitan = strings.ToUpper(strings.TrimSpace(k.Kesulitan))
+		if k.Jumlah < 1 {
+			return fmt.Errorf("jumlah kuota %d minimal 1", i+1)
+		}
+		if k.Topik == "" && k.Kesulitan == "" {
+			return fmt.Errorf("kuota %d harus mengisi topik atau kesulitan", i+1)
+		}
+		if k.Kesulitan != "" && !isValidKesulitan(k.Kesulitan) {
+			return fmt.Errorf("kesulitan kuota %d harus %s, %s, atau %s", i+1, models.KesulitanMudah, models.KesulitanSedang, models.KesulitanSulit)
+		}
+		total += k.Jumlah
+	}
+	if pengaturan.JumlahSoal > 0 && total > pengaturan.JumlahSoal {
+		return fmt.Errorf("total kuota %d melebihi jumlahSoal %d", total, pengaturan.JumlahSoal)
+	}
+	if total > MaxJumlahSoalUjian {
+		return fmt.Errorf("total kuota melebihi %d soal", MaxJumlahSoalUjian)
+	}
+	return nil
+}
@@ -6,15 +6,25 @@ import (
 	"fmt"
 	"mime/multipart"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
 )
 
 // Constants for validation
 const (
-	MaxFileSize       = 5 * 1024 * 1024 // 5MB
-	MinPilihanLength  = 5
-	MinSoalDataLength = 1
+	MaxFileSize          = 5 * 1024 * 1024 // 5MB
+	MinJumlahPilihan     = 2               // benar/salah
+	MaxJumlahPilihan     = 6
+	DefaultJumlahPilihan = 5 // A-E, dipakai bila soal maupun mata pelajaran tidak mengatur jumlah pilihan
+	MinSoalDataLength    = 1
+	MaxBobotSoal         = 100 // bobot 0 berarti memakai bobot default 1
+	MaxPanjangTopik      = 100
 )
 
+// kodeKD kode kompetensi dasar kurikulum 2013, misalnya 3.2 atau 4.10
+var kodeKD = regexp.MustCompile(`^[0-9]{1,2}(\.[0-9]{1,2}){1,2}$`)
+
 // AcceptedImageTypes contains valid image MIME types
 var AcceptedImageTypes = map[string]bool{
 	"image/jpeg": true,
@@ -22,8 +32,9 @@ var AcceptedImageTypes = map[string]bool{
 	"image/webp": true,
 }
 
-// ValidateSoalInput validates the entire soal input
-func ValidateSoalInput(tingkat, pelajaran string, soalData []models.SoalInput, files map[string][]*multipart.FileHeader) error {
+// ValidateSoalInput validates the entire soal input. jumlahPilihan is the option count configured
+// on the mata pelajaran (0 for the default), soal with their own JumlahPilihan override it.
+func ValidateSoalInput(tingkat, pelajaran string, jumlahPilihan int, soalData []models.SoalInput, files map[string][]*multipart.FileHeader) error {
 	// Validate tingkat
 	if !isValidTingkat(tingkat) {
 		return errors.New("tingkat harus X, XI, atau XII")
@@ -41,7 +52,7 @@ func ValidateSoalInput(tingkat, pelajaran string, soalData []models.SoalInput, f
 
 	// Validate each soal
 	for i, soal := range soalData {
-		if err := validateSingleSoal(soal, i, files); err != nil {
+		if err := validateSingleSoal(soal, i, jumlahPilihan, files); err != nil {
 			return err
 		}
 	}
@@ -59,8 +70,35 @@ func isValidTingkat(tingkat string) bool {
 	return validTingkat[tingkat]
 }
 
+// ValidateSoal validates one soal without an uploaded image, used by imports that carry images separately
+func ValidateSoal(soal models.SoalInput, index, jumlahPilihan int) error {
+	return validateSingleSoal(soal, index, jumlahPilihan, nil)
+}
+
+// ValidateGambar validates the size and extension of a soal image
+func ValidateGambar(filename string, size int64, index int) error {
+	if size > MaxFileSize {
+		return fmt.Errorf("ukuran file untuk soal %d terlalu besar. Maksimal 5MB", index+1)
+	}
+
+	fileType := ""
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg":
+		fileType = "image/jpeg"
+	case ".png":
+		fileType = "image/png"
+	case ".webp":
+		fileType = "image/webp"
+	}
+
+	if !AcceptedImageTypes[fileType] {
+		return fmt.Errorf("format file untuk soal %d harus jpeg, jpg, png, atau webp", index+1)
+	}
+	return nil
+}
+
 // validateSingleSoal validates a single soal entry
-func validateSingleSoal(soal models.SoalInput, index int, files map[string][]*multipart.FileHeader) error {
+func validateSingleSoal(soal models.SoalInput, index, jumlahPilihan int, files map[string][]*multipart.FileHeader) error {
 	// Validate soal text
 	if soal.Soal == "" {
 		return fmt.Errorf("soal %d tidak boleh kosong", index+1)
@@ -71,39 +109,79 @@ func validateSingleSoal(soal models.SoalInput, index int, files map[string][]*mu
 	if fileHeaders, exists := files[fileKey]; exists && len(fileHeaders) > 0 {
 		file := fileHeaders[0]
 		
-		// Validate file size
-		if file.Size > MaxFileSize {
-			return fmt.Errorf("ukuran file untuk soal %d terlalu besar. Maksimal 5MB", index+1)
+		// Validate file size and type
+		if err := ValidateGambar(file.Filename, file.Size, index); err != nil {
+			return err
 		}
 		
-		// Open file to check type
+		// Open file to check it is readable
 		f, err := file.Open()
 		if err != nil {
 			return fmt.Errorf("gagal membuka file untuk soal %d: %v", index+1, err)
 		}
 		defer f.Close()
-		
-		// Get file extension
-		ext := filepath.Ext(file.Filename)
-		fileType := ""
-		
-		switch ext {
-		case ".jpg", ".jpeg":
-			fileType = "image/jpeg"
-		case ".png":
-			fileType = "image/png"
-		case ".webp":
-			fileType = "image/webp"
+	}
+
+	if soal.Bobot < 0 || soal.Bobot > MaxBobotSoal {
+		return fmt.Errorf("bobot soal %d harus antara 1 dan %d", index+1, MaxBobotSoal)
+	}
+
+	if err := validateFormat(soal, index); err != nil {
+		return err
+	}
+
+	if soal.Kesulitan != "" && !isValidKesulitan(soal.Kesulitan) {
+		return fmt.Errorf("kesulitan soal %d harus %s, %s, atau %s", index+1, models.KesulitanMudah, models.KesulitanSedang, models.KesulitanSulit)
+	}
+	if utf8.RuneCountInString(soal.Topik) > MaxPanjangTopik {
+		return fmt.Errorf("topik soal %d maksimal %d karakter", index+1, MaxPanjangTopik)
+	}
+	if soal.KD != "" && !kodeKD.MatchString(soal.KD) {
+		return fmt.Errorf("KD soal %d harus berupa kode kompetensi dasar seperti 3.2, bukan %q", index+1, soal.KD)
+	}
+
+	switch soal.Tipe {
+	case "", models.TipePilihanGanda, models.TipePilihanGandaKompleks:
+		return validatePilihanGanda(soal, index, jumlahPilihan)
+	case models.TipeIsianSingkat:
+		// Pilihan soal isian singkat berisi jawaban yang diterima
+		if len(soal.Pilihan) == 0 {
+			return fmt.Errorf("soal %d harus memiliki minimal satu jawaban yang diterima", index+1)
 		}
-		
-		if !AcceptedImageTypes[fileType] {
-			return fmt.Errorf("format file untuk soal %d harus jpeg, jpg, png, atau webp", index+1)
+		for j, pilihan := range soal.Pilihan {
+			if strings.TrimSpace(pilihan.Text) == "" {
+				return fmt.Errorf("jawaban yang diterima %d untuk soal %d tidak boleh kosong", j+1, index+1)
+			}
 		}
+	case models.TipeEsai:
+		if len(soal.Pilihan) != 0 {
+			return fmt.Errorf("soal esai %d tidak boleh memiliki pilihan jawaban", index+1)
+		}
+	default:
+		return fmt.Errorf("tipe soal %d harus %s, %s, %s, atau %s", index+1,
+			models.TipePilihanGanda, models.TipePilihanGandaKompleks, models.TipeIsianSingkat, models.TipeEsai)
+	}
+
+	return nil
+}
+
+// validatePilihanGanda validates the choices of a multiple choice soal
+func validatePilihanGanda(soal models.SoalInput, index, jumlahPilihan int) error {
+	// Jumlah pilihan soal menimpa pengaturan mata pelajaran
+	jumlah := soal.JumlahPilihan
+	if jumlah == 0 {
+		jumlah = jumlahPilihan
+	}
+	if jumlah == 0 {
+		jumlah = DefaultJumlahPilihan
+	}
+	if jumlah < MinJumlahPilihan || jumlah > MaxJumlahPilihan {
+		return fmt.Errorf("jumlah pilihan soal %d harus antara %d dan %d, bukan %d", index+1, MinJumlahPilihan, MaxJumlahPilihan, jumlah)
 	}
 
 	// Validate pilihan
-	if len(soal.Pilihan) != MinPilihanLength {
-		return fmt.Errorf("soal %d harus memiliki tepat 5 pilihan jawaban", index+1)
+	if len(soal.Pilihan) != jumlah {
+		return fmt.Errorf("soal %d harus memiliki tepat %d pilihan jawaban, ditemukan %d", index+1, jumlah, len(soal.Pilihan))
 	}
 
 	// Check for empty pilihan text
@@ -113,18 +191,22 @@ func validateSingleSoal(soal models.SoalInput, index int, files map[string][]*mu
 		}
 	}
 
-	// Check if at least one answer is marked as correct
-	hasCorrectAnswer := false
+	// Count answers marked as correct
+	jumlahBenar := 0
 	for _, pilihan := range soal.Pilihan {
 		if pilihan.Benar {
-			hasCorrectAnswer = true
-			break
+			jumlahBenar++
 		}
 	}
 
-	if !hasCorrectAnswer {
+	if jumlahBenar == 0 {
 		return fmt.Errorf("soal %d harus memiliki minimal satu jawaban benar", index+1)
 	}
 
+	// Pilihan ganda biasa hanya boleh punya satu kunci, pilihan ganda kompleks boleh lebih dari satu
+	if soal.Tipe != models.TipePilihanGandaKompleks && jumlahBenar > 1 {
+		return fmt.Errorf("soal %d bertipe %s harus memiliki tepat satu jawaban benar", index+1, models.TipePilihanGanda)
+	}
+
 	return nil
-}
\ No newline at end of file
+}